@@ -1,31 +1,662 @@
 package main
 
 import (
+	"bytes"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/sujanks/data-gen-app/pkg"
+	"github.com/sujanks/data-gen-app/pkg/infer"
+	"github.com/sujanks/data-gen-app/pkg/server"
 	"github.com/sujanks/data-gen-app/pkg/sink"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+const (
+	// progressEvery is how many records pass between progress checks
+	progressEvery = 1000
+	// progressInterval is the minimum time between progress lines
+	progressInterval = 5 * time.Second
+	// manifestStdin is the -manifest value that reads the manifest from standard input
+	manifestStdin = "-"
 )
 
 func main() {
-	profile := os.Getenv("PROFILE")
-	records := os.Getenv("RECORDS")
-	count, _ := strconv.Atoi(records)
-	sink := getDataSink(profile)
-	manifestPath := fmt.Sprintf("./manifest/%s.yaml", profile)
-	pkg.GenerateData(sink, count, manifestPath)
+	manifest := flag.String("manifest", os.Getenv("MANIFEST"), "manifest file, overrides the PROFILE lookup, - reads it from stdin")
+	out := flag.String("out", os.Getenv("OUTPUT_DIR"), "output directory for file formats, defaults to ./output")
+	format := flag.String("format", "", "write files in this format, csv, json or bigquery, instead of using SINK")
+	verbose := flag.Bool("verbose", os.Getenv("VERBOSE") != "", "log how each column's values were produced after the run")
+	records := flag.String("records", os.Getenv("RECORDS"), "record count, optionally with per-table counts such as 1000,users=100")
+	locale := flag.String("locale", os.Getenv("LOCALE"), "language of generated names, such as de or fr_FR, defaults to English")
+	strict := flag.Bool("strict", os.Getenv("STRICT") != "", "fail the run on rule and condition expression errors instead of logging them")
+	limitDuration := flag.Duration("limit-duration", envDuration("LIMIT_DURATION"), "generate in rounds of the record count until this much time has passed, such as 10m")
+	dialect := flag.String("dialect", os.Getenv("DIALECT"), "SQL dialect of MODE=ddl statements, postgres (default), mysql or sqlite")
+	maxParentKeys := flag.Int("max-parent-keys", envInt("MAX_PARENT_KEYS"), "keep a random sample of at most this many keys per parent column for foreign keys, 0 keeps all")
+	dictionaryFormat := flag.String("dictionary-format", os.Getenv("DICTIONARY_FORMAT"), "format of the MODE=dictionary data dictionary, markdown (default) or json")
+	metrics := flag.Bool("metrics", os.Getenv("METRICS") != "", "log how long each table took and its records per second after the run")
+	examples := flag.Int("examples", envInt("EXAMPLES"), "example records per table printed by MODE=openapi, defaults to 1")
+	profileFlag := flag.String("profile", os.Getenv("PROFILE"), "manifest profile, or a comma separated list of profiles generated one after another")
+	flag.Parse()
+
+	count, tableCounts, err := parseRecordCounts(*records)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mode := os.Getenv("MODE")
+	if mode == "http" {
+		addr := os.Getenv("ADDR")
+		if addr == "" {
+			addr = ":8080"
+		}
+		log.Printf("serving generation on %s", addr)
+		log.Fatal(http.ListenAndServe(addr, server.NewHandler()))
+	}
+
+	profiles := parseProfiles(*profileFlag)
+	cfg := runConfig{
+		manifest:    *manifest,
+		outputDir:   *out,
+		format:      *format,
+		verbose:     *verbose,
+		count:       count,
+		tableCounts: tableCounts,
+		locale:      *locale,
+		strict:      *strict,
+		keysPath:    os.Getenv("PARENT_KEYS"),
+		duration:    *limitDuration,
+		dialect:     *dialect,
+
+		dictionaryFormat: *dictionaryFormat,
+		maxParentKeys:    *maxParentKeys,
+		metrics:          *metrics,
+		examples:         *examples,
+	}
+	if len(profiles) > 1 {
+		if cfg.manifest != "" {
+			log.Fatal("a manifest cannot be combined with several profiles")
+		}
+		if cfg.keysPath != "" {
+			log.Fatal("PARENT_KEYS cannot be combined with several profiles")
+		}
+		cfg.namespaced = true
+	}
+	if cfg.manifest == manifestStdin {
+		if mode == "scaffold" || mode == "infer" {
+			log.Fatalf("MODE=%s writes the manifest, it cannot be read from stdin", mode)
+		}
+		// Standard input can only be read once, the content is reused wherever the run loads the manifest
+		if cfg.manifestData, err = io.ReadAll(os.Stdin); err != nil {
+			log.Fatalf("failed to read the manifest from stdin: %v", err)
+		}
+	}
+	for _, profile := range profiles {
+		if err := run(mode, profile, cfg); err != nil {
+			if len(profiles) > 1 {
+				log.Fatalf("profile %s: %v", profile, err)
+			}
+			log.Fatal(err)
+		}
+	}
+}
+
+// runConfig holds the command line settings shared by every profile of a run
+type runConfig struct {
+	manifest    string
+	outputDir   string
+	format      string
+	verbose     bool
+	count       int
+	tableCounts map[string]int
+	locale      string
+	strict      bool
+	keysPath    string
+	duration    time.Duration
+	dialect     string
+	namespaced  bool // Several profiles run, each writes beneath its own name
+	// dictionaryFormat is the MODE=dictionary output, markdown or json
+	dictionaryFormat string
+	// maxParentKeys caps the keys retained per parent column, zero keeps them all
+	maxParentKeys int
+	// metrics logs per-table timings after the run
+	metrics bool
+	// examples is the records per table of MODE=openapi, zero for one
+	examples int
+	// manifestData is the manifest read from stdin for -manifest -, nil otherwise
+	manifestData []byte
 }
 
-func getDataSink(profile string) sink.DataSink {
+// loadSchema loads the manifest at manifestPath, or the one read from stdin
+func (cfg runConfig) loadSchema(manifestPath string) (*types.Schema, error) {
+	if cfg.manifestData == nil {
+		return pkg.LoadSchema(manifestPath)
+	}
+	// Values files of a manifest from stdin are relative to the working directory
+	return pkg.LoadSchemaFrom(bytes.NewReader(cfg.manifestData), ".")
+}
+
+// validate validates the manifest at manifestPath, or the one read from stdin
+func (cfg runConfig) validate(manifestPath string) error {
+	if cfg.manifestData == nil {
+		return pkg.Validate(manifestPath)
+	}
+	return pkg.ValidateFrom(bytes.NewReader(cfg.manifestData), ".")
+}
+
+// envDuration parses the duration in the environment variable name, zero when it is not set
+func envDuration(name string) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("invalid %s %q: %v", name, value, err)
+	}
+	return d
+}
+
+// envInt parses the integer in the environment variable name, zero when it is not set
+func envInt(name string) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("invalid %s %q: %v", name, value, err)
+	}
+	return n
+}
+
+// parseProfiles splits a comma separated profile list, a single empty profile
+// when none is given so that an explicit manifest still runs
+func parseProfiles(profiles string) []string {
+	var names []string
+	for _, name := range strings.Split(profiles, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return []string{""}
+	}
+	return names
+}
+
+// run executes the mode for one profile
+func run(mode string, profile string, cfg runConfig) error {
+	manifestPath := resolveManifestPath(cfg.manifest, profile)
+	outputDir := cfg.outputDir
+	if cfg.namespaced {
+		outputDir = profileOutputDir(outputDir, profile)
+	}
+	switch mode {
+	case "scaffold":
+		if err := pkg.WriteScaffold(manifestPath); err != nil {
+			return err
+		}
+		log.Printf("example manifest written to %s", manifestPath)
+		return nil
+	case "infer":
+		schema := inferSchema()
+		if err := pkg.WriteManifest(manifestPath, schema); err != nil {
+			return err
+		}
+		log.Printf("inferred manifest written to %s", manifestPath)
+		return nil
+	case "validate":
+		if err := cfg.validate(manifestPath); err != nil {
+			return err
+		}
+		log.Printf("manifest %s is valid", manifestPath)
+		return nil
+	}
+
+	schema, err := cfg.loadSchema(manifestPath)
+	if err != nil {
+		return err
+	}
+	switch mode {
+	case "verify":
+		if outputDir == "" {
+			outputDir = "./output"
+		}
+		// Null foreign keys are written as the CSV sink's null token
+		var opts []pkg.IntegrityOption
+		if token, ok := os.LookupEnv("NULL_TOKEN"); ok {
+			opts = append(opts, pkg.IntegrityNullToken(token))
+		}
+		orphans, err := pkg.CheckCSVIntegrity(schema, outputDir, opts...)
+		if err != nil {
+			return err
+		}
+		for _, orphan := range orphans {
+			log.Print(orphan)
+		}
+		if len(orphans) > 0 {
+			return fmt.Errorf("%d orphaned foreign keys in %s", len(orphans), outputDir)
+		}
+		log.Printf("foreign keys in %s are consistent", outputDir)
+		return nil
+	case "ddl":
+		dialect := cfg.dialect
+		if dialect == "" {
+			dialect = sink.DialectPostgres
+		}
+		statements, err := sink.CreateTableStatements(schema, dialect)
+		if err != nil {
+			return err
+		}
+		for _, statement := range statements {
+			fmt.Printf("%s;\n", statement)
+		}
+		return nil
+	case "dictionary":
+		return pkg.WriteDictionary(os.Stdout, pkg.Dictionary(schema), cfg.dictionaryFormat)
+	case "jsonschema":
+		return pkg.WriteJSONSchema(os.Stdout, pkg.JSONSchema(schema))
+	case "openapi":
+		n := cfg.examples
+		if n == 0 {
+			n = 1
+		}
+		examples, err := pkg.OpenAPIExamples(schema, n, pkg.WithLocale(cfg.locale), pkg.WithStrict(cfg.strict))
+		if err != nil {
+			return err
+		}
+		return pkg.WriteOpenAPIExamples(os.Stdout, examples)
+	case "estimate":
+		estimates := pkg.Estimate(schema, cfg.count, pkg.WithTableCounts(cfg.tableCounts))
+		var rows int
+		var bytes int64
+		for _, estimate := range estimates {
+			log.Printf("%s: %d rows of about %d bytes, %s", estimate.Table, estimate.Rows, estimate.RowBytes, formatBytes(estimate.Bytes))
+			rows += estimate.Rows
+			bytes += estimate.Bytes
+		}
+		log.Printf("estimated total: %d rows, %s of CSV", rows, formatBytes(bytes))
+		return nil
+	}
+
+	var dataSink sink.DataSink
+	if cfg.format != "" {
+		if dataSink, err = newFileSink(cfg.format, outputDir, schema); err != nil {
+			return err
+		}
+	} else {
+		dataSink = getDataSink(profile, schema, outputDir, cfg.namespaced)
+	}
+	opts := []pkg.Option{
+		pkg.WithProgress(progressEvery, reportProgress(progressInterval)),
+		pkg.WithTableCounts(cfg.tableCounts),
+		pkg.WithLocale(cfg.locale),
+		pkg.WithStrict(cfg.strict),
+		pkg.WithDuration(cfg.duration),
+		pkg.WithMaxParentKeys(cfg.maxParentKeys),
+	}
+
+	// PARENT_KEYS carries parent keys across runs: loaded when the file exists, saved afterwards
+	keys := make(map[string][]string)
+	if cfg.keysPath != "" {
+		if _, err := os.Stat(cfg.keysPath); err == nil {
+			if keys, err = pkg.LoadParentKeys(cfg.keysPath); err != nil {
+				return err
+			}
+		}
+		opts = append(opts, pkg.WithParentKeys(keys))
+	}
+
+	stats := pkg.Stats{}
+	if cfg.verbose {
+		opts = append(opts, pkg.WithStats(stats))
+	}
+
+	metrics := pkg.Metrics{}
+	if cfg.metrics {
+		opts = append(opts, pkg.WithMetrics(metrics))
+	}
+
+	if err := pkg.GenerateSchema(dataSink, cfg.count, schema, opts...); err != nil {
+		return err
+	}
+	for _, line := range formatStats(stats) {
+		log.Print(line)
+	}
+	for _, line := range formatMetrics(metrics) {
+		log.Print(line)
+	}
+	if cfg.keysPath != "" {
+		return pkg.SaveParentKeys(cfg.keysPath, keys)
+	}
+	return nil
+}
+
+// profileOutputDir returns the profile's subdirectory of the output directory
+func profileOutputDir(outputDir string, profile string) string {
+	if outputDir == "" {
+		outputDir = "./output"
+	}
+	return filepath.Join(outputDir, profile)
+}
+
+// parseRecordCounts parses a record count such as "1000,users=100,orders=5000" into the
+// run's count and per-table counts, either of which may be omitted
+func parseRecordCounts(records string) (int, map[string]int, error) {
+	count := 0
+	tableCounts := make(map[string]int)
+	for _, entry := range strings.Split(records, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		table, value, perTable := strings.Cut(entry, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if !perTable {
+			n, err = strconv.Atoi(entry)
+		}
+		if err != nil || n < 0 {
+			return 0, nil, fmt.Errorf("invalid record count %q", entry)
+		}
+		if perTable {
+			tableCounts[strings.TrimSpace(table)] = n
+		} else {
+			count = n
+		}
+	}
+	return count, tableCounts, nil
+}
+
+// reportProgress logs per-table counts to stderr at most once per interval
+func reportProgress(interval time.Duration) func(pkg.Progress) {
+	lastReport := time.Now()
+	return func(p pkg.Progress) {
+		if time.Since(lastReport) < interval {
+			return
+		}
+		lastReport = time.Now()
+		log.Print(formatProgress(p))
+	}
+}
+
+// formatProgress renders a progress line such as "progress: 3000 records (orders=1000, users=2000) in 5s"
+func formatProgress(p pkg.Progress) string {
+	counts := make([]string, 0, len(p.Tables))
+	for _, table := range sortedNames(p.Tables) {
+		counts = append(counts, fmt.Sprintf("%s=%d", table, p.Tables[table]))
+	}
+	return fmt.Sprintf("progress: %d records (%s) in %s", p.Records, strings.Join(counts, ", "), p.Elapsed.Round(time.Second))
+}
+
+// formatBytes renders a size with a binary unit, such as "1.5 MiB"
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// formatStats renders one line per column such as
+// "orders.status: 950 values (value=900, null=50), 3 distinct", sorted by table and column
+func formatStats(stats pkg.Stats) []string {
+	var lines []string
+	for _, table := range sortedNames(stats) {
+		for _, column := range sortedNames(stats[table]) {
+			colStats := stats[table][column]
+			total := 0
+			sources := make([]string, 0, len(colStats.Sources))
+			for _, source := range sortedNames(colStats.Sources) {
+				total += colStats.Sources[source]
+				sources = append(sources, fmt.Sprintf("%s=%d", source, colStats.Sources[source]))
+			}
+			lines = append(lines, fmt.Sprintf("%s.%s: %d values (%s), %d distinct",
+				table, column, total, strings.Join(sources, ", "), colStats.Distinct))
+		}
+	}
+	return lines
+}
+
+// formatMetrics renders one line per table such as
+// "orders: 5000 records in 1.2s, 4167 records/s", sorted by table
+func formatMetrics(metrics pkg.Metrics) []string {
+	var lines []string
+	for _, table := range sortedNames(metrics) {
+		m := metrics[table]
+		lines = append(lines, fmt.Sprintf("%s: %d records in %s, %.0f records/s",
+			table, m.Records, m.Duration.Round(time.Millisecond), m.RecordsPerSecond()))
+	}
+	return lines
+}
+
+// sortedNames returns the keys of m in sorted order
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// inferSchema infers a manifest from the Postgres table named by INFER_PG_TABLE, as
+// [schema.]table, or from the CSV file named by INFER_CSV whose table is named by
+// INFER_TABLE or after the file
+func inferSchema() *types.Schema {
+	if pgTable := os.Getenv("INFER_PG_TABLE"); pgTable != "" {
+		schemaName, tableName, found := strings.Cut(pgTable, ".")
+		if !found {
+			schemaName, tableName = "", pgTable
+		}
+		db := sink.PgConnection()
+		defer db.Close()
+
+		schema, err := infer.FromPostgres(db, schemaName, tableName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return schema
+	}
+
+	csvPath := os.Getenv("INFER_CSV")
+	if csvPath == "" {
+		log.Fatal("INFER_CSV or INFER_PG_TABLE must name the data to infer from")
+	}
+	tableName := os.Getenv("INFER_TABLE")
+	if tableName == "" {
+		tableName = strings.TrimSuffix(filepath.Base(csvPath), filepath.Ext(csvPath))
+	}
+
+	file, err := os.Open(csvPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	schema, err := infer.FromCSV(file, tableName, infer.DefaultSampleRows)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return schema
+}
+
+// resolveManifestPath returns the explicit manifest when given, otherwise the profile's manifest
+func resolveManifestPath(manifest string, profile string) string {
+	if manifest != "" {
+		return manifest
+	}
+	return fmt.Sprintf("./manifest/%s.yaml", profile)
+}
+
+// newFileSink creates a sink writing one file per table into outputDir, CSV for
+// the csv format, JSON Lines for json and typed JSON Lines with schema files for bigquery
+func newFileSink(format string, outputDir string, schema *types.Schema) (sink.DataSink, error) {
+	if outputDir == "" {
+		outputDir = "./output"
+	}
+	compression := os.Getenv("COMPRESS")
+
+	switch format {
+	case "csv":
+		opts := []sink.CSVOption{sink.WithCompression(compression)}
+		if os.Getenv("FIELD_ORDER") == "declared" {
+			opts = append(opts, sink.WithDeclaredFieldOrder())
+		}
+		if maxRows := os.Getenv("MAX_ROWS_PER_FILE"); maxRows != "" {
+			n, err := strconv.Atoi(maxRows)
+			if err != nil {
+				return nil, fmt.Errorf("invalid MAX_ROWS_PER_FILE %q: %v", maxRows, err)
+			}
+			opts = append(opts, sink.WithMaxRowsPerFile(n))
+		}
+		if os.Getenv("APPEND") == "true" {
+			opts = append(opts, sink.WithAppend())
+		}
+		if scale, notation := os.Getenv("FLOAT_SCALE"), os.Getenv("FLOAT_FORMAT"); scale != "" || notation != "" {
+			n := 2
+			if scale != "" {
+				var err error
+				if n, err = strconv.Atoi(scale); err != nil {
+					return nil, fmt.Errorf("invalid FLOAT_SCALE %q: %v", scale, err)
+				}
+			}
+			if notation != "" && notation != sink.FloatFixed && notation != sink.FloatScientific {
+				return nil, fmt.Errorf("invalid FLOAT_FORMAT %q, expected fixed or scientific", notation)
+			}
+			opts = append(opts, sink.WithFloatFormat(n, notation == sink.FloatScientific))
+		}
+		if token, ok := os.LookupEnv("NULL_TOKEN"); ok {
+			opts = append(opts, sink.WithNullToken(token))
+		}
+		if columns := os.Getenv("QUOTE_COLUMNS"); columns != "" {
+			opts = append(opts, sink.WithQuotedColumns(strings.Split(columns, ",")...))
+		}
+		if os.Getenv("QUOTE_STRINGS") == "true" {
+			opts = append(opts, sink.WithQuotedStrings())
+		}
+		if every := os.Getenv("FLUSH_EVERY"); every != "" {
+			n, err := strconv.Atoi(every)
+			if err != nil {
+				return nil, fmt.Errorf("invalid FLUSH_EVERY %q: %v", every, err)
+			}
+			opts = append(opts, sink.WithFlushEvery(n))
+		}
+		return sink.NewCSVSink(outputDir, schema, opts...)
+	case "json":
+		return sink.NewJSONLSink(outputDir, compression)
+	case "bigquery":
+		return sink.NewBigQuerySink(outputDir, compression, schema)
+	default:
+		return nil, fmt.Errorf("unsupported format %q, expected csv, json or bigquery", format)
+	}
+}
+
+// getDataSink creates the sink chosen by SINK. Namespaced runs upload beneath
+// the profile's name for s3, file sinks get the profile's output directory.
+func getDataSink(profile string, schema *types.Schema, outputDir string, namespaced bool) sink.DataSink {
 	dataSink := os.Getenv("SINK")
 	switch dataSink {
 	case "pg":
 		return sink.NewPgDataSink(profile)
+	case "csv", "json", "bigquery":
+		fileSink, err := newFileSink(dataSink, outputDir, schema)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return fileSink
+	case "sqlite":
+		dbPath := os.Getenv("DB_PATH")
+		if dbPath == "" {
+			dbPath = fmt.Sprintf("./%s.db", profile)
+		}
+		sqliteSink, err := sink.NewSQLiteSink(dbPath, schema)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return withRetries(sqliteSink)
+	case "mongo":
+		uri := os.Getenv("MONGO_URI")
+		if uri == "" {
+			uri = "mongodb://localhost:27017"
+		}
+		database := os.Getenv("MONGO_DATABASE")
+		if database == "" {
+			database = profile
+		}
+		mongoSink, err := sink.NewMongoSink(uri, database)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return mongoSink
+	case "kafka":
+		brokers := os.Getenv("KAFKA_BROKERS")
+		if brokers == "" {
+			brokers = "localhost:9092"
+		}
+		return sink.NewKafkaSink(strings.Split(brokers, ","), os.Getenv("KAFKA_TOPIC"), os.Getenv("KAFKA_KEY_COLUMN"), schema)
+	case "s3":
+		format := os.Getenv("S3_FORMAT")
+		if format == "" {
+			format = "csv"
+		}
+		s3Prefix := os.Getenv("S3_PREFIX")
+		if namespaced {
+			s3Prefix = path.Join(s3Prefix, profile)
+		}
+		cfg := sink.S3Config{
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Prefix:    s3Prefix,
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") == "true",
+		}
+		s3Sink, err := sink.NewS3Sink(cfg, func(stagingDir string) (sink.DataSink, error) {
+			return newFileSink(format, stagingDir, schema)
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		return s3Sink
+	case "cassandra":
+		hosts := os.Getenv("CASSANDRA_HOSTS")
+		if hosts == "" {
+			hosts = "localhost"
+		}
+		keyspace := os.Getenv("CASSANDRA_KEYSPACE")
+		if keyspace == "" {
+			keyspace = profile
+		}
+		cassandraSink, err := sink.NewCassandraSink(strings.Split(hosts, ","), keyspace, schema)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return withRetries(cassandraSink)
 	default:
 		log.Fatal("no data sink specified")
 	}
 	return nil
 }
+
+// withRetries retries the failed inserts of a sink that writes each record as it
+// is inserted, as set by RETRY_ATTEMPTS and RETRY_DELAY
+func withRetries(dataSink sink.DataSink) sink.DataSink {
+	policy, err := sink.RetryPolicyFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return sink.NewRetrySink(dataSink, policy)
+}
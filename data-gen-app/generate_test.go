@@ -0,0 +1,228 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg"
+	"github.com/sujanks/data-gen-app/pkg/sink"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+func TestResolveManifestPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		profile  string
+		want     string
+	}{
+		{
+			name:    "Profile lookup",
+			profile: "application",
+			want:    "./manifest/application.yaml",
+		},
+		{
+			name:     "Explicit path wins over profile",
+			manifest: "/data/manifests/orders.yaml",
+			profile:  "application",
+			want:     "/data/manifests/orders.yaml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, resolveManifestPath(tt.manifest, tt.profile))
+		})
+	}
+}
+
+func TestFormatProgress(t *testing.T) {
+	line := formatProgress(pkg.Progress{
+		Records: 3000,
+		Tables:  map[string]int{"users": 2000, "orders": 1000},
+		Elapsed: 5200 * time.Millisecond,
+	})
+	assert.Equal(t, "progress: 3000 records (orders=1000, users=2000) in 5s", line)
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "2.0 GiB", formatBytes(2<<30))
+}
+
+func TestParseRecordCounts(t *testing.T) {
+	tests := []struct {
+		name        string
+		records     string
+		count       int
+		tableCounts map[string]int
+		wantErr     bool
+	}{
+		{
+			name:        "Global count",
+			records:     "1000",
+			count:       1000,
+			tableCounts: map[string]int{},
+		},
+		{
+			name:        "Per-table counts",
+			records:     "users=100,orders=5000",
+			tableCounts: map[string]int{"users": 100, "orders": 5000},
+		},
+		{
+			name:        "Global and per-table counts",
+			records:     "1000, users=100",
+			count:       1000,
+			tableCounts: map[string]int{"users": 100},
+		},
+		{
+			name:        "Empty",
+			tableCounts: map[string]int{},
+		},
+		{
+			name:    "Invalid count",
+			records: "users=many",
+			wantErr: true,
+		},
+		{
+			name:    "Negative count",
+			records: "-5",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, tableCounts, err := parseRecordCounts(tt.records)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.count, count)
+			assert.Equal(t, tt.tableCounts, tableCounts)
+		})
+	}
+}
+
+func TestNewFileSink(t *testing.T) {
+	schema := &types.Schema{Tables: []types.Table{{Name: "users", Columns: []types.Column{{Name: "id"}}}}}
+
+	csvSink, err := newFileSink("csv", t.TempDir(), schema)
+	assert.NoError(t, err)
+	assert.IsType(t, &sink.CSVSink{}, csvSink)
+
+	jsonSink, err := newFileSink("json", t.TempDir(), schema)
+	assert.NoError(t, err)
+	assert.IsType(t, &sink.JSONLSink{}, jsonSink)
+
+	bigQuerySink, err := newFileSink("bigquery", t.TempDir(), schema)
+	assert.NoError(t, err)
+	assert.IsType(t, &sink.BigQuerySink{}, bigQuerySink)
+
+	_, err = newFileSink("xml", t.TempDir(), schema)
+	assert.Error(t, err)
+}
+
+func TestFormatStats(t *testing.T) {
+	lines := formatStats(pkg.Stats{
+		"orders": {
+			"status":      {Sources: map[string]int{"value": 900, "null": 50}, Distinct: 3},
+			"customer_id": {Sources: map[string]int{"foreign": 950}, Distinct: 400},
+		},
+	})
+	assert.Equal(t, []string{
+		"orders.customer_id: 950 values (foreign=950), 400 distinct",
+		"orders.status: 950 values (null=50, value=900), 3 distinct",
+	}, lines)
+}
+
+func TestFormatMetrics(t *testing.T) {
+	lines := formatMetrics(pkg.Metrics{
+		"users":  {Records: 100, Duration: 50 * time.Millisecond},
+		"orders": {Records: 5000, Duration: 1200 * time.Millisecond},
+	})
+	assert.Equal(t, []string{
+		"orders: 5000 records in 1.2s, 4167 records/s",
+		"users: 100 records in 50ms, 2000 records/s",
+	}, lines)
+}
+
+func TestParseProfiles(t *testing.T) {
+	assert.Equal(t, []string{""}, parseProfiles(""))
+	assert.Equal(t, []string{"application"}, parseProfiles("application"))
+	assert.Equal(t, []string{"application", "billing"}, parseProfiles("application, billing,"))
+}
+
+func TestRunSeveralProfiles(t *testing.T) {
+	// Profiles resolve to ./manifest/<profile>.yaml
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	dir := t.TempDir()
+	assert.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	assert.NoError(t, os.Mkdir("manifest", 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join("manifest", "application.yaml"), []byte(`
+tables:
+- name: users
+  columns:
+  - name: id
+    pattern: "U###"
+`), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join("manifest", "billing.yaml"), []byte(`
+tables:
+- name: invoices
+  columns:
+  - name: id
+    pattern: "I###"
+`), 0o644))
+
+	cfg := runConfig{outputDir: filepath.Join(dir, "output"), format: "csv", count: 3, namespaced: true}
+	for _, profile := range parseProfiles("application,billing") {
+		assert.NoError(t, run("", profile, cfg))
+	}
+
+	users, err := os.ReadFile(filepath.Join(dir, "output", "application", "users.csv"))
+	assert.NoError(t, err)
+	assert.Regexp(t, `^id\n(U\d{3}\n){3}$`, string(users))
+	invoices, err := os.ReadFile(filepath.Join(dir, "output", "billing", "invoices.csv"))
+	assert.NoError(t, err)
+	assert.Regexp(t, `^id\n(I\d{3}\n){3}$`, string(invoices))
+
+	assert.NoFileExists(t, filepath.Join(dir, "output", "application", "invoices.csv"))
+	assert.NoFileExists(t, filepath.Join(dir, "output", "billing", "users.csv"))
+	assert.NoFileExists(t, filepath.Join(dir, "output", "users.csv"))
+}
+
+func TestRunManifestFromStdin(t *testing.T) {
+	// The manifest read from stdin is passed through the run and loaded from memory
+	dir := t.TempDir()
+	cfg := runConfig{
+		manifest:  manifestStdin,
+		outputDir: dir,
+		format:    "csv",
+		count:     2,
+		manifestData: []byte(`
+tables:
+- name: users
+  columns:
+  - name: id
+    pattern: "U###"
+`),
+	}
+	assert.NoError(t, run("validate", "", cfg))
+	assert.NoError(t, run("", "", cfg))
+
+	users, err := os.ReadFile(filepath.Join(dir, "users.csv"))
+	assert.NoError(t, err)
+	assert.Regexp(t, `^id\n(U\d{3}\n){2}$`, string(users))
+
+	cfg.manifestData = []byte("tables: [oops")
+	assert.Error(t, run("validate", "", cfg))
+	assert.Error(t, run("", "", cfg))
+}
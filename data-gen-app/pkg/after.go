@@ -0,0 +1,63 @@
+package pkg
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// defaultMaxOffset bounds how much later an after column is when max_offset is not set
+const defaultMaxOffset = 24 * time.Hour
+
+// applyAfter sets each after column, in declaration order so chains such as
+// created_on, updated_on, deleted_on stay ordered, to a time strictly later
+// than the column it follows. A missing earlier time leaves the column nil.
+func applyAfter(faker *gofakeit.Faker, columns []types.Column, formats map[string]string, tableData map[string]interface{}) {
+	for _, col := range columns {
+		if col.After.Column == "" || tableData[col.Name] == nil {
+			continue
+		}
+		earlier, ok := timeValue(tableData[col.After.Column], formats[col.After.Column])
+		if !ok {
+			tableData[col.Name] = nil
+			continue
+		}
+		// Offsets are whole seconds so they survive unix second precision
+		maxOffset, _ := afterMaxOffset(col.After)
+		offset := time.Duration(faker.IntRange(1, int(maxOffset/time.Second))) * time.Second
+		tableData[col.Name] = epochValue(col.Format, earlier.Add(offset))
+	}
+}
+
+// afterMaxOffset parses the largest offset of an after column
+func afterMaxOffset(after types.After) (time.Duration, error) {
+	if after.MaxOffset == "" {
+		return defaultMaxOffset, nil
+	}
+	maxOffset, err := time.ParseDuration(after.MaxOffset)
+	if err != nil {
+		return 0, err
+	}
+	if maxOffset < time.Second {
+		return 0, fmt.Errorf("max_offset %s is shorter than a second", after.MaxOffset)
+	}
+	return maxOffset, nil
+}
+
+// timeValue converts a generated timestamp, either a time or an epoch in the
+// column's unix format, back to a time
+func timeValue(value interface{}, format string) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case int64:
+		if format == unixMsFormat {
+			return time.UnixMilli(v), true
+		}
+		return time.Unix(v, 0), true
+	default:
+		return time.Time{}, false
+	}
+}
@@ -0,0 +1,49 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAfterColumns(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: events
+  columns:
+  - name: created_on
+    type: timestamp
+    range:
+      min: "2024-01-01 00:00:00"
+      max: "2024-12-31 00:00:00"
+  - name: updated_on
+    type: timestamp
+    after:
+      column: created_on
+      max_offset: 72h
+  - name: deleted_on
+    type: timestamp
+    format: unix
+    after:
+      column: updated_on
+`)
+
+	stream, err := GenerateStream(context.Background(), 500, manifestPath)
+	assert.NoError(t, err)
+
+	n := 0
+	for record := range stream.Records() {
+		n++
+		created := record.Data["created_on"].(time.Time)
+		updated := record.Data["updated_on"].(time.Time)
+		deleted := time.Unix(record.Data["deleted_on"].(int64), 0)
+
+		assert.True(t, updated.After(created), "updated_on %s not after created_on %s", updated, created)
+		assert.True(t, updated.Sub(created) <= 72*time.Hour)
+		assert.True(t, deleted.After(updated.Truncate(time.Second)), "deleted_on %s not after updated_on %s", deleted, updated)
+		assert.True(t, deleted.Sub(updated) <= 24*time.Hour)
+	}
+	assert.Equal(t, 500, n)
+}
@@ -0,0 +1,184 @@
+package pkg
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+const (
+	aggregateCount = "count"
+	aggregateSum   = "sum"
+)
+
+// aggregateSpec describes one parent column backfilled from its children
+type aggregateSpec struct {
+	table       string // Parent table holding the aggregate column
+	column      string // Aggregate column
+	keyColumn   string // Parent column the children reference
+	childTable  string
+	childColumn string // Child foreign column
+	function    string
+	field       string // Child column summed by sum
+	fieldType   string // Type of the summed column, sums of integer columns stay integers
+}
+
+// aggregator holds back the records of tables with aggregate columns until
+// every child has been generated, then fills the aggregates in. Records of the
+// tables generated after them, their children included, are held too, so sinks
+// still receive parents before children.
+type aggregator struct {
+	specs  []aggregateSpec
+	totals []map[string]*runningTotal // Running total per spec, keyed by parent key
+	held   []Record                   // Records from the first table with aggregates on, in generation order
+}
+
+func newAggregator(tables []types.Table) *aggregator {
+	columns := make(map[string]types.Column)
+	for _, table := range tables {
+		for _, col := range table.Columns {
+			columns[table.Name+"."+col.Name] = col
+		}
+	}
+
+	a := &aggregator{}
+	for _, table := range tables {
+		for _, col := range table.Columns {
+			if col.Aggregate.Function == "" {
+				continue
+			}
+			childTable, childColumn, _ := strings.Cut(col.Aggregate.Foreign, ".")
+			_, keyColumn, _ := strings.Cut(columns[col.Aggregate.Foreign].Foreign, ".")
+			a.specs = append(a.specs, aggregateSpec{
+				table:       table.Name,
+				column:      col.Name,
+				keyColumn:   keyColumn,
+				childTable:  childTable,
+				childColumn: childColumn,
+				function:    col.Aggregate.Function,
+				field:       col.Aggregate.Field,
+				fieldType:   columns[childTable+"."+col.Aggregate.Field].Type,
+			})
+			a.totals = append(a.totals, make(map[string]*runningTotal))
+		}
+	}
+	return a
+}
+
+// holds reports whether a record of table is held back until release: records
+// of tables with aggregate columns and of every table generated after the first one
+func (a *aggregator) holds(table string) bool {
+	if len(a.held) > 0 {
+		return true
+	}
+	for _, spec := range a.specs {
+		if spec.table == table {
+			return true
+		}
+	}
+	return false
+}
+
+// observe adds a child record to the totals of the parent it references
+func (a *aggregator) observe(record Record) {
+	for i, spec := range a.specs {
+		if spec.childTable != record.Table {
+			continue
+		}
+		parent := record.Data[spec.childColumn]
+		if parent == nil {
+			continue
+		}
+		key := fmt.Sprint(parent)
+		total := a.totals[i][key]
+		if total == nil {
+			total = &runningTotal{}
+			a.totals[i][key] = total
+		}
+		switch spec.function {
+		case aggregateCount:
+			total.add(1)
+		case aggregateSum:
+			total.add(record.Data[spec.field])
+		}
+	}
+}
+
+// hold keeps a record back until release
+func (a *aggregator) hold(record Record) {
+	a.held = append(a.held, record)
+}
+
+// release fills the aggregate columns of held records and emits them in the
+// order they were generated
+func (a *aggregator) release(emit func(Record) error) error {
+	for _, record := range a.held {
+		for i, spec := range a.specs {
+			if spec.table != record.Table {
+				continue
+			}
+			var total runningTotal
+			if t := a.totals[i][fmt.Sprint(record.Data[spec.keyColumn])]; t != nil {
+				total = *t
+			}
+			switch {
+			case spec.function == aggregateCount || spec.fieldType == "int":
+				record.Data[spec.column] = int(total.integer())
+			case spec.fieldType == "bigint" || spec.fieldType == "long":
+				record.Data[spec.column] = total.integer()
+			default:
+				record.Data[spec.column] = total.float()
+			}
+		}
+		if err := emit(record); err != nil {
+			return err
+		}
+	}
+	a.held = nil
+	return nil
+}
+
+// runningTotal adds up generated numbers, keeping integers in an int64 so large
+// integer sums stay exact instead of losing precision as a float64
+type runningTotal struct {
+	ints   int64   // Sum of the integer values
+	floats float64 // Sum of the other values
+}
+
+// add adds a generated numeric value, ignoring anything else
+func (t *runningTotal) add(value interface{}) {
+	switch v := value.(type) {
+	case int:
+		t.ints += int64(v)
+	case int64:
+		t.ints += v
+	case float64:
+		t.floats += v
+	}
+}
+
+// integer returns the total for integer columns, rounding any fractional part
+func (t runningTotal) integer() int64 {
+	return t.ints + int64(math.Round(t.floats))
+}
+
+// float returns the total for other columns
+func (t runningTotal) float() float64 {
+	return float64(t.ints) + t.floats
+}
+
+// toFloat converts a generated numeric value for summing
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,73 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregates(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: customers
+  priority: 2
+  columns:
+  - name: id
+    pattern: "C######"
+    parent: true
+  - name: order_count
+    type: int
+    aggregate:
+      function: count
+      foreign: orders.customer_id
+  - name: order_total
+    type: int
+    aggregate:
+      function: sum
+      foreign: orders.customer_id
+      field: amount
+- name: orders
+  priority: 1
+  depends_on: customers
+  columns:
+  - name: id
+    pattern: "O######"
+  - name: customer_id
+    foreign: customers.id
+  - name: amount
+    type: int
+    range:
+      min: 1
+      max: 100
+`)
+
+	sink := &MockDataSink{}
+	assert.NoError(t, GenerateData(sink, 50, manifestPath))
+
+	counts := make(map[interface{}]int)
+	totals := make(map[interface{}]int)
+	var customers []map[string]interface{}
+	for _, record := range sink.Records {
+		if _, isOrder := record["customer_id"]; isOrder {
+			// Parents are still written before the children referencing them
+			assert.Len(t, customers, 50, "order written before its customer")
+			counts[record["customer_id"]]++
+			totals[record["customer_id"]] += record["amount"].(int)
+		} else {
+			customers = append(customers, record)
+		}
+	}
+
+	assert.Len(t, customers, 50)
+	sum := 0
+	seen := make(map[interface{}]bool)
+	for _, customer := range customers {
+		assert.Equal(t, counts[customer["id"]], customer["order_count"], "order count of %v", customer["id"])
+		assert.Equal(t, totals[customer["id"]], customer["order_total"], "order total of %v", customer["id"])
+		if !seen[customer["id"]] {
+			seen[customer["id"]] = true
+			sum += customer["order_count"].(int)
+		}
+	}
+	assert.Equal(t, 50, sum)
+}
@@ -0,0 +1,91 @@
+package pkg
+
+import (
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// applyCounters adds each counter column's delta to the table's running total
+// and replaces the column with the new total. The delta is the delta column's
+// value, or the column's own generated value when no delta column is set; a
+// missing delta leaves the total unchanged. totals holds the state of one
+// table across its rows.
+func applyCounters(columns []types.Column, totals map[string]*runningTotal, tableData map[string]interface{}) {
+	for _, col := range columns {
+		if col.Counter == nil {
+			continue
+		}
+		total, ok := totals[col.Name]
+		if !ok {
+			total = &runningTotal{}
+			total.add(col.Counter.Start)
+			totals[col.Name] = total
+		}
+
+		source := col.Name
+		if col.Counter.Delta != "" {
+			source = col.Counter.Delta
+		}
+		total.add(tableData[source])
+
+		switch col.Type {
+		case "int":
+			tableData[col.Name] = int(total.integer())
+		case "bigint", "long":
+			tableData[col.Name] = total.integer()
+		default:
+			tableData[col.Name] = total.float()
+		}
+	}
+}
+
+// Value modes, random picks a value for every row and sequential cycles through them in order
+const (
+	modeRandom     = "random"
+	modeSequential = "sequential"
+)
+
+// applySequences replaces the values of sequential columns with the next value
+// of their list, or of their values files in turn, wrapping around at its end.
+// Rows where the column has no value, such as unmet when conditions, do not
+// advance the sequence. next holds the state of one table across its rows.
+func applySequences(columns []types.Column, next map[string]int, tableData map[string]interface{}) {
+	for _, col := range columns {
+		if col.Mode != modeSequential || tableData[col.Name] == nil {
+			continue
+		}
+		size := sequenceSize(col)
+		if size == 0 {
+			continue
+		}
+		i := next[col.Name]
+		tableData[col.Name] = sequenceValue(col, i)
+		next[col.Name] = (i + 1) % size
+	}
+}
+
+// sequenceSize returns the number of values a sequential column cycles through
+func sequenceSize(col types.Column) int {
+	if len(col.Value) > 0 {
+		return len(col.Value)
+	}
+	size := 0
+	for _, file := range col.ValuesFiles {
+		size += len(file.Values)
+	}
+	return size
+}
+
+// sequenceValue returns the i-th value of a sequential column, counting through
+// its values files one after the other when it has no value list
+func sequenceValue(col types.Column, i int) string {
+	if len(col.Value) > 0 {
+		return col.Value[i]
+	}
+	for _, file := range col.ValuesFiles {
+		if i < len(file.Values) {
+			return file.Values[i]
+		}
+		i -= len(file.Values)
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+func TestCounterColumns(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: ledger
+  columns:
+  - name: amount
+    type: int
+    range:
+      min: 1
+      max: 100
+  - name: balance
+    type: int
+    counter:
+      start: 1000
+      delta: amount
+  - name: points
+    type: float
+    range:
+      min: 0.5
+      max: 2.5
+    counter: {}
+`)
+
+	records, err := Generate(manifestPath, 200)
+	if !assert.NoError(t, err) {
+		return
+	}
+	ledger := records["ledger"]
+	assert.Len(t, ledger, 200)
+
+	balance, points := 1000, 0.0
+	for _, record := range ledger {
+		amount := record["amount"].(int)
+		assert.Equal(t, balance+amount, record["balance"])
+		balance = record["balance"].(int)
+
+		total := record["points"].(float64)
+		assert.GreaterOrEqual(t, total-points, 0.5-1e-9)
+		assert.LessOrEqual(t, total-points, 2.5+1e-9)
+		points = total
+	}
+}
+
+func TestSequentialValues(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: shifts
+  columns:
+  - name: slot
+    value: [morning, afternoon, night]
+    mode: sequential
+  - name: crew
+    value: [red, blue]
+`)
+
+	records, err := Generate(manifestPath, 7)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var slots []interface{}
+	for _, record := range records["shifts"] {
+		slots = append(slots, record["slot"])
+		assert.Contains(t, []string{"red", "blue"}, record["crew"])
+	}
+	assert.Equal(t, []interface{}{"morning", "afternoon", "night", "morning", "afternoon", "night", "morning"}, slots)
+}
+
+func TestApplyCountersLargeIntegers(t *testing.T) {
+	// 2^53 + 1 has no exact float64, so the total must be kept as an integer
+	columns := []types.Column{{Name: "amount", Type: "bigint"}, {Name: "balance", Type: "bigint", Counter: &types.Counter{Start: 1, Delta: "amount"}}}
+	totals := make(map[string]*runningTotal)
+
+	row := map[string]interface{}{"amount": int64(1 << 53)}
+	applyCounters(columns, totals, row)
+	assert.Equal(t, int64(1<<53+1), row["balance"])
+
+	row = map[string]interface{}{"amount": int64(1 << 53)}
+	applyCounters(columns, totals, row)
+	assert.Equal(t, int64(1<<54+1), row["balance"])
+}
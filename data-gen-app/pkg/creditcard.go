@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+// cardNetwork describes the numbers a card network issues
+type cardNetwork struct {
+	length    int                                // Digits in a card number, check digit included
+	cvvLength int                                // Digits in the security code
+	prefix    func(faker *gofakeit.Faker) string // Issuer identification prefix
+}
+
+// cardNetworks lists the supported networks by the name used for network
+var cardNetworks = map[string]cardNetwork{
+	"visa": {length: 16, cvvLength: 3, prefix: func(faker *gofakeit.Faker) string {
+		return "4"
+	}},
+	"mastercard": {length: 16, cvvLength: 3, prefix: func(faker *gofakeit.Faker) string {
+		// Mastercard issues from both the 51-55 and the 2221-2720 ranges
+		if faker.Bool() {
+			return strconv.Itoa(faker.IntRange(51, 55))
+		}
+		return strconv.Itoa(faker.IntRange(2221, 2720))
+	}},
+	"amex": {length: 15, cvvLength: 4, prefix: func(faker *gofakeit.Faker) string {
+		return faker.RandomString([]string{"34", "37"})
+	}},
+}
+
+// cardNetworkNames lists the networks a card is drawn from when none is set
+var cardNetworkNames = []string{"amex", "mastercard", "visa"}
+
+// defaultExpiryFormat renders card expiry dates as MM/YY
+const defaultExpiryFormat = "01/06"
+
+// cardNetworkFor resolves network, picking a random one when it is empty or
+// unknown. Manifests are checked for unknown networks when they are loaded, but
+// columns built in code are not.
+func cardNetworkFor(faker *gofakeit.Faker, network string) cardNetwork {
+	if card, ok := cardNetworks[network]; ok {
+		return card
+	}
+	return cardNetworks[faker.RandomString(cardNetworkNames)]
+}
+
+// creditCardNumber generates a Luhn-valid card number of the network's length and prefix
+func creditCardNumber(faker *gofakeit.Faker, network string) string {
+	card := cardNetworkFor(faker, network)
+	var b strings.Builder
+	b.WriteString(card.prefix(faker))
+	for b.Len() < card.length {
+		b.WriteRune(randDigit(faker))
+	}
+	return withLuhnCheckDigit(b.String())
+}
+
+// cardCVV generates a security code, four digits for amex and three otherwise
+func cardCVV(faker *gofakeit.Faker, network string) string {
+	card := cardNetworkFor(faker, network)
+	return fillPattern(faker, strings.Repeat(string(hashtag), card.cvvLength), true)
+}
+
+// cardExpiry generates an expiry date in the next five years, formatted with
+// the column's layout or MM/YY
+func cardExpiry(faker *gofakeit.Faker, format string) string {
+	if format == "" {
+		format = defaultExpiryFormat
+	}
+	now := time.Now()
+	expiry := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, faker.IntRange(1, 60), 0)
+	return expiry.Format(format)
+}
@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+func TestCreditCardNumbers(t *testing.T) {
+	tests := []struct {
+		network string
+		length  int
+		prefix  func(number string) bool
+		cvv     string
+	}{
+		{
+			network: "visa",
+			length:  16,
+			prefix:  func(number string) bool { return number[0] == '4' },
+			cvv:     `^[0-9]{3}$`,
+		},
+		{
+			network: "mastercard",
+			length:  16,
+			prefix: func(number string) bool {
+				two, _ := strconv.Atoi(number[:2])
+				four, _ := strconv.Atoi(number[:4])
+				return (two >= 51 && two <= 55) || (four >= 2221 && four <= 2720)
+			},
+			cvv: `^[0-9]{3}$`,
+		},
+		{
+			network: "amex",
+			length:  15,
+			prefix:  func(number string) bool { return number[:2] == "34" || number[:2] == "37" },
+			cvv:     `^[0-9]{4}$`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.network, func(t *testing.T) {
+			card := types.Column{Name: "card_number", Type: "creditcard", Network: tt.network}
+			cvv := types.Column{Name: "cvv", Type: "card_cvv", Network: tt.network}
+			for i := 0; i < 200; i++ {
+				number := generateColumnValue(card, gofakeit.GlobalFaker).(string)
+				assert.Len(t, number, tt.length)
+				assert.Regexp(t, `^[0-9]+$`, number)
+				assert.True(t, tt.prefix(number), "%s has the wrong prefix for %s", number, tt.network)
+				assert.True(t, luhnValid(number), "%s fails the Luhn check", number)
+
+				assert.Regexp(t, tt.cvv, generateColumnValue(cvv, gofakeit.GlobalFaker))
+			}
+		})
+	}
+}
+
+func TestCreditCardAnyNetwork(t *testing.T) {
+	// Unknown networks fall back to a random one like an empty network
+	for _, network := range []string{"", "discover"} {
+		card := types.Column{Name: "card_number", Type: "creditcard", Network: network}
+		cvv := types.Column{Name: "cvv", Type: "card_cvv", Network: network}
+		for i := 0; i < 100; i++ {
+			number := generateColumnValue(card, gofakeit.GlobalFaker).(string)
+			assert.Contains(t, []int{15, 16}, len(number))
+			assert.True(t, luhnValid(number), "%s fails the Luhn check", number)
+			assert.Regexp(t, `^[0-9]{3,4}$`, generateColumnValue(cvv, gofakeit.GlobalFaker))
+		}
+	}
+}
+
+func TestCardExpiry(t *testing.T) {
+	now := time.Now()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 100; i++ {
+		value := generateColumnValue(types.Column{Name: "expiry", Type: "card_expiry"}, gofakeit.GlobalFaker).(string)
+		expiry, err := time.Parse("01/06", value)
+		if assert.NoError(t, err) {
+			assert.True(t, expiry.After(thisMonth), "%s is not in the future", value)
+			assert.False(t, expiry.After(thisMonth.AddDate(5, 0, 0)), "%s is more than five years out", value)
+		}
+	}
+
+	value := generateColumnValue(types.Column{Name: "expiry", Type: "card_expiry", Format: "2006-01"}, gofakeit.GlobalFaker)
+	assert.Regexp(t, `^[0-9]{4}-[0-9]{2}$`, value)
+}
@@ -0,0 +1,245 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// Data dictionary formats accepted by WriteDictionary
+const (
+	DictionaryMarkdown = "markdown"
+	DictionaryJSON     = "json"
+)
+
+// DictionaryTable documents one table of a manifest
+type DictionaryTable struct {
+	Name      string             `json:"name"`
+	DependsOn string             `json:"depends_on,omitempty"`
+	Count     int                `json:"count,omitempty"` // Fixed record count, zero when the run's count applies
+	Columns   []DictionaryColumn `json:"columns"`
+}
+
+// DictionaryColumn documents how the values of one column are generated
+type DictionaryColumn struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Strategy    string   `json:"strategy"`    // Source of the values, as reported by the run's stats
+	Description string   `json:"description"` // The strategy's settings in words
+	Range       string   `json:"range,omitempty"`
+	Notes       []string `json:"notes,omitempty"` // Keys, uniqueness, conditions and post-processing
+}
+
+// Dictionary describes every table and column of the manifest, in manifest order,
+// with its type, range and generation strategy
+func Dictionary(schema *types.Schema) []DictionaryTable {
+	columns := make(map[string]types.Column) // Columns by table.column, to type foreign keys
+	for _, table := range schema.Tables {
+		for _, col := range table.Columns {
+			columns[table.Name+"."+col.Name] = col
+		}
+	}
+
+	tables := make([]DictionaryTable, 0, len(schema.Tables))
+	for _, table := range schema.Tables {
+		entry := DictionaryTable{Name: table.Name, DependsOn: table.DependsOn, Count: table.Count}
+		for _, col := range table.Columns {
+			entry.Columns = append(entry.Columns, DictionaryColumn{
+				Name:        col.Name,
+				Type:        dictionaryType(col, columns),
+				Strategy:    valueSource(col),
+				Description: describeColumn(col),
+				Range:       describeRange(col.Range),
+				Notes:       columnNotes(col),
+			})
+		}
+		tables = append(tables, entry)
+	}
+	return tables
+}
+
+// dictionaryType returns the declared type of col, a foreign column without one
+// takes its parent's and untyped columns hold strings
+func dictionaryType(col types.Column, columns map[string]types.Column) string {
+	if col.Type == "" && col.Foreign != "" {
+		if parent, ok := columns[col.Foreign]; ok {
+			return dictionaryType(parent, columns)
+		}
+	}
+	if col.Type == "" {
+		return "string"
+	}
+	return col.Type
+}
+
+// describeColumn explains in words how the values of col are produced, following
+// the precedence of columnValue
+func describeColumn(col types.Column) string {
+	switch valueSource(col) {
+	case "aggregate":
+		if col.Aggregate.Function == "sum" {
+			return fmt.Sprintf("sum of %s over the rows of %s referencing the record", col.Aggregate.Field, col.Aggregate.Foreign)
+		}
+		return fmt.Sprintf("%s of the rows referencing the record through %s", col.Aggregate.Function, col.Aggregate.Foreign)
+	case "hash":
+		algorithm := col.HashConfig.Algorithm
+		if algorithm == "" {
+			algorithm = "sha256"
+		}
+		return fmt.Sprintf("%s digest of %s", algorithm, strings.Join(col.HashConfig.Fields, ", "))
+	case "const":
+		return fmt.Sprintf("always %s", col.Const)
+	case "foreign":
+		if col.ForeignFilter != "" {
+			return fmt.Sprintf("references %s where %s", col.Foreign, col.ForeignFilter)
+		}
+		return fmt.Sprintf("references %s", col.Foreign)
+	case "value":
+		if col.Mode == "sequential" {
+			return fmt.Sprintf("cycles through %s", strings.Join(col.Value, ", "))
+		}
+		return fmt.Sprintf("one of %s", strings.Join(col.Value, ", "))
+	case "pattern":
+		return fmt.Sprintf("pattern %s", col.Pattern)
+	}
+
+	switch {
+	case len(col.ValuesFiles) > 0:
+		var paths []string
+		for _, file := range col.ValuesFiles {
+			paths = append(paths, file.Path)
+		}
+		if col.Mode == "sequential" {
+			return fmt.Sprintf("cycles through the lines of %s", strings.Join(paths, ", "))
+		}
+		return fmt.Sprintf("line of %s", strings.Join(paths, ", "))
+	case col.Type == "template":
+		return fmt.Sprintf("template %s", col.Template)
+	case col.After.Column != "":
+		return fmt.Sprintf("after %s", col.After.Column)
+	case col.Counter != nil:
+		if col.Counter.Delta != "" {
+			return fmt.Sprintf("running total of %s", col.Counter.Delta)
+		}
+		return "running total"
+	case col.Format != "":
+		return fmt.Sprintf("random %s formatted %s", dictionaryType(col, nil), col.Format)
+	}
+	return fmt.Sprintf("random %s", dictionaryType(col, nil))
+}
+
+// describeRange renders a range in interval notation, such as [18, 90] or
+// (0, 1] step 0.25, with * for an unset bound. Empty when nothing is set.
+func describeRange(r types.Range) string {
+	if r.Min == nil && r.Max == nil && r.Step == 0 {
+		return ""
+	}
+	lower, upper := "[", "]"
+	if r.ExclusiveMin || r.Min == nil {
+		lower = "("
+	}
+	if r.ExclusiveMax || r.Max == nil {
+		upper = ")"
+	}
+	bound := func(v interface{}) string {
+		if v == nil {
+			return "*"
+		}
+		return fmt.Sprint(v)
+	}
+	interval := fmt.Sprintf("%s%s, %s%s", lower, bound(r.Min), bound(r.Max), upper)
+	if r.Step > 0 {
+		interval += fmt.Sprintf(" step %v", r.Step)
+	}
+	return interval
+}
+
+// columnNotes lists the keys, constraints and post-processing of col
+func columnNotes(col types.Column) []string {
+	var notes []string
+	if col.Parent {
+		notes = append(notes, "parent key")
+	}
+	if col.Validation.Unique {
+		notes = append(notes, "unique")
+	} else if col.Validation.UniquePerParent != "" {
+		notes = append(notes, fmt.Sprintf("unique per %s", col.Validation.UniquePerParent))
+	}
+	if col.Mandatory {
+		notes = append(notes, "mandatory")
+	}
+	if col.NullProbability > 0 {
+		notes = append(notes, fmt.Sprintf("null %v%% of the time", col.NullProbability*100))
+	}
+	if col.When != "" {
+		notes = append(notes, fmt.Sprintf("only when %s", col.When))
+	}
+	if col.Default != "" {
+		notes = append(notes, fmt.Sprintf("defaults to %s", col.Default))
+	}
+	if len(col.Rules) > 0 {
+		notes = append(notes, fmt.Sprintf("%d rules", len(col.Rules)))
+	}
+	if col.Mask.Strategy != "" {
+		notes = append(notes, fmt.Sprintf("masked %s", col.Mask.Strategy))
+	}
+	if col.SoftDelete != nil {
+		note := fmt.Sprintf("set for %v%% of rows, deleted", col.SoftDelete.Probability*100)
+		if col.SoftDelete.Flag != "" {
+			note += fmt.Sprintf(" and flagged by %s", col.SoftDelete.Flag)
+		}
+		notes = append(notes, note)
+	}
+	return notes
+}
+
+// WriteDictionary writes tables to w as a markdown document, one section and
+// table per manifest table, or as indented JSON
+func WriteDictionary(w io.Writer, tables []DictionaryTable, format string) error {
+	switch format {
+	case DictionaryJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(tables)
+	case DictionaryMarkdown, "":
+		return writeDictionaryMarkdown(w, tables)
+	default:
+		return fmt.Errorf("unsupported dictionary format %q, expected markdown or json", format)
+	}
+}
+
+func writeDictionaryMarkdown(w io.Writer, tables []DictionaryTable) error {
+	var b strings.Builder
+	b.WriteString("# Data Dictionary\n")
+	for _, table := range tables {
+		fmt.Fprintf(&b, "\n## %s\n\n", table.Name)
+		var about []string
+		if table.DependsOn != "" {
+			about = append(about, fmt.Sprintf("Depends on %s.", table.DependsOn))
+		}
+		if table.Count > 0 {
+			about = append(about, fmt.Sprintf("%d records per run.", table.Count))
+		}
+		if len(about) > 0 {
+			fmt.Fprintf(&b, "%s\n\n", strings.Join(about, " "))
+		}
+		b.WriteString("| Column | Type | Generation | Range | Notes |\n")
+		b.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, col := range table.Columns {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+				markdownCell(col.Name), markdownCell(col.Type), markdownCell(col.Strategy+": "+col.Description),
+				markdownCell(col.Range), markdownCell(strings.Join(col.Notes, ", ")))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell escapes pipes and line breaks, which would end a table cell or row
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.NewReplacer("\r\n", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,114 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const dictionaryManifest = `
+tables:
+- name: customers
+  columns:
+  - name: id
+    pattern: "C######"
+    parent: true
+  - name: age
+    type: int
+    range:
+      min: 18
+      max: 90
+  - name: score
+    type: float
+    range:
+      min: 0
+      exclusive_min: true
+      step: 0.5
+  - name: status
+    value: [active, closed]
+  - name: country
+    const: NZ
+- name: orders
+  depends_on: customers
+  count: 50
+  columns:
+  - name: id
+    type: uuid
+    validation:
+      unique: true
+  - name: customer_id
+    foreign: customers.id
+    null_probability: 0.1
+  - name: placed_at
+    type: timestamp
+    format: "2006-01-02"
+  - name: note
+    type: sentence
+    when: "customer_id != nil"
+`
+
+func TestDictionary(t *testing.T) {
+	tables := Dictionary(loadTempManifest(t, dictionaryManifest))
+
+	columnTypes := make(map[string]string)
+	for _, table := range tables {
+		for _, col := range table.Columns {
+			columnTypes[table.Name+"."+col.Name] = col.Type
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"customers.id":       "string",
+		"customers.age":      "int",
+		"customers.score":    "float",
+		"customers.status":   "string",
+		"customers.country":  "string",
+		"orders.id":          "uuid",
+		"orders.customer_id": "string",
+		"orders.placed_at":   "timestamp",
+		"orders.note":        "sentence",
+	}, columnTypes)
+
+	customers, orders := tables[0].Columns, tables[1].Columns
+	assert.Equal(t, DictionaryColumn{Name: "id", Type: "string", Strategy: "pattern", Description: "pattern C######", Notes: []string{"parent key"}}, customers[0])
+	assert.Equal(t, "[18, 90]", customers[1].Range)
+	assert.Equal(t, "(0, *) step 0.5", customers[2].Range)
+	assert.Equal(t, "one of active, closed", customers[3].Description)
+	assert.Equal(t, "always NZ", customers[4].Description)
+	assert.Equal(t, DictionaryColumn{Name: "customer_id", Type: "string", Strategy: "foreign", Description: "references customers.id", Notes: []string{"null 10% of the time"}}, orders[1])
+	assert.Equal(t, "random timestamp formatted 2006-01-02", orders[2].Description)
+	assert.Equal(t, []string{"only when customer_id != nil"}, orders[3].Notes)
+}
+
+func TestWriteDictionary(t *testing.T) {
+	tables := Dictionary(loadTempManifest(t, dictionaryManifest))
+
+	var markdown bytes.Buffer
+	assert.NoError(t, WriteDictionary(&markdown, tables[1:], DictionaryMarkdown))
+	assert.Equal(t, `# Data Dictionary
+
+## orders
+
+Depends on customers. 50 records per run.
+
+| Column | Type | Generation | Range | Notes |
+| --- | --- | --- | --- | --- |
+| id | uuid | generated: random uuid |  | unique |
+| customer_id | string | foreign: references customers.id |  | null 10% of the time |
+| placed_at | timestamp | generated: random timestamp formatted 2006-01-02 |  |  |
+| note | sentence | generated: random sentence |  | only when customer_id != nil |
+`, markdown.String())
+
+	var encoded bytes.Buffer
+	assert.NoError(t, WriteDictionary(&encoded, tables, DictionaryJSON))
+	var decoded []DictionaryTable
+	assert.NoError(t, json.Unmarshal(encoded.Bytes(), &decoded))
+	assert.Equal(t, tables, decoded)
+
+	assert.Error(t, WriteDictionary(&encoded, tables, "html"))
+}
+
+func TestMarkdownCell(t *testing.T) {
+	assert.Equal(t, `a \| b c`, markdownCell("a | b\nc"))
+}
@@ -0,0 +1,71 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// resolveEnums replaces every enum reference, including those of nested columns,
+// with the referenced enum's values so generation only ever sees value lists
+func resolveEnums(schema *types.Schema) error {
+	var problems []string
+	names := make([]string, 0, len(schema.Enums))
+	for name := range schema.Enums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if len(schema.Enums[name]) == 0 {
+			problems = append(problems, fmt.Sprintf("enum %s has no values", name))
+		}
+	}
+	for t := range schema.Tables {
+		table := &schema.Tables[t]
+		for c := range table.Columns {
+			problems = append(problems, resolveColumnEnums(schema.Enums, table.Name, table.Columns[c].Name, &table.Columns[c])...)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("enum resolution failed: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// resolveColumnEnums resolves the enum of col and its nested columns in place
+func resolveColumnEnums(enums map[string][]string, tableName string, path string, col *types.Column) []string {
+	var problems []string
+	if col.Enum != "" {
+		values, ok := enums[col.Enum]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("table %s column %s references unknown enum %s", tableName, path, col.Enum))
+		case len(col.Value) > 0:
+			problems = append(problems, fmt.Sprintf("table %s column %s sets both enum and value", tableName, path))
+		default:
+			col.Value = append([]string(nil), values...)
+		}
+	}
+
+	for i := range col.UDTConfig.Fields {
+		field := &col.UDTConfig.Fields[i]
+		problems = append(problems, resolveColumnEnums(enums, tableName, path+"."+field.Name, field)...)
+	}
+	for i := range col.TupleConfig.Elements {
+		problems = append(problems, resolveColumnEnums(enums, tableName, fmt.Sprintf("%s[%d]", path, i), &col.TupleConfig.Elements[i])...)
+	}
+	for i := range col.ObjectsConfig.Fields {
+		field := &col.ObjectsConfig.Fields[i]
+		problems = append(problems, resolveColumnEnums(enums, tableName, path+"[]."+field.Name, field)...)
+	}
+	if col.ListConfig.Element != nil {
+		problems = append(problems, resolveColumnEnums(enums, tableName, path+"[]", col.ListConfig.Element)...)
+	}
+	if col.SetConfig.Element != nil {
+		problems = append(problems, resolveColumnEnums(enums, tableName, path+"[]", col.SetConfig.Element)...)
+	}
+	return problems
+}
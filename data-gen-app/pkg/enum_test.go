@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+func TestSharedEnum(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+enums:
+  status_values: [active, suspended, closed]
+tables:
+- name: accounts
+  columns:
+  - name: id
+    pattern: "ACC####"
+    parent: true
+  - name: status
+    enum: status_values
+- name: cards
+  columns:
+  - name: account_id
+    foreign: accounts.id
+  - name: status
+    enum: status_values
+`)
+
+	records, err := Generate(manifestPath, 20)
+	if !assert.NoError(t, err) {
+		return
+	}
+	statuses := []string{"active", "suspended", "closed"}
+	for _, table := range []string{"accounts", "cards"} {
+		assert.Len(t, records[table], 20)
+		for _, record := range records[table] {
+			assert.Contains(t, statuses, record["status"])
+		}
+	}
+}
+
+func TestResolveEnums(t *testing.T) {
+	schema := types.Schema{
+		Enums: map[string][]string{"status": {"on", "off"}, "empty": {}},
+		Tables: []types.Table{{
+			Name: "devices",
+			Columns: []types.Column{
+				{Name: "state", Enum: "status"},
+				{Name: "mode", Enum: "modes"},
+				{Name: "power", Enum: "status", Value: []string{"high"}},
+				{Name: "parts", Type: "objects", ObjectsConfig: types.ObjectsConfig{
+					Min: 1, Max: 2, Fields: []types.Column{{Name: "state", Enum: "status"}},
+				}},
+			},
+		}},
+	}
+
+	err := resolveEnums(&schema)
+	assert.EqualError(t, err, "enum resolution failed: "+
+		"enum empty has no values, "+
+		"table devices column mode references unknown enum modes, "+
+		"table devices column power sets both enum and value")
+	assert.Equal(t, []string{"on", "off"}, schema.Tables[0].Columns[0].Value)
+	assert.Equal(t, []string{"on", "off"}, schema.Tables[0].Columns[3].ObjectsConfig.Fields[0].Value)
+}
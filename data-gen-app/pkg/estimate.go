@@ -0,0 +1,319 @@
+package pkg
+
+import (
+	"math"
+	"strings"
+
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// TableEstimate is the approximate CSV output of one table
+type TableEstimate struct {
+	Table    string
+	Rows     int
+	RowBytes int   // Average bytes per data row, including separators and newline
+	Bytes    int64 // Header and every row
+}
+
+// Average widths, in bytes, of values whose size does not follow from the column config
+const (
+	wordWidth        = 7  // gofakeit word
+	nameWidth        = 13 // gofakeit first and last name
+	sentenceWordSize = 6  // Average word plus its space or punctuation
+	jsonFieldWidth   = 12 // Rendered JSON field value
+	defaultTimeWidth = 19 // 2006-01-02 15:04:05
+	elementSeparator = 1  // Comma between collection elements
+)
+
+// Estimate approximates, without generating anything, the rows and CSV bytes
+// each table of the manifest would produce for count records, honouring table
+// counts and options such as WithTableCounts. Value widths are derived from the
+// column types, ranges, patterns and value lists.
+func Estimate(schema *types.Schema, count int, opts ...Option) []TableEstimate {
+	o := newOptions(opts)
+
+	columns := make(map[string]types.Column) // Columns by table.column, to size foreign keys
+	for _, table := range schema.Tables {
+		for _, col := range table.Columns {
+			columns[table.Name+"."+col.Name] = col
+		}
+	}
+
+	var estimates []TableEstimate
+	for _, table := range sortTablesByDependency(schema.Tables) {
+		rowBytes, headerBytes := 0.0, 0
+		for i, col := range table.Columns {
+			width := columnWidth(col, columns)
+			if col.NullProbability > 0 && col.Foreign != "" {
+				width *= 1 - col.NullProbability
+			}
+			rowBytes += width
+			headerBytes += len(col.Name)
+			if i > 0 {
+				rowBytes++
+				headerBytes++
+			}
+		}
+		rowBytes++ // Newline
+		headerBytes++
+
+		rows := o.tableCount(table, count)
+		average := int(math.Round(rowBytes))
+		estimates = append(estimates, TableEstimate{
+			Table:    table.Name,
+			Rows:     rows,
+			RowBytes: average,
+			Bytes:    int64(headerBytes) + int64(math.Round(rowBytes*float64(rows))),
+		})
+	}
+	return estimates
+}
+
+// columnWidth returns the average width in bytes of col's rendered values
+func columnWidth(col types.Column, columns map[string]types.Column) float64 {
+	switch {
+	case col.Const != "":
+		return float64(len(col.Const))
+	case col.Foreign != "":
+		if parent, ok := columns[col.Foreign]; ok {
+			return columnWidth(parent, columns)
+		}
+		return wordWidth
+	case len(col.Value) > 0:
+		return averageLength(col.Value)
+	case len(col.ValuesFiles) > 0:
+		return valuesFilesWidth(col.ValuesFiles)
+	case col.Pattern != "":
+		return float64(len(col.Pattern))
+	}
+
+	switch col.Type {
+	case "int", "bigint", "long":
+		return math.Max(float64(col.Width), integerWidth(col.Range, 0, 1000000))
+	case "float", "decimal":
+		// Integer digits plus the two decimals the CSV sink writes by default
+		return integerWidth(col.Range, 0, 100) + 3
+	case "bool":
+		return 4.5
+	case "uuid":
+		return 36
+	case "ulid":
+		return 26
+	case "date", "timestamp":
+		return timeWidth(col)
+	case "hash":
+		return hashWidth(col.HashConfig)
+	case "phone":
+		switch col.Format {
+		case "", phoneNational:
+			return 14
+		case phoneE164:
+			return 12
+		default:
+			return float64(len(col.Format))
+		}
+	case "ssn":
+		return 11
+	case "ein":
+		return 10
+	case "creditcard":
+		if col.Network == "amex" {
+			return 15
+		}
+		return 16
+	case "card_expiry":
+		return 5
+	case "card_cvv":
+		if col.Network == "amex" {
+			return 4
+		}
+		return 3
+	case "template":
+		// Tokens expand to values of roughly a word each
+		tokens := templateToken.FindAllString(col.Template, -1)
+		return float64(len(templateToken.ReplaceAllString(col.Template, "")) + len(tokens)*wordWidth)
+	case "sentence", "paragraph":
+		return textWidth(col)
+	case "json":
+		return jsonWidth(col.JSONConfig)
+	case "map":
+		entries := averageSize(col.MapConfig.MinEntries, col.MapConfig.MaxEntries)
+		return 2 + entries*(2*wordWidth+2) // {k:v,...}
+	case "list", "set":
+		minElements, maxElements, values, pattern := col.ListConfig.MinElements, col.ListConfig.MaxElements, col.ListConfig.Values, col.ListConfig.Pattern
+		if col.Type == "set" {
+			minElements, maxElements, values, pattern = col.SetConfig.MinElements, col.SetConfig.MaxElements, col.SetConfig.Values, col.SetConfig.Pattern
+		}
+		element := float64(wordWidth)
+		switch elements := collectionElements(col); {
+		case len(values) > 0:
+			element = averageLength(values)
+		case pattern != "":
+			element = float64(len(pattern))
+		case len(elements) > 0:
+			element = columnWidth(elements[0], columns)
+		}
+		return 2 + averageSize(minElements, maxElements)*(element+elementSeparator)
+	case "udt":
+		return 2 + fieldsWidth(col.UDTConfig.Fields, columns)
+	case "tuple":
+		width := 2.0
+		for _, element := range col.TupleConfig.Elements {
+			width += columnWidth(element, columns) + elementSeparator
+		}
+		return width
+	case "objects":
+		// Objects are JSON encoded, with quoted names and string values
+		objects := (float64(col.ObjectsConfig.Min) + float64(max(col.ObjectsConfig.Max, col.ObjectsConfig.Min))) / 2
+		return 2 + objects*(2+fieldsWidth(col.ObjectsConfig.Fields, columns)+4*float64(len(col.ObjectsConfig.Fields)))
+	}
+
+	if strings.Contains(col.Name, "name") {
+		return nameWidth
+	}
+	return wordWidth
+}
+
+// averageLength returns the average length of values
+func averageLength(values []string) float64 {
+	total := 0
+	for _, value := range values {
+		total += len(value)
+	}
+	return float64(total) / float64(len(values))
+}
+
+// valuesFilesWidth returns the average length of values drawn from files by weight
+func valuesFilesWidth(files []types.ValuesFile) float64 {
+	width, total := 0.0, 0.0
+	for _, file := range files {
+		width += file.RelativeWeight() * averageLength(file.Values)
+		total += file.RelativeWeight()
+	}
+	return width / total
+}
+
+// averageSize returns the average collection size for min and max, using the
+// generator's defaults when neither is set
+func averageSize(min, max int) float64 {
+	if min == 0 && max == 0 {
+		min, max = 1, 3
+	}
+	if max < min {
+		max = min
+	}
+	return float64(min+max) / 2
+}
+
+// integerWidth returns the average number of characters of integers drawn
+// uniformly from the range, using the defaults when a bound is not set
+func integerWidth(r types.Range, defaultMin, defaultMax float64) float64 {
+	low, high := defaultMin, defaultMax
+	if v, ok := toFloat(r.Min); ok {
+		low = v
+	}
+	if v, ok := toFloat(r.Max); ok {
+		high = v
+	}
+	low, high = math.Floor(low), math.Floor(high)
+	if high < low {
+		high = low
+	}
+
+	// Negative values are their magnitude's digits plus the sign
+	total, n := 0.0, high-low+1
+	if low < 0 {
+		negativeHigh := math.Min(high, -1)
+		total += digitsTotal(-negativeHigh, -low) + (negativeHigh - low + 1)
+		low = 0
+	}
+	if high >= 0 {
+		total += digitsTotal(low, high)
+	}
+	return total / n
+}
+
+// digitsTotal returns the total number of digits of the integers in [low, high], low >= 0
+func digitsTotal(low, high float64) float64 {
+	total := 0.0
+	for digits, start := 1.0, 0.0; start <= high; digits, start = digits+1, math.Pow(10, digits) {
+		end := math.Pow(10, digits) - 1
+		from, to := math.Max(start, low), math.Min(end, high)
+		if from <= to {
+			total += digits * (to - from + 1)
+		}
+	}
+	return total
+}
+
+// timeWidth returns the width of rendered dates and timestamps
+func timeWidth(col types.Column) float64 {
+	switch col.Format {
+	case unixFormat:
+		return 10
+	case unixMsFormat:
+		return 13
+	case "":
+		if col.Type == "date" {
+			return 10
+		}
+		return defaultTimeWidth
+	default:
+		return float64(len(col.Format))
+	}
+}
+
+// hashWidth returns the length of an encoded digest
+func hashWidth(cfg types.HashConfig) float64 {
+	size := 32 // sha256
+	switch cfg.Algorithm {
+	case "sha1":
+		size = 20
+	case "md5":
+		size = 16
+	}
+	if cfg.Encoding == "base64" {
+		return float64((size + 2) / 3 * 4)
+	}
+	return float64(size * 2)
+}
+
+// textWidth returns the width of generated sentences and paragraphs
+func textWidth(col types.Column) float64 {
+	words := col.Words
+	if words <= 0 {
+		words = 5
+	}
+	if col.Type != "paragraph" {
+		return float64(words * sentenceWordSize)
+	}
+	sentences, paragraphs := col.Sentences, col.Paragraphs
+	if sentences <= 0 {
+		sentences = 3
+	}
+	if paragraphs <= 0 {
+		paragraphs = 1
+	}
+	return float64(paragraphs * sentences * words * sentenceWordSize)
+}
+
+// jsonWidth returns the width of a rendered JSON object, {name:value,...}
+func jsonWidth(fields types.JSONConfig) float64 {
+	if len(fields) == 0 {
+		return 2 + 3*(wordWidth+jsonFieldWidth+2)
+	}
+	width := 2.0
+	for _, field := range fields {
+		width += float64(len(field.Name)+2) + jsonFieldWidth
+	}
+	return width
+}
+
+// fieldsWidth returns the width of named fields rendered as name:value pairs
+func fieldsWidth(fields []types.Column, columns map[string]types.Column) float64 {
+	width := 0.0
+	for _, field := range fields {
+		width += float64(len(field.Name)+2) + columnWidth(field, columns)
+	}
+	return width
+}
@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/sink"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+func TestEstimate(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: customers
+  columns:
+  - name: id
+    pattern: "C######"
+    parent: true
+  - name: external_id
+    type: uuid
+  - name: age
+    type: int
+    range:
+      min: 18
+      max: 90
+  - name: status
+    value: [active, suspended, closed]
+- name: orders
+  depends_on: customers
+  count: 400
+  columns:
+  - name: id
+    type: bigint
+    range:
+      min: 1000
+      max: 99999
+  - name: customer_id
+    foreign: customers.id
+  - name: total
+    type: float
+    range:
+      min: 1
+      max: 500
+  - name: placed_at
+    type: timestamp
+  - name: paid
+    type: bool
+`)
+	schema, err := LoadSchema(manifestPath)
+	assert.NoError(t, err)
+	estimates := Estimate(schema, 200)
+	assert.Len(t, estimates, 2)
+	assert.Equal(t, "customers", estimates[0].Table)
+	assert.Equal(t, 200, estimates[0].Rows)
+	assert.Equal(t, "orders", estimates[1].Table)
+	assert.Equal(t, 400, estimates[1].Rows)
+
+	outputDir := t.TempDir()
+	csvSink, err := sink.NewCSVSink(outputDir, schema)
+	assert.NoError(t, err)
+	assert.NoError(t, GenerateData(csvSink, 200, manifestPath))
+
+	for _, estimate := range estimates {
+		info, err := os.Stat(filepath.Join(outputDir, estimate.Table+".csv"))
+		assert.NoError(t, err)
+		actual := float64(info.Size())
+		assert.InDelta(t, actual, float64(estimate.Bytes), actual*0.1, estimate.Table)
+	}
+
+	estimates = Estimate(schema, 200, WithTableCounts(map[string]int{"orders": 50}))
+	assert.Equal(t, 50, estimates[1].Rows)
+}
+
+func TestIntegerWidth(t *testing.T) {
+	tests := []struct {
+		name     string
+		min, max interface{}
+		expected float64
+	}{
+		{"single digits", 0, 9, 1},
+		{"two digits", 10, 99, 2},
+		{"mixed digits", 0, 99, 1.9},
+		{"negative", -9, 0, 1.9},
+		{"defaults", nil, nil, 3.889},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width := integerWidth(types.Range{Min: tt.min, Max: tt.max}, 0, 9999)
+			assert.InDelta(t, tt.expected, width, 0.001)
+		})
+	}
+}
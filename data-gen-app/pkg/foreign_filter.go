@@ -0,0 +1,107 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// filterKey identifies a foreign_filter by the parent column it selects from,
+// children using the same filter on the same parent share its matches
+type filterKey struct {
+	foreign string
+	filter  string
+}
+
+// foreignFilter holds the compiled filter and the keys of the parents that matched it
+type foreignFilter struct {
+	parentTable  string
+	parentColumn string
+	program      *vm.Program
+	matches      []string
+	seen         int // Matching parents, including any the sampler dropped
+}
+
+// foreignFilters are the foreign_filter expressions of a schema. Each is evaluated
+// once per parent record, as the parent is generated, so only the matching keys
+// are kept rather than every parent record.
+type foreignFilters struct {
+	byKey   map[filterKey]*foreignFilter
+	ordered []*foreignFilter // Manifest order, so seeded runs sample alike
+}
+
+// newForeignFilters compiles the foreign_filter of every foreign column
+func newForeignFilters(schema types.Schema) (foreignFilters, error) {
+	filters := foreignFilters{byKey: make(map[filterKey]*foreignFilter)}
+	for _, table := range schema.Tables {
+		for _, col := range table.Columns {
+			if col.ForeignFilter == "" {
+				continue
+			}
+			key := filterKey{foreign: col.Foreign, filter: col.ForeignFilter}
+			if filters.byKey[key] != nil {
+				continue
+			}
+			program, err := expr.Compile(col.ForeignFilter, expr.Env(initEnv(nil, nil)), expr.AllowUndefinedVariables())
+			if err != nil {
+				return foreignFilters{}, fmt.Errorf("table %s column %s: foreign_filter %q: %v", table.Name, col.Name, col.ForeignFilter, err)
+			}
+			parentTable, parentColumn, _ := strings.Cut(col.Foreign, ".")
+			filter := &foreignFilter{parentTable: parentTable, parentColumn: parentColumn, program: program}
+			filters.byKey[key] = filter
+			filters.ordered = append(filters.ordered, filter)
+		}
+	}
+	return filters, nil
+}
+
+// observe evaluates the filters selecting from table against a newly generated
+// record, exposed to the expression as parent.<table>, and offers its key to
+// sampler when the filter holds
+func (f foreignFilters) observe(table types.Table, record map[string]interface{}, sampler keySampler, strict bool) error {
+	for _, filter := range f.ordered {
+		if filter.parentTable != table.Name {
+			continue
+		}
+		env := initEnv(nil, map[string]interface{}{table.Name: record})
+		output, err := expr.Run(filter.program, env)
+		if err == nil {
+			if _, ok := output.(bool); !ok {
+				err = fmt.Errorf("expression did not evaluate to a boolean")
+			}
+		}
+		if err != nil {
+			err = fmt.Errorf("error evaluating foreign_filter of %s.%s: %v", table.Name, filter.parentColumn, err)
+			if err := expressionFailure(strict, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if output.(bool) {
+			filter.matches = sampler.add(filter.matches, filter.seen, fmt.Sprint(record[filter.parentColumn]))
+			filter.seen++
+		}
+	}
+	return nil
+}
+
+// foreignKeys are the parent keys foreign columns pick from
+type foreignKeys struct {
+	all      map[string][]string // Keys of every parent column, by table.column
+	filtered foreignFilters
+}
+
+// candidates returns the keys col may reference, only those matching its
+// foreign_filter when it has one
+func (k foreignKeys) candidates(col types.Column) []string {
+	if col.ForeignFilter != "" {
+		if filter := k.filtered.byKey[filterKey{foreign: col.Foreign, filter: col.ForeignFilter}]; filter != nil {
+			return filter.matches
+		}
+		return nil
+	}
+	return k.all[col.Foreign]
+}
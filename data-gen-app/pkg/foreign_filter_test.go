@@ -0,0 +1,95 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const foreignFilterManifest = `
+tables:
+- name: customers
+  columns:
+  - name: id
+    pattern: "C######"
+    parent: true
+    validation:
+      unique: true
+  - name: status
+    value: [active, suspended, closed]
+- name: orders
+  depends_on: customers
+  columns:
+  - name: id
+    type: uuid
+  - name: customer_id
+    foreign: customers.id
+    foreign_filter: "parent.customers.status == 'active'"
+  - name: referrer_id
+    foreign: customers.id
+`
+
+func TestForeignFilter(t *testing.T) {
+	rows, err := Generate(writeTempManifest(t, foreignFilterManifest), 200)
+	assert.NoError(t, err)
+
+	status := make(map[string]string)
+	for _, customer := range rows["customers"] {
+		status[customer["id"].(string)] = customer["status"].(string)
+	}
+	referrers := make(map[string]bool)
+	for _, order := range rows["orders"] {
+		assert.Equal(t, "active", status[order["customer_id"].(string)], order["customer_id"])
+		referrers[status[order["referrer_id"].(string)]] = true
+	}
+	// Columns without a filter still reference every parent
+	assert.Equal(t, map[string]bool{"active": true, "suspended": true, "closed": true}, referrers)
+}
+
+func TestForeignFilterWithoutMatches(t *testing.T) {
+	manifest := `
+tables:
+- name: customers
+  columns:
+  - name: id
+    pattern: "C######"
+    parent: true
+  - name: status
+    value: [closed]
+- name: orders
+  depends_on: customers
+  columns:
+  - name: customer_id
+    foreign: customers.id
+    foreign_filter: "parent.customers.status == 'active'"
+`
+	rows, err := Generate(writeTempManifest(t, manifest), 10)
+	assert.NoError(t, err)
+	for _, order := range rows["orders"] {
+		assert.Nil(t, order["customer_id"])
+	}
+}
+
+func TestForeignFilterErrors(t *testing.T) {
+	manifest := `
+tables:
+- name: customers
+  columns:
+  - name: id
+    pattern: "C######"
+    parent: true
+- name: orders
+  depends_on: customers
+  columns:
+  - name: customer_id
+    foreign: customers.id
+    foreign_filter: "parent.customers.id"
+`
+	_, err := Generate(writeTempManifest(t, manifest), 10, WithStrict(true))
+	assert.ErrorContains(t, err, "error evaluating foreign_filter of customers.id: expression did not evaluate to a boolean")
+
+	// Without strict the parent does not match and the error is logged
+	rows, err := Generate(writeTempManifest(t, manifest), 10)
+	assert.NoError(t, err)
+	assert.Nil(t, rows["orders"][0]["customer_id"])
+}
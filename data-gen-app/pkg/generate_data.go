@@ -1,16 +1,19 @@
 package pkg
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
-	"sort"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v7"
 	"github.com/expr-lang/expr"
+	"github.com/google/uuid"
 	"github.com/sujanks/data-gen-app/pkg/sink"
 	"github.com/sujanks/data-gen-app/pkg/types"
 	"gopkg.in/yaml.v3"
@@ -24,26 +27,61 @@ type Generator struct {
 
 const hashtag = '#'
 
+// maxUniqueAttempts bounds how often a duplicate value of a unique column is regenerated
+const maxUniqueAttempts = 1000
+
+const (
+	// durationRoundSize is the records per table of each round of a duration limited run without a count
+	durationRoundSize = 1000
+	// durationMaxParentKeys is the parent keys kept per column by duration limited
+	// runs that set no maximum, sampled as WithMaxParentKeys does
+	durationMaxParentKeys = 100000
+	// durationMaxUniqueValues bounds the unique values a duration limited run remembers
+	durationMaxUniqueValues = 1000000
+)
+
+const (
+	// defaultTimeFormat is the layout used when a column has no format
+	defaultTimeFormat = "2006-01-02 15:04:05"
+	// unixFormat renders timestamps as int64 unix seconds
+	unixFormat = "unix"
+	// unixMsFormat renders timestamps as int64 unix milliseconds
+	unixMsFormat = "unix_ms"
+)
+
 // NewGenerator creates a new data generator
 func NewGenerator(manifestPath string, sink sink.DataSink) (*Generator, error) {
-	// Read manifest file
-	data, err := os.ReadFile(manifestPath)
+	schema, err := LoadSchema(manifestPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read manifest file: %v", err)
-	}
-
-	// Parse manifest
-	var schema types.Schema
-	if err := yaml.Unmarshal(data, &schema); err != nil {
-		return nil, fmt.Errorf("failed to parse manifest: %v", err)
+		return nil, err
 	}
 
 	return &Generator{
-		schema: &schema,
+		schema: schema,
 		sink:   sink,
 	}, nil
 }
 
+// LoadSchema reads, parses and validates the manifest file into a schema
+func LoadSchema(manifestPath string) (*types.Schema, error) {
+	file, err := os.Open(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file %v", err)
+	}
+	defer file.Close()
+	return LoadSchemaFrom(file, filepath.Dir(manifestPath))
+}
+
+// LoadSchemaFrom reads, parses and validates a manifest from r into a schema,
+// resolving the paths of its values files relative to dir
+func LoadSchemaFrom(r io.Reader, dir string) (*types.Schema, error) {
+	schema, err := readManifest(r, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
 // parseTimeRange parses time range from min/max strings using the specified format
 func parseTimeRange(format string, minStr, maxStr interface{}) (time.Time, time.Time, error) {
 	zero := time.Time{}
@@ -52,28 +90,44 @@ func parseTimeRange(format string, minStr, maxStr interface{}) (time.Time, time.
 		return zero, zero, fmt.Errorf("min or max is nil")
 	}
 
-	minTimeStr, okMin := minStr.(string)
-	maxTimeStr, okMax := maxStr.(string)
-	if !okMin || !okMax {
-		return zero, zero, fmt.Errorf("min or max is not a string")
-	}
-
-	minTime, err1 := time.Parse(format, minTimeStr)
-	maxTime, err2 := time.Parse(format, maxTimeStr)
+	minTime, err1 := parseTimeBound(format, minStr)
+	maxTime, err2 := parseTimeBound(format, maxStr)
 	if err1 != nil || err2 != nil {
 		return zero, zero, fmt.Errorf("parse error: %v, %v", err1, err2)
 	}
 
+	if minTime.After(maxTime) {
+		return zero, zero, fmt.Errorf("min %q is after max %q", minStr, maxStr)
+	}
+
 	return minTime, maxTime, nil
 }
 
+// parseTimeBound parses one bound of a time range, which must be a string in format
+func parseTimeBound(format string, bound interface{}) (time.Time, error) {
+	s, ok := bound.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%v is not a string", bound)
+	}
+	return time.Parse(format, s)
+}
+
+// randomTimeInRange returns a random time between minTime and maxTime inclusive,
+// a zero-width range always yields exactly that instant
+func randomTimeInRange(faker *gofakeit.Faker, minTime, maxTime time.Time) time.Time {
+	if minTime.Equal(maxTime) {
+		return minTime
+	}
+	return faker.DateRange(minTime, maxTime)
+}
+
 // Register the UDTGenerator.Generate method implementation
 func init() {
 	// Set up the UDTGenerator implementation
 	types.RegisterGenerateUDT(func(g *types.UDTGenerator) interface{} {
 		result := make(map[string]interface{})
 		for _, field := range g.Config.Fields {
-			result[field.Name] = generateColumnValue(field)
+			result[field.Name] = generateColumnValue(field, g.Rand())
 		}
 		return result
 	})
@@ -82,15 +136,38 @@ func init() {
 	types.RegisterGenerateTuple(func(g *types.TupleGenerator) interface{} {
 		result := make([]interface{}, len(g.Config.Elements))
 		for i, element := range g.Config.Elements {
-			result[i] = generateColumnValue(element)
+			result[i] = generateColumnValue(element, g.Rand())
 		}
 		return result
 	})
 
+	// Generate list and set elements configured with a full column
+	types.RegisterGenerateElement(generateColumnValue)
+
+	// Set up the ObjectsGenerator implementation
+	types.RegisterGenerateObjects(func(g *types.ObjectsGenerator) interface{} {
+		count := g.Config.Min
+		if g.Config.Max > g.Config.Min {
+			count = g.Rand().IntRange(g.Config.Min, g.Config.Max)
+		}
+		result := make([]interface{}, count)
+		for i := range result {
+			object := make(map[string]interface{})
+			for _, field := range g.Config.Fields {
+				object[field.Name] = generateColumnValue(field, g.Rand())
+			}
+			result[i] = object
+		}
+		return result
+	})
+
+	// Set up pattern substitution for string, list and JSON field patterns
+	types.RegisterStringPatternHandler(replaceWithNumbers)
+
 	// Set up the TimeGenerator implementation
 	types.RegisterGenerateTime(func(g *types.TimeGenerator) interface{} {
-		format := "2006-01-02 15:04:05"
-		if g.Column.Format != "" {
+		format := defaultTimeFormat
+		if g.Column.Format != "" && !isEpochFormat(g.Column.Format) {
 			format = g.Column.Format
 		}
 
@@ -101,209 +178,812 @@ func init() {
 			minTime, maxTime, err := parseTimeRange(format, g.Column.Range.Min, g.Column.Range.Max)
 			if err == nil {
 				if isDateOnly {
-					return gofakeit.DateRange(minTime, maxTime).Format(format)
+					return randomTimeInRange(g.Rand(), minTime, maxTime).Format(format)
 				}
-				return gofakeit.DateRange(minTime, maxTime)
+				return epochValue(g.Column.Format, randomTimeInRange(g.Rand(), minTime, maxTime))
 			}
+			log.Printf("invalid range for column %s: %v", g.Column.Name, err)
 		}
 
 		// Default to current time if range is not specified or invalid
 		if isDateOnly {
 			return time.Now().Format(format)
 		}
-		return time.Now()
+		return epochValue(g.Column.Format, time.Now())
 	})
 }
 
-// NewValueGenerator creates a new value generator based on the column type
-func NewValueGenerator(col types.Column) types.ValueGenerator {
+// isEpochFormat reports whether format requests an integer epoch instead of a layout
+func isEpochFormat(format string) bool {
+	return format == unixFormat || format == unixMsFormat
+}
+
+// epochValue converts t to unix seconds or milliseconds when format asks for it,
+// otherwise t is returned unchanged
+func epochValue(format string, t time.Time) interface{} {
+	switch format {
+	case unixFormat:
+		return t.Unix()
+	case unixMsFormat:
+		return t.UnixMilli()
+	default:
+		return t
+	}
+}
+
+// NewValueGenerator creates a new value generator based on the column type, drawing
+// values from faker or from gofakeit's global faker when faker is nil. Types added
+// with RegisterType take precedence over the built-in ones.
+func NewValueGenerator(col types.Column, faker *gofakeit.Faker) types.ValueGenerator {
+	if factory, ok := registeredType(col.Type); ok {
+		return factory(col)
+	}
+
+	base := types.BaseGenerator{Faker: faker}
 	switch col.Type {
 	case "map":
-		return &types.MapGenerator{Config: col.MapConfig}
+		// Key and value types may be declared on the column itself
+		cfg := col.MapConfig
+		if cfg.KeyType == "" {
+			cfg.KeyType = col.KeyType
+		}
+		if cfg.ValueType == "" {
+			cfg.ValueType = col.ValueType
+		}
+		return &types.MapGenerator{BaseGenerator: base, Config: cfg}
 	case "set":
-		return &types.SetGenerator{Config: col.SetConfig}
+		return &types.SetGenerator{BaseGenerator: base, Config: col.SetConfig}
 	case "list":
-		return &types.ListGenerator{Config: col.ListConfig}
+		return &types.ListGenerator{BaseGenerator: base, Config: col.ListConfig}
 	case "udt":
-		return &types.UDTGenerator{Config: col.UDTConfig}
+		return &types.UDTGenerator{BaseGenerator: base, Config: col.UDTConfig}
 	case "tuple":
-		return &types.TupleGenerator{Config: col.TupleConfig}
+		return &types.TupleGenerator{BaseGenerator: base, Config: col.TupleConfig}
+	case "objects":
+		return &types.ObjectsGenerator{BaseGenerator: base, Config: col.ObjectsConfig}
 	case "float", "decimal":
-		return &types.NumericGenerator{Config: col.Range, IsFloat: true}
+		return &types.NumericGenerator{BaseGenerator: base, Config: col.Range, IsFloat: true}
 	case "int":
-		return &types.NumericGenerator{Config: col.Range, IsFloat: false}
+		return &types.NumericGenerator{BaseGenerator: base, Config: col.Range, IsFloat: false}
+	case "bigint", "long":
+		return &types.BigIntGenerator{BaseGenerator: base, Config: col.Range}
 	case "string":
-		return &types.StringGenerator{Column: col}
+		return &types.StringGenerator{BaseGenerator: base, Column: col}
+	case "sentence", "paragraph":
+		return &types.TextGenerator{BaseGenerator: base, Column: col}
 	case "date", "timestamp":
-		return &types.TimeGenerator{Column: col}
+		return &types.TimeGenerator{BaseGenerator: base, Column: col}
 	case "json":
-		return &types.JSONGenerator{Config: col.JSONConfig}
-	case "uuid":
-		// Handle UUID specially, don't use a generator
+		return &types.JSONGenerator{BaseGenerator: base, Config: col.JSONConfig}
+	case "uuid", "ulid", "bool", "hash", "phone", "ssn", "ein", "creditcard", "card_expiry", "card_cvv", "template":
+		// Handle identifiers, bool, phone, ssn, ein, the card types and template
+		// specially and derive hashes, don't use a generator
 		return nil
 	default:
-		return &types.StringGenerator{Column: col}
+		return &types.StringGenerator{BaseGenerator: base, Column: col}
 	}
 }
 
-func GenerateData(ds sink.DataSink, count int, profile string) {
-	tables := readManifest(profile)
-	sortedTables := sortTablesByDependency(tables.Tables)
-	parentKeyValues := make(map[string][]string, 0)
+// Record is a single generated row together with the table it belongs to
+type Record struct {
+	Table string
+	Data  map[string]interface{}
+}
 
-	for _, table := range sortedTables {
-		for i := 0; i < count; i++ {
-			var tableData = make(map[string]interface{})
+// GenerateData generates count records per table from the manifest and writes them to ds,
+// the sink is closed once generation finishes so buffered records are not lost
+func GenerateData(ds sink.DataSink, count int, profile string, opts ...Option) error {
+	schema, err := LoadSchema(profile)
+	if err != nil {
+		ds.Close()
+		return err
+	}
+	return GenerateSchema(ds, count, schema, opts...)
+}
 
-			// First pass: generate all basic values
-			for _, col := range table.Columns {
-				var colValue interface{}
-				if col.Foreign != "" {
-					// Handle foreign key reference
-					if len(parentKeyValues[col.Foreign]) > 0 {
-						colValue = gofakeit.RandomString(parentKeyValues[col.Foreign])
+// GenerateSchema generates count records per table of a loaded schema and writes
+// them to ds, closing the sink once generation finishes like GenerateData
+func GenerateSchema(ds sink.DataSink, count int, schema *types.Schema, opts ...Option) (err error) {
+	defer func() {
+		if closeErr := ds.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close sink: %v", closeErr)
+		}
+	}()
+
+	inserted := 0
+	err = generateRecords(*schema, count, func(record Record) error {
+		if err := ds.InsertRecord(record.Table, record.Data); err != nil {
+			return fmt.Errorf("failed to insert record into %s: %v", record.Table, err)
+		}
+		inserted++
+		return nil
+	}, opts...)
+	if err != nil {
+		return err
+	}
+	log.Printf("%d records inserted", inserted)
+	return nil
+}
+
+// Generate generates count records per table from the manifest and returns them
+// in memory grouped by table, convenient for tests that need fixture rows
+func Generate(manifest string, count int, opts ...Option) (map[string][]map[string]interface{}, error) {
+	memorySink := sink.NewMemorySink()
+	if err := GenerateData(memorySink, count, manifest, opts...); err != nil {
+		return nil, err
+	}
+
+	rows := make(map[string][]map[string]interface{})
+	for _, table := range memorySink.Tables() {
+		rows[table] = memorySink.Records(table)
+	}
+	return rows, nil
+}
+
+// Stream is a lazily generated sequence of records, see GenerateStream
+type Stream struct {
+	records chan Record
+	err     error
+}
+
+// Records returns the channel of generated records, closed once generation ends
+func (s *Stream) Records() <-chan Record {
+	return s.records
+}
+
+// Err returns the error that ended generation, nil when every record was generated.
+// It is only set once the Records channel has been closed.
+func (s *Stream) Err() error {
+	return s.err
+}
+
+// GenerateStream lazily generates count records per table from the manifest and emits
+// them on the stream's channel, which is closed once every table has been generated,
+// generation fails or ctx is done; Err then reports why it stopped. With WithDuration
+// it streams rounds of records until the duration elapses. Callers that stop reading
+// early must cancel ctx to release the generator.
+func GenerateStream(ctx context.Context, count int, manifest string, opts ...Option) (*Stream, error) {
+	schema, err := LoadSchema(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := &Stream{records: make(chan Record)}
+	go func() {
+		defer close(stream.records)
+		stream.err = generateRecords(*schema, count, func(record Record) error {
+			select {
+			case stream.records <- record:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}, opts...)
+	}()
+	return stream, nil
+}
+
+// generateRecords generates count records for every table in dependency order, unless
+// the table's count is set, passing each one to emit and stopping at the first emit error.
+// With a duration the tables are generated again in rounds until it elapses, count
+// defaulting to durationRoundSize.
+func generateRecords(schema types.Schema, count int, emit func(Record) error, opts ...Option) error {
+	o := newOptions(opts)
+	emit = o.trackProgress(o.trackStats(schema.Tables, emit))
+	faker := o.faker
+	if faker == nil {
+		faker = gofakeit.GlobalFaker
+	}
+	loc, err := lookupLocale(o.locale)
+	if err != nil {
+		return err
+	}
+
+	tables := schema.Tables
+	sortedTables := sortTablesByDependency(tables)
+	parentKeyValues := o.parentKeys
+	if parentKeyValues == nil {
+		parentKeyValues = make(map[string][]string, 0)
+	}
+	for _, key := range schema.ExternalKeys {
+		if len(parentKeyValues[key]) == 0 {
+			return fmt.Errorf("no parent keys loaded for external key %s", key)
+		}
+	}
+	aggregates := newAggregator(tables)
+	maxParentKeys := o.maxParentKeys
+	if o.duration > 0 {
+		// Rounds run for as long as the duration, so every piece of state kept
+		// across them is bounded
+		if len(aggregates.specs) > 0 {
+			return fmt.Errorf("aggregate columns can't be generated with a duration, their records would be held for the whole run")
+		}
+		if count == 0 {
+			count = durationRoundSize
+		}
+		if maxParentKeys == 0 {
+			maxParentKeys = durationMaxParentKeys
+		}
+	}
+	filters, err := newForeignFilters(schema)
+	if err != nil {
+		return err
+	}
+	sampler := keySampler{faker: faker, size: maxParentKeys}
+	keysSeen := sampler.trim(parentKeyValues) // Keys offered per parent column, retained or not
+
+	g := &rowGenerator{
+		o:             o,
+		faker:         faker,
+		loc:           loc,
+		keys:          foreignKeys{all: parentKeyValues, filtered: filters},
+		parents:       newParentRecords(schema),
+		schemaRules:   schemaRulesByTable(schema),
+		formats:       make(map[string]map[string]string),
+		uniqueValues:  make(map[string]map[string]bool),
+		uniqueTuples:  make(map[string]map[string]bool),
+		counterTotals: make(map[string]map[string]*runningTotal),
+		sequenceNext:  make(map[string]map[string]int),
+	}
+	for _, table := range tables {
+		g.formats[table.Name] = make(map[string]string)
+		for _, col := range table.Columns {
+			g.formats[table.Name][col.Name] = col.Format
+		}
+		g.counterTotals[table.Name] = make(map[string]*runningTotal)
+		g.sequenceNext[table.Name] = make(map[string]int)
+	}
+
+	// A duration repeats the tables in rounds until it has elapsed
+	var deadline time.Time
+	if o.duration > 0 {
+		deadline = time.Now().Add(o.duration)
+	}
+	expired := func() bool {
+		return !deadline.IsZero() && !time.Now().Before(deadline)
+	}
+
+rounds:
+	for generated := true; generated && !expired(); {
+		generated = false
+		for _, table := range sortedTables {
+			tableCount := o.tableCount(table, count)
+			for i := 0; i < tableCount; i++ {
+				if expired() {
+					break rounds
+				}
+				generated = true
+				started := time.Now()
+				tableData, err := g.row(table)
+				if err != nil {
+					return err
+				}
+
+				// Store parent values for foreign key references
+				for _, col := range table.Columns {
+					if col.Parent {
+						keyName := fmt.Sprintf("%s.%s", table.Name, col.Name)
+						parentKeyValues[keyName] = sampler.add(parentKeyValues[keyName], keysSeen[keyName], fmt.Sprint(tableData[col.Name]))
+						keysSeen[keyName]++
 					}
-				} else if len(col.Value) > 0 {
-					colValue = gofakeit.RandomString(col.Value)
-				} else if col.Pattern != "" {
-					colValue = replaceWithNumbers(col.Pattern)
-				} else {
-					colValue = generateColumnValue(col)
+				}
+				g.parents.store(table, tableData)
+				if err := filters.observe(table, tableData, sampler, o.strict); err != nil {
+					return err
 				}
 
-				// Only add non-nil values to the tableData
-				if colValue != nil || col.Mandatory {
-					tableData[col.Name] = colValue
+				// Parents with aggregate columns are emitted once their children are known,
+				// followed by the records generated after them
+				record := Record{Table: table.Name, Data: tableData}
+				aggregates.observe(record)
+				if aggregates.holds(table.Name) {
+					aggregates.hold(record)
+					o.metrics.observe(table.Name, started)
+					continue
+				}
+				if err := emit(record); err != nil {
+					return err
 				}
+				o.metrics.observe(table.Name, started)
 			}
+		}
+		if deadline.IsZero() {
+			break
+		}
+		// Parent records are only kept for the sampled keys children can still pick
+		g.parents.prune(parentKeyValues)
+		if err := checkUniqueBound(g.uniqueValues, g.uniqueTuples, durationMaxUniqueValues); err != nil {
+			return err
+		}
+	}
+	return aggregates.release(emit)
+}
 
-			// Second pass: apply rules
-			for _, col := range table.Columns {
-				if len(col.Rules) > 0 {
-					applyRules(col.Rules, tableData)
-				}
+// rowGenerator holds the state generateRecords carries from one row to the next
+type rowGenerator struct {
+	o             *options
+	faker         *gofakeit.Faker
+	loc           *locale
+	keys          foreignKeys
+	parents       parentRecords
+	schemaRules   map[string][]types.Rule
+	formats       map[string]map[string]string        // Column formats per table, to read back epochs
+	uniqueValues  map[string]map[string]bool          // Values seen per unique table.column
+	uniqueTuples  map[string]map[string]bool          // Value combinations seen per composite constraint
+	counterTotals map[string]map[string]*runningTotal // Running totals of each table's counter columns
+	sequenceNext  map[string]map[string]int           // Next value index of each table's sequential columns
+}
+
+// row generates the values of one record of table, from its first pass to its masks
+func (g *rowGenerator) row(table types.Table) (map[string]interface{}, error) {
+	faker, loc, keys, uniqueValues := g.faker, g.loc, g.keys, g.uniqueValues
+	var tableData = make(map[string]interface{})
+
+	// Optional foreign keys drawn as null stay null, without their default. Like
+	// conditional columns whose condition does not hold, they are never regenerated.
+	unset := make(map[string]bool)
+	for _, col := range table.Columns {
+		if col.Foreign != "" && col.NullProbability > 0 && faker.Float64() < col.NullProbability {
+			unset[col.Name] = true
+		}
+	}
+
+	// First pass: generate all basic values, conditional columns wait
+	// until the values they depend on exist
+	for _, col := range table.Columns {
+		if col.When == "" && col.Aggregate.Function == "" && col.Type != "hash" && col.Validation.UniquePerParent == "" {
+			if err := g.generate(table, col, tableData, unset); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Correlated columns are assigned together from one choice row
+	if len(table.Choices) > 0 {
+		for name, value := range table.Choices[faker.IntN(len(table.Choices))] {
+			tableData[name] = value
+		}
+	}
+
+	// Columns unique per parent wait for the foreign key that scopes them
+	for _, col := range table.Columns {
+		if col.When == "" && col.Validation.UniquePerParent != "" {
+			if err := g.generate(table, col, tableData, unset); err != nil {
+				return nil, err
 			}
+		}
+	}
+
+	// Conditional columns are only generated when their condition holds,
+	// conditions may read the parent records the foreign keys selected
+	for _, col := range table.Columns {
+		if col.When == "" {
+			continue
+		}
+		ok, err := evaluateExpression(col.When, tableData, g.parents.lookup(table, tableData))
+		if err != nil {
+			err = fmt.Errorf("error evaluating condition for table %s column %s: %v", table.Name, col.Name, err)
+			if err := expressionFailure(g.o.strict, err); err != nil {
+				return nil, err
+			}
+		}
+		if err != nil || !ok {
+			// Never generated, so the default applies
+			unset[col.Name] = true
+			setColumnValue(tableData, col, nil)
+			continue
+		}
+		if err := g.generate(table, col, tableData, unset); err != nil {
+			return nil, err
+		}
+	}
+
+	// Sequential columns take the next value of their list
+	applySequences(table.Columns, g.sequenceNext[table.Name], tableData)
+
+	// Regenerate the columns of composite unique constraints until their combination is new
+	for _, columns := range table.Unique {
+		if err := ensureUniqueTuple(table, columns, unset, tableData, keys, uniqueValues, g.uniqueTuples, faker, loc); err != nil {
+			return nil, err
+		}
+	}
+
+	// Counter columns add this row's delta to their running totals
+	applyCounters(table.Columns, g.counterTotals[table.Name], tableData)
 
-			if table.Rules != nil {
-				applyRules(table.Rules, tableData)
+	// Timestamps that follow another column are placed after it
+	applyAfter(faker, table.Columns, g.formats[table.Name], tableData)
+
+	// Soft deleted rows keep their deletion time, the others drop it
+	applySoftDeletes(faker, table.Columns, tableData)
+
+	// Hashes digest the values generated so far
+	applyHashes(table.Columns, tableData)
+
+	// Second pass: apply rules, with the parent records the foreign keys selected
+	recordParents := g.parents.lookup(table, tableData)
+	for _, col := range table.Columns {
+		if len(col.Rules) > 0 {
+			if err := applyRules(col.Rules, tableData, recordParents, g.o.strict); err != nil {
+				return nil, fmt.Errorf("table %s column %s: %v", table.Name, col.Name, err)
 			}
+		}
+	}
+
+	if table.Rules != nil {
+		if err := applyRules(table.Rules, tableData, recordParents, g.o.strict); err != nil {
+			return nil, fmt.Errorf("table %s: %v", table.Name, err)
+		}
+	}
 
-			// Store parent values for foreign key references
+	// Cross-table rules run last
+	if rules := g.schemaRules[table.Name]; len(rules) > 0 {
+		if err := applyRules(rules, tableData, recordParents, g.o.strict); err != nil {
+			return nil, fmt.Errorf("table %s schema rules: %v", table.Name, err)
+		}
+	}
+
+	// Fixed width numbers are padded and masks hide the final values, parent
+	// keys are stored padded and masked so children match
+	applyPadding(table.Columns, tableData)
+	applyMasks(table.Columns, tableData)
+	return tableData, nil
+}
+
+// generate sets the value of col in tableData. Optional foreign keys in nulled are
+// left null rather than taking their default.
+func (g *rowGenerator) generate(table types.Table, col types.Column, tableData map[string]interface{}, nulled map[string]bool) error {
+	if nulled[col.Name] {
+		if col.Mandatory {
+			tableData[col.Name] = nil
+		}
+		return nil
+	}
+	colValue, err := uniqueColumnValue(table.Name, col, tableData, g.keys, g.uniqueValues, g.faker, g.loc)
+	if err != nil {
+		return err
+	}
+	setColumnValue(tableData, col, colValue)
+	return nil
+}
+
+// checkUniqueBound fails a run once the unique values and combinations it remembers exceed limit
+func checkUniqueBound(uniqueValues, uniqueTuples map[string]map[string]bool, limit int) error {
+	total := 0
+	for _, sets := range []map[string]map[string]bool{uniqueValues, uniqueTuples} {
+		for _, seen := range sets {
+			total += len(seen)
+		}
+	}
+	if total > limit {
+		return fmt.Errorf("unique columns have used %d values, more than the %d a duration limited run keeps", total, limit)
+	}
+	return nil
+}
+
+// columnValue generates a value for col, resolving foreign keys against the parent values generated so far
+// and drawing name columns from loc when the run is localized
+func columnValue(col types.Column, keys foreignKeys, faker *gofakeit.Faker, loc *locale) interface{} {
+	if col.Const != "" {
+		// Constants are checked when the manifest is loaded
+		value, _ := literalValue(col, col.Const)
+		return value
+	}
+	if col.Foreign != "" {
+		// Handle foreign key reference, the rows of optional relationships left
+		// without a parent never get here
+		if candidates := keys.candidates(col); len(candidates) > 0 {
+			return faker.RandomString(candidates)
+		}
+		return nil
+	}
+	if len(col.Value) > 0 {
+		return faker.RandomString(col.Value)
+	}
+	if col.Pattern != "" {
+		value := fillPattern(faker, col.Pattern, col.AllowLeadingZero)
+		if col.Luhn {
+			value = withLuhnCheckDigit(value)
+		}
+		return value
+	}
+	if loc.localizes(col) {
+		return loc.name(faker)
+	}
+	return generateColumnValue(col, faker)
+}
+
+// uniqueColumnValue generates a value for col, regenerating values already used
+// when the column is unique, or unique per parent among the records of tableData's
+// parent. Nil values are never considered duplicates.
+func uniqueColumnValue(tableName string, col types.Column, tableData map[string]interface{}, keys foreignKeys, uniqueValues map[string]map[string]bool, faker *gofakeit.Faker, loc *locale) (interface{}, error) {
+	seen, scope := uniqueSet(tableName, col, tableData, uniqueValues)
+	if seen == nil {
+		return columnValue(col, keys, faker, loc), nil
+	}
+	value, err := uniqueCandidate(tableName, col, seen, scope, keys, faker, loc)
+	if value != nil {
+		seen[fmt.Sprint(value)] = true
+	}
+	return value, err
+}
+
+// uniqueSet returns the values col has used so far, across the table or among the
+// records of tableData's parent for unique_per_parent, and the parent scope named in
+// errors. It returns nil when col is not unique.
+func uniqueSet(tableName string, col types.Column, tableData map[string]interface{}, uniqueValues map[string]map[string]bool) (map[string]bool, string) {
+	if !col.Validation.Unique && col.Validation.UniquePerParent == "" {
+		return nil, ""
+	}
+	keyName := fmt.Sprintf("%s.%s", tableName, col.Name)
+	scope := ""
+	if parent := col.Validation.UniquePerParent; parent != "" && !col.Validation.Unique {
+		// Each parent's children keep their own set of used values
+		scope = fmt.Sprintf(" for %s %v", parent, tableData[parent])
+		keyName += scope
+	}
+	if uniqueValues[keyName] == nil {
+		uniqueValues[keyName] = make(map[string]bool)
+	}
+	return uniqueValues[keyName], scope
+}
+
+// uniqueCandidate generates a value for col that is not in seen, without recording it
+func uniqueCandidate(tableName string, col types.Column, seen map[string]bool, scope string, keys foreignKeys, faker *gofakeit.Faker, loc *locale) (interface{}, error) {
+	for attempt := 0; attempt < maxUniqueAttempts; attempt++ {
+		value := columnValue(col, keys, faker, loc)
+		if value == nil || !seen[fmt.Sprint(value)] {
+			return value, nil
+		}
+	}
+	return nil, fmt.Errorf("no unique value for table %s column %s%s after %d attempts, %d values already used",
+		tableName, col.Name, scope, maxUniqueAttempts, len(seen))
+}
+
+// ensureUniqueTuple regenerates the given columns of tableData while their combined
+// values repeat an earlier record's. Columns in unset, whose when condition was
+// false or that were drawn as null, stay empty. Candidates of columns that are
+// unique on their own are checked against their used values, and only the
+// accepted ones replace the values the row recorded.
+func ensureUniqueTuple(table types.Table, columns []string, unset map[string]bool, tableData map[string]interface{}, keys foreignKeys, uniqueValues, uniqueTuples map[string]map[string]bool, faker *gofakeit.Faker, loc *locale) error {
+	constraint := fmt.Sprintf("%s(%s)", table.Name, strings.Join(columns, ","))
+	if uniqueTuples[constraint] == nil {
+		uniqueTuples[constraint] = make(map[string]bool)
+	}
+	seen := uniqueTuples[constraint]
+
+	// Values recorded by the row's unique columns before they were first regenerated
+	type recordedValue struct {
+		seen  map[string]bool
+		value interface{}
+	}
+	recorded := make(map[string]recordedValue)
+	for attempt := 0; attempt < maxUniqueAttempts; attempt++ {
+		values := make([]string, len(columns))
+		for i, name := range columns {
+			values[i] = fmt.Sprint(tableData[name])
+		}
+		key := strings.Join(values, "\x00")
+		if !seen[key] {
+			seen[key] = true
 			for _, col := range table.Columns {
-				if col.Parent {
-					keyName := fmt.Sprintf("%s.%s", table.Name, col.Name)
-					parentKeyValues[keyName] = append(parentKeyValues[keyName], fmt.Sprint(tableData[col.Name]))
+				original, regenerated := recorded[col.Name]
+				if !regenerated {
+					continue
+				}
+				if original.value != nil {
+					delete(original.seen, fmt.Sprint(original.value))
+				}
+				if value := tableData[col.Name]; value != nil {
+					used, _ := uniqueSet(table.Name, col, tableData, uniqueValues)
+					used[fmt.Sprint(value)] = true
 				}
 			}
-			ds.InsertRecord(table.Name, tableData)
+			return nil
 		}
+
+		for _, col := range table.Columns {
+			if !containsString(columns, col.Name) || unset[col.Name] {
+				continue
+			}
+			used, scope := uniqueSet(table.Name, col, tableData, uniqueValues)
+			if used == nil {
+				setColumnValue(tableData, col, columnValue(col, keys, faker, loc))
+				continue
+			}
+			if _, ok := recorded[col.Name]; !ok {
+				recorded[col.Name] = recordedValue{seen: used, value: tableData[col.Name]}
+			}
+			colValue, err := uniqueCandidate(table.Name, col, used, scope, keys, faker, loc)
+			if err != nil {
+				return err
+			}
+			setColumnValue(tableData, col, colValue)
+		}
+	}
+	return fmt.Errorf("no unique combination for table %s columns %s after %d attempts, %d combinations already used",
+		table.Name, strings.Join(columns, ", "), maxUniqueAttempts, len(seen))
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// setColumnValue stores a generated value, falling back to the column default when it is nil.
+// Remaining nil values are only kept for mandatory columns.
+func setColumnValue(tableData map[string]interface{}, col types.Column, value interface{}) {
+	if value == nil && col.Default != "" {
+		// Defaults are checked when the manifest is loaded
+		value, _ = literalValue(col, col.Default)
+	}
+	if value != nil || col.Mandatory {
+		tableData[col.Name] = value
+	}
+}
+
+// literalValue converts a literal from the manifest to the column's type
+func literalValue(col types.Column, literal string) (interface{}, error) {
+	switch col.Type {
+	case "int":
+		return strconv.Atoi(literal)
+	case "bigint", "long":
+		return strconv.ParseInt(literal, 10, 64)
+	case "float", "decimal":
+		return strconv.ParseFloat(literal, 64)
+	case "bool":
+		return strconv.ParseBool(literal)
+	default:
+		return literal, nil
 	}
-	log.Printf("%d records inserted", count)
 }
 
 // generateColumnValue generates a value for a column based on its configuration
-func generateColumnValue(col types.Column) interface{} {
-	if generator := NewValueGenerator(col); generator != nil {
+func generateColumnValue(col types.Column, faker *gofakeit.Faker) interface{} {
+	if generator := NewValueGenerator(col, faker); generator != nil {
 		return generator.Generate()
 	}
 
 	// Special cases that aren't covered by generators
 	switch col.Type {
-	case "sentence":
-		return gofakeit.Sentence(5)
 	case "bool":
-		return gofakeit.Bool()
+		return faker.Bool()
 	case "uuid":
-		return gofakeit.UUID()
+		if col.Version == 7 {
+			// Version 7 UUIDs start with the time and increase within a run,
+			// their random bits come from the run's faker
+			return uuid.Must(uuid.NewV7FromReader(fakerReader{faker})).String()
+		}
+		return faker.UUID()
+	case "ulid":
+		return ulids.next(faker)
+	case "phone":
+		return phoneNumber(faker, col.Format)
+	case "ssn":
+		return ssn(faker)
+	case "ein":
+		return ein(faker)
+	case "creditcard":
+		return creditCardNumber(faker, col.Network)
+	case "card_expiry":
+		return cardExpiry(faker, col.Format)
+	case "card_cvv":
+		return cardCVV(faker, col.Network)
+	case "template":
+		return expandTemplate(faker, col.Template)
 	default:
 		// Should never reach here as the default generator handles this
-		return gofakeit.Word()
+		return faker.Word()
+	}
+}
+
+// fakerReader reads random bytes from a faker, so values built from an io.Reader
+// follow the run's faker and its seed
+type fakerReader struct {
+	faker *gofakeit.Faker
+}
+
+func (r fakerReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = r.faker.Uint8()
 	}
+	return len(p), nil
 }
 
-func readManifest(filename string) types.Tables {
-	file, err := os.Open(filename)
+func readManifest(r io.Reader, dir string) (types.Tables, error) {
+	tables, err := decodeManifest(r, dir)
 	if err != nil {
-		log.Fatalf("error reading file %v ", err.Error())
+		return types.Tables{}, err
+	}
+	if err := validateSchema(&tables, manifestChecks...); err != nil {
+		return types.Tables{}, err
 	}
+	return tables, nil
+}
 
+// decodeManifest parses the manifest read from r, resolves its enums and reads
+// its values files relative to dir, without validating it
+func decodeManifest(r io.Reader, dir string) (types.Tables, error) {
 	var tables types.Tables
-	err = yaml.NewDecoder(file).Decode(&tables)
-	if err != nil {
-		log.Fatalf("error reading file %v ", err.Error())
+	if err := yaml.NewDecoder(r).Decode(&tables); err != nil {
+		return types.Tables{}, fmt.Errorf("error reading file %v", err)
+	}
+	if err := resolveEnums(&tables); err != nil {
+		return types.Tables{}, err
+	}
+	if err := loadValuesFiles(&tables, dir); err != nil {
+		return types.Tables{}, err
 	}
-	return tables
+	return tables, nil
 }
 
-func replaceWithNumbers(str string) string {
-	if str == "" {
+// replaceWithNumbers replaces every # in str with a random digit, never starting the value with a generated zero
+func replaceWithNumbers(faker *gofakeit.Faker, str string) string {
+	return fillPattern(faker, str, false)
+}
+
+// fillPattern replaces every # in pattern with a random digit. Unless allowLeadingZero
+// is set, a leading # never becomes 0; literal characters are always kept.
+func fillPattern(faker *gofakeit.Faker, pattern string, allowLeadingZero bool) string {
+	if pattern == "" {
 		return ""
 	}
-	bytestr := []byte(str)
+	bytestr := []byte(pattern)
 	for i := 0; i < len(bytestr); i++ {
 		if bytestr[i] == hashtag {
-			bytestr[i] = byte(randDigit())
+			bytestr[i] = byte(randDigit(faker))
 		}
 	}
-	if bytestr[0] == '0' {
-		bytestr[0] = byte(gofakeit.IntN(8)+1) + '0'
-	}
-	// Special handling for TEST pattern
-	if strings.HasPrefix(str, "TEST") {
-		// Ensure we have exactly 4 digits after TEST
-		if len(bytestr) > 4 {
-			for i := 4; i < len(bytestr); i++ {
-				bytestr[i] = byte(randDigit())
-			}
-		}
+	if !allowLeadingZero && pattern[0] == hashtag && bytestr[0] == '0' {
+		bytestr[0] = byte(faker.IntN(9)+1) + '0'
 	}
 	return string(bytestr)
 }
 
-func randDigit() rune {
-	return rune(byte(gofakeit.IntN(10)) + '0')
+func randDigit(faker *gofakeit.Faker) rune {
+	return rune(byte(faker.IntN(10)) + '0')
 }
 
-// sortTablesByDependency sorts tables based on their dependencies and priorities
+// sortTablesByDependency orders tables so that each comes after the table it depends on.
+// Priority only orders the tables whose dependency is already placed: higher priorities
+// go first and equal priorities keep their manifest order, so a dependency always wins
+// over a conflicting priority. Tables caught in a dependency cycle follow by priority.
 func sortTablesByDependency(tables []types.Table) []types.Table {
-	// Create dependency graph
-	graph := make(map[string][]string)
-	for _, table := range tables {
-		if table.DependsOn != "" {
-			graph[table.DependsOn] = append(graph[table.DependsOn], table.Name)
-		}
-	}
-
-	// Create priority map
-	priorities := make(map[string]int)
+	names := make(map[string]bool, len(tables))
 	for _, table := range tables {
-		priorities[table.Name] = table.Priority
+		names[table.Name] = true
 	}
 
-	// Sort based on both dependencies and priorities
-	sorted := make([]types.Table, len(tables))
-	copy(sorted, tables)
-
-	sort.SliceStable(sorted, func(i, j int) bool {
-		// First check dependencies
-		if sorted[i].DependsOn == sorted[j].Name {
-			return false
-		}
-		if sorted[j].DependsOn == sorted[i].Name {
-			return true
+	placed := make([]bool, len(tables))
+	placedNames := make(map[string]bool, len(tables))
+	sorted := make([]types.Table, 0, len(tables))
+	for len(sorted) < len(tables) {
+		next, nextReady := -1, false
+		for i, table := range tables {
+			if placed[i] {
+				continue
+			}
+			// Unknown dependencies are reported by validation and do not hold a table back
+			ready := table.DependsOn == "" || !names[table.DependsOn] || placedNames[table.DependsOn]
+			if next < 0 || (ready && !nextReady) || (ready == nextReady && table.Priority > tables[next].Priority) {
+				next, nextReady = i, ready
+			}
 		}
-
-		// Then check priorities
-		return sorted[i].Priority > sorted[j].Priority
-	})
-
+		placed[next] = true
+		placedNames[tables[next].Name] = true
+		sorted = append(sorted, tables[next])
+	}
 	return sorted
 }
 
 // evaluateExpression evaluates an expression against field values using expr library
-func evaluateExpression(expression string, fields map[string]interface{}) (bool, error) {
+func evaluateExpression(expression string, fields map[string]interface{}, parents map[string]interface{}) (bool, error) {
 	// Add helper functions to the environment
-	env := initEnv(fields)
+	env := initEnv(fields, parents)
 
 	// Create options for the expression
 	options := []expr.Option{
@@ -314,14 +994,12 @@ func evaluateExpression(expression string, fields map[string]interface{}) (bool,
 	// Compile the expression
 	program, err := expr.Compile(expression, options...)
 	if err != nil {
-		log.Printf("Error compiling expression: %v", err)
 		return false, err
 	}
 
 	// Run the expression
 	output, err := expr.Run(program, env)
 	if err != nil {
-		log.Printf("Error running expression: %v", err)
 		return false, err
 	}
 
@@ -333,9 +1011,12 @@ func evaluateExpression(expression string, fields map[string]interface{}) (bool,
 	return false, fmt.Errorf("expression did not evaluate to a boolean")
 }
 
-func initEnv(fields map[string]interface{}) map[string]interface{} {
+// initEnv builds the environment expressions run in, the record's fields and the
+// parent records referenced by its foreign keys, keyed by parent table
+func initEnv(fields map[string]interface{}, parents map[string]interface{}) map[string]interface{} {
 	return map[string]interface{}{
 		"fields": fields,
+		"parent": parents,
 		"contains": func(s, substr string) bool {
 			return strings.Contains(s, substr)
 		},
@@ -366,15 +1047,16 @@ func initEnv(fields map[string]interface{}) map[string]interface{} {
 	}
 }
 
-// parseValue converts string value to appropriate type using expr
-func parseValue(value string, fields map[string]interface{}) interface{} {
+// parseValue converts string value to appropriate type using expr, a ${...}
+// expression that fails is returned unchanged together with its error
+func parseValue(value string, fields map[string]interface{}, parents map[string]interface{}) (interface{}, error) {
 	// If the value contains an expression (indicated by ${...})
 	if strings.Contains(value, "${") && strings.Contains(value, "}") {
 		// Extract the expression
 		expression := strings.TrimPrefix(strings.TrimSuffix(value, "}"), "${")
 
 		// Add helper functions to the environment
-		env := initEnv(fields)
+		env := initEnv(fields, parents)
 
 		// Create options for the expression
 		options := []expr.Option{
@@ -385,17 +1067,15 @@ func parseValue(value string, fields map[string]interface{}) interface{} {
 		// Compile and run the expression
 		program, err := expr.Compile(expression, options...)
 		if err != nil {
-			log.Printf("Error compiling value expression: %v", err)
-			return value
+			return value, err
 		}
 
 		output, err := expr.Run(program, env)
 		if err != nil {
-			log.Printf("Error running value expression: %v", err)
-			return value
+			return value, err
 		}
 
-		return output
+		return output, nil
 	}
 
 	// Handle simple time arithmetic expressions like "fieldname + 1h"
@@ -407,7 +1087,7 @@ func parseValue(value string, fields map[string]interface{}) interface{} {
 				if baseTime, ok := baseValue.(time.Time); ok {
 					duration := strings.TrimSpace(parts[1])
 					if parsedDuration, err := time.ParseDuration(duration); err == nil {
-						return baseTime.Add(parsedDuration)
+						return baseTime.Add(parsedDuration), nil
 					}
 				}
 			}
@@ -421,45 +1101,75 @@ func parseValue(value string, fields map[string]interface{}) interface{} {
 	}
 	for _, layout := range layouts {
 		if t, err := time.Parse(layout, value); err == nil {
-			return t
+			return t, nil
 		}
 	}
 
 	// Try to parse as int
 	if i, err := strconv.Atoi(value); err == nil {
-		return i
+		return i, nil
 	}
 	// Try to parse as float
 	if f, err := strconv.ParseFloat(value, 64); err == nil {
-		return f
+		return f, nil
 	}
 	// Try to parse as bool
 	if b, err := strconv.ParseBool(value); err == nil {
-		return b
+		return b, nil
 	}
 	// Return as string if no other type matches
-	return value
+	return value, nil
 }
 
-// applyRules applies the rules to the generated data
-func applyRules(rules []types.Rule, fields map[string]interface{}) {
+// applyRules applies the rules to the generated data, parents holds the parent
+// records rules may read as parent.<table>. Failing expressions are logged and
+// skipped, or returned when strict.
+func applyRules(rules []types.Rule, fields map[string]interface{}, parents map[string]interface{}, strict bool) error {
 	for _, rule := range rules {
-		result, err := evaluateExpression(rule.When, fields)
+		values, err := ruleBranch(rule, fields, parents)
 		if err != nil {
-			log.Printf("Error evaluating rule condition: %v", err)
+			if err := expressionFailure(strict, fmt.Errorf("error evaluating rule condition: %v", err)); err != nil {
+				return err
+			}
 			continue
 		}
+		for field, value := range values {
+			parsed, err := parseValue(value, fields, parents)
+			if err != nil {
+				if err := expressionFailure(strict, fmt.Errorf("error evaluating rule value for %s: %v", field, err)); err != nil {
+					return err
+				}
+			}
+			fields[field] = parsed
+		}
+	}
+	return nil
+}
 
+// expressionFailure returns err in strict runs, other runs log it and carry on
+func expressionFailure(strict bool, err error) error {
+	if strict {
+		return err
+	}
+	log.Print(err)
+	return nil
+}
+
+// ruleBranch returns the values of the first branch whose condition holds, the
+// rule's own when, then its cases in order, or otherwise when none does
+func ruleBranch(rule types.Rule, fields map[string]interface{}, parents map[string]interface{}) (map[string]string, error) {
+	branches := rule.Cases
+	if rule.When != "" || len(rule.Cases) == 0 {
+		branches = append([]types.RuleCase{{When: rule.When, Then: rule.Then}}, rule.Cases...)
+	}
+	for _, branch := range branches {
+		result, err := evaluateExpression(branch.When, fields, parents)
+		if err != nil {
+			return nil, err
+		}
 		if result {
-			// Apply 'then' values
-			for field, value := range rule.Then {
-				fields[field] = parseValue(value, fields)
-			}
-		} else if rule.Otherwise != nil {
-			// Apply 'otherwise' values
-			for field, value := range rule.Otherwise {
-				fields[field] = parseValue(value, fields)
-			}
+			return branch.Then, nil
 		}
 	}
+	return rule.Otherwise, nil
 }
@@ -1,17 +1,27 @@
 package pkg
 
 import (
+	"context"
+	"fmt"
+	"math"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/sink"
 	"github.com/sujanks/data-gen-app/pkg/types"
 )
 
 // MockDataSink is a mock implementation of the DataSink interface
 type MockDataSink struct {
 	Records []map[string]interface{}
+	Closed  bool
 }
 
 func (m *MockDataSink) InsertRecord(tableName string, data map[string]interface{}) error {
@@ -19,21 +29,100 @@ func (m *MockDataSink) InsertRecord(tableName string, data map[string]interface{
 	return nil
 }
 
+func (m *MockDataSink) Flush() error {
+	return nil
+}
+
+func (m *MockDataSink) Close() error {
+	m.Closed = true
+	return nil
+}
+
 // Initialize pattern handling for tests
 func init() {
 	// Since the pattern handling is in the pkg package and not in types package,
 	// we need to tell the test to handle the patterns correctly
-	types.RegisterStringPatternHandler(func(pattern string) string {
-		return replaceWithNumbers(pattern)
+	types.RegisterStringPatternHandler(func(f *gofakeit.Faker, pattern string) string {
+		return replaceWithNumbers(f, pattern)
 	})
 }
 
 func TestCSVSink(t *testing.T) {
-	os.Setenv("SINK", "csv")
-	os.Setenv("PROFILE", "test")
-	os.Setenv("RECORDS", "10")
 	manifestPath := "../manifest/test.yaml"
-	GenerateData(nil, 1, manifestPath)
+	schema, err := LoadSchema(manifestPath)
+	assert.NoError(t, err)
+
+	tempDir := t.TempDir()
+	csvSink, err := sink.NewCSVSink(tempDir, schema)
+	assert.NoError(t, err)
+
+	// GenerateData must close the sink itself so buffered rows reach the file
+	err = GenerateData(csvSink, 3, manifestPath)
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(tempDir, "application.csv"))
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.True(t, strings.HasPrefix(lines[0], "application_id,metadata,"))
+	assert.Regexp(t, "^ABC[0-9]{5},", lines[1])
+}
+
+func TestCSVSinkZeroRecords(t *testing.T) {
+	manifestPath := "../manifest/test.yaml"
+	schema, err := LoadSchema(manifestPath)
+	assert.NoError(t, err)
+
+	tempDir := t.TempDir()
+	csvSink, err := sink.NewCSVSink(tempDir, schema)
+	assert.NoError(t, err)
+
+	err = GenerateData(csvSink, 0, manifestPath)
+	assert.NoError(t, err)
+
+	for _, table := range schema.Tables {
+		content, err := os.ReadFile(filepath.Join(tempDir, table.Name+".csv"))
+		assert.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		assert.Len(t, lines, 1, "table %s should only have a header", table.Name)
+		assert.True(t, strings.HasPrefix(lines[0], table.Columns[0].Name))
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: customers
+  columns:
+  - name: id
+    pattern: "C####"
+    parent: true
+- name: orders
+  depends_on: customers
+  count: 12
+  columns:
+  - name: customer_id
+    foreign: "customers.id"
+`)
+
+	rows, err := Generate(manifestPath, 5)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Len(t, rows["customers"], 5)
+	assert.Len(t, rows["orders"], 12)
+	assert.Regexp(t, "^C[0-9]{4}$", rows["customers"][0]["id"])
+
+	_, err = Generate(filepath.Join(t.TempDir(), "missing.yaml"), 5)
+	assert.Error(t, err)
+}
+
+func TestGenerateDataClosesSink(t *testing.T) {
+	mockSink := &MockDataSink{
+		Records: make([]map[string]interface{}, 0),
+	}
+
+	err := GenerateData(mockSink, 1, "../manifest/test.yaml")
+	assert.NoError(t, err)
+	assert.True(t, mockSink.Closed)
 }
 
 func TestGenerateData(t *testing.T) {
@@ -147,6 +236,27 @@ func TestGenerateColumnValue(t *testing.T) {
 				assert.Regexp(t, "^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$", value)
 			},
 		},
+		{
+			name: "Generate UUID v7",
+			column: types.Column{
+				Name:    "id",
+				Type:    "uuid",
+				Version: 7,
+			},
+			wantType: "",
+			validate: func(t *testing.T, value interface{}) {
+				assert.Regexp(t, "^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$", value)
+			},
+		},
+		{
+			name: "Generate Bool",
+			column: types.Column{
+				Name: "is_active",
+				Type: "bool",
+			},
+			wantType: false,
+			validate: func(t *testing.T, value interface{}) {},
+		},
 		{
 			name: "Generate Int with Range",
 			column: types.Column{
@@ -206,6 +316,40 @@ func TestGenerateColumnValue(t *testing.T) {
 				assert.True(t, v.Before(maxTime) || v.Equal(maxTime))
 			},
 		},
+		{
+			name: "Generate Unix Timestamp",
+			column: types.Column{
+				Name:   "created_at",
+				Type:   "timestamp",
+				Format: "unix",
+				Range: types.Range{
+					Min: "2025-03-07 12:00:00",
+					Max: "2025-03-07 12:00:00",
+				},
+			},
+			wantType: int64(0),
+			validate: func(t *testing.T, value interface{}) {
+				expected := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+				assert.Equal(t, expected.Unix(), value)
+			},
+		},
+		{
+			name: "Generate Unix Millisecond Timestamp",
+			column: types.Column{
+				Name:   "created_at",
+				Type:   "timestamp",
+				Format: "unix_ms",
+				Range: types.Range{
+					Min: "2025-03-07 12:00:00",
+					Max: "2025-03-07 12:00:00",
+				},
+			},
+			wantType: int64(0),
+			validate: func(t *testing.T, value interface{}) {
+				expected := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+				assert.Equal(t, expected.UnixMilli(), value)
+			},
+		},
 		{
 			name: "Generate JSON",
 			column: types.Column{
@@ -229,13 +373,254 @@ func TestGenerateColumnValue(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			value := generateColumnValue(tt.column)
+			value := generateColumnValue(tt.column, gofakeit.GlobalFaker)
 			assert.IsType(t, tt.wantType, value)
 			tt.validate(t, value)
 		})
 	}
 }
 
+func TestBigIntColumns(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: events
+  columns:
+  - name: id
+    type: bigint
+    range:
+      min: 5000000000
+      max: 9000000000000000000
+  - name: offset
+    type: long
+    range:
+      min: -3000000000
+      max: -2147483649
+  - name: sequence
+    type: bigint
+    const: "9007199254740993"
+  - name: total
+    type: bigint
+    counter:
+      start: 4294967296
+      delta: step
+  - name: step
+    type: int
+    range:
+      min: 1
+      max: 10
+`)
+
+	records, err := Generate(manifestPath, 50)
+	if !assert.NoError(t, err) {
+		return
+	}
+	total := int64(4294967296)
+	for _, event := range records["events"] {
+		id, ok := event["id"].(int64)
+		if assert.True(t, ok, "id should be int64, got %T", event["id"]) {
+			assert.GreaterOrEqual(t, id, int64(5000000000))
+			assert.LessOrEqual(t, id, int64(9000000000000000000))
+		}
+		offset, ok := event["offset"].(int64)
+		if assert.True(t, ok, "offset should be int64, got %T", event["offset"]) {
+			assert.GreaterOrEqual(t, offset, int64(-3000000000))
+			assert.LessOrEqual(t, offset, int64(-2147483649))
+		}
+		// 2^53 + 1 is not representable as a float64 and must survive exactly
+		assert.Equal(t, int64(9007199254740993), event["sequence"])
+
+		total += int64(event["step"].(int))
+		assert.Equal(t, total, event["total"])
+	}
+
+	// The CSV sink writes the values in full
+	tempDir := t.TempDir()
+	schema, err := LoadSchema(manifestPath)
+	assert.NoError(t, err)
+	csvSink, err := sink.NewCSVSink(tempDir, schema)
+	assert.NoError(t, err)
+	assert.NoError(t, csvSink.InsertRecord("events", records["events"][0]))
+	assert.NoError(t, csvSink.Close())
+	content, err := os.ReadFile(filepath.Join(tempDir, "events.csv"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), fmt.Sprintf("%d,%d,9007199254740993,", records["events"][0]["id"], records["events"][0]["offset"]))
+}
+
+func TestExclusiveRanges(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: readings
+  columns:
+  - name: middle
+    type: int
+    range:
+      min: 1
+      max: 3
+      exclusive_min: true
+      exclusive_max: true
+  - name: below_five
+    type: int
+    range:
+      min: 0
+      max: 5
+      exclusive_max: true
+  - name: above_minus_one
+    type: bigint
+    range:
+      min: -1
+      max: 1
+      exclusive_min: true
+  - name: ratio
+    type: float
+    range:
+      min: 0.0
+      max: 1.0
+      exclusive_min: true
+      exclusive_max: true
+`)
+
+	records, err := Generate(manifestPath, 1000)
+	if !assert.NoError(t, err) {
+		return
+	}
+	seen := make(map[interface{}]bool)
+	for _, reading := range records["readings"] {
+		assert.Equal(t, 2, reading["middle"])
+		assert.Contains(t, []int{0, 1, 2, 3, 4}, reading["below_five"])
+		seen[reading["below_five"]] = true
+		assert.Contains(t, []int64{0, 1}, reading["above_minus_one"])
+		ratio := reading["ratio"].(float64)
+		assert.Greater(t, ratio, 0.0)
+		assert.Less(t, ratio, 1.0)
+	}
+	assert.Len(t, seen, 5, "every value inside the bounds is generated")
+
+	// Between two floats one representable value apart only that value remains
+	low := 1.0
+	high := math.Nextafter(math.Nextafter(low, 2), 2)
+	generator := &types.NumericGenerator{
+		Config:  types.Range{Min: low, Max: high, ExclusiveMin: true, ExclusiveMax: true},
+		IsFloat: true,
+	}
+	for i := 0; i < 1000; i++ {
+		assert.Equal(t, math.Nextafter(low, 2), generator.Generate())
+	}
+}
+
+func TestRangeStep(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: products
+  columns:
+  - name: price
+    type: int
+    range:
+      min: 3
+      max: 98
+      step: 5
+  - name: even
+    type: int
+    range:
+      min: -10
+      max: 10
+      step: 2
+      exclusive_min: true
+  - name: stock
+    type: bigint
+    range:
+      min: -5500
+      max: 5500
+      step: 1000
+  - name: weight
+    type: float
+    range:
+      min: 0.3
+      max: 1.2
+      step: 0.1
+`)
+
+	records, err := Generate(manifestPath, 1000)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, product := range records["products"] {
+		price := product["price"].(int)
+		assert.Zero(t, price%5, "price %d", price)
+		assert.GreaterOrEqual(t, price, 5)
+		assert.LessOrEqual(t, price, 95)
+
+		even := product["even"].(int)
+		assert.Zero(t, even%2, "even %d", even)
+		assert.Greater(t, even, -10)
+
+		stock := product["stock"].(int64)
+		assert.Zero(t, stock%1000, "stock %d", stock)
+		assert.LessOrEqual(t, stock, int64(5000))
+
+		weight := product["weight"].(float64)
+		tenths := weight * 10
+		assert.InDelta(t, math.Round(tenths), tenths, 1e-9, "weight %v", weight)
+		assert.GreaterOrEqual(t, weight, 0.3)
+		assert.LessOrEqual(t, weight, 1.2)
+	}
+}
+
+func TestTextGenerator(t *testing.T) {
+	t.Run("Sentence with word count", func(t *testing.T) {
+		value := generateColumnValue(types.Column{Name: "title", Type: "sentence", Words: 8}, gofakeit.GlobalFaker)
+		sentence, ok := value.(string)
+		assert.True(t, ok)
+		assert.True(t, strings.HasSuffix(sentence, "."))
+		assert.InDelta(t, 8, len(strings.Fields(sentence)), 2)
+	})
+
+	t.Run("Default sentence", func(t *testing.T) {
+		value := generateColumnValue(types.Column{Name: "title", Type: "sentence"}, gofakeit.GlobalFaker)
+		assert.InDelta(t, 5, len(strings.Fields(value.(string))), 2)
+	})
+
+	t.Run("Paragraphs with sentence count", func(t *testing.T) {
+		value := generateColumnValue(types.Column{
+			Name:       "description",
+			Type:       "paragraph",
+			Words:      4,
+			Sentences:  3,
+			Paragraphs: 2,
+		}, gofakeit.GlobalFaker)
+		text, ok := value.(string)
+		assert.True(t, ok)
+
+		paragraphs := strings.Split(text, "\n")
+		assert.Len(t, paragraphs, 2)
+		for _, paragraph := range paragraphs {
+			// Some faker words contain periods themselves, so allow a little slack
+			assert.InDelta(t, 3, strings.Count(paragraph, "."), 2)
+			assert.InDelta(t, 12, len(strings.Fields(paragraph)), 3)
+		}
+	})
+}
+
+func TestParseTimeRange(t *testing.T) {
+	format := "2006-01-02 15:04:05"
+
+	t.Run("Equal bounds", func(t *testing.T) {
+		minTime, maxTime, err := parseTimeRange(format, "2025-03-07 12:00:00", "2025-03-07 12:00:00")
+		assert.NoError(t, err)
+		assert.True(t, minTime.Equal(maxTime))
+
+		// A zero-width range must always produce that exact instant
+		for i := 0; i < 100; i++ {
+			assert.Equal(t, minTime, randomTimeInRange(gofakeit.GlobalFaker, minTime, maxTime))
+		}
+	})
+
+	t.Run("Inverted bounds", func(t *testing.T) {
+		_, _, err := parseTimeRange(format, "2025-03-08 12:00:00", "2025-03-07 12:00:00")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "is after max")
+	})
+}
+
 func TestSortTablesByDependency(t *testing.T) {
 	tables := []types.Table{
 		{
@@ -264,6 +649,37 @@ func TestSortTablesByDependency(t *testing.T) {
 	assert.Equal(t, "table3", sortedTables[2].Name)
 }
 
+func TestSortTablesByDependencyPriorityConflict(t *testing.T) {
+	// Each table has a higher priority than the table it depends on
+	tables := []types.Table{
+		{Name: "order_items", Priority: 3, DependsOn: "orders"},
+		{Name: "orders", Priority: 2, DependsOn: "customers"},
+		{Name: "audit", Priority: 5},
+		{Name: "customers", Priority: 1},
+		{Name: "products", Priority: 1},
+	}
+
+	var names []string
+	for _, table := range sortTablesByDependency(tables) {
+		names = append(names, table.Name)
+	}
+	assert.Equal(t, []string{"audit", "customers", "orders", "order_items", "products"}, names)
+}
+
+func TestSortTablesByDependencyCycle(t *testing.T) {
+	tables := []types.Table{
+		{Name: "a", DependsOn: "b"},
+		{Name: "b", Priority: 1, DependsOn: "a"},
+		{Name: "c", DependsOn: "missing"},
+	}
+
+	var names []string
+	for _, table := range sortTablesByDependency(tables) {
+		names = append(names, table.Name)
+	}
+	assert.Equal(t, []string{"c", "b", "a"}, names)
+}
+
 func TestReplaceWithNumbers(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -298,16 +714,416 @@ func TestReplaceWithNumbers(t *testing.T) {
 				assert.Regexp(t, "^TEST[0-9]{4}$", result)
 			},
 		},
+		{
+			name:    "TEST prefix without hashtags",
+			pattern: "TESTABC",
+			validate: func(t *testing.T, result string) {
+				assert.Equal(t, "TESTABC", result)
+			},
+		},
+		{
+			name:    "TEST prefix keeps literal digits",
+			pattern: "TEST12##",
+			validate: func(t *testing.T, result string) {
+				assert.Regexp(t, "^TEST12[0-9]{2}$", result)
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := replaceWithNumbers(tt.pattern)
+			result := replaceWithNumbers(gofakeit.GlobalFaker, tt.pattern)
 			tt.validate(t, result)
 		})
 	}
 }
 
+func TestFillPatternLeadingZero(t *testing.T) {
+	t.Run("Generated leading zero suppressed by default", func(t *testing.T) {
+		for i := 0; i < 200; i++ {
+			assert.Regexp(t, "^[1-9][0-9]$", fillPattern(gofakeit.GlobalFaker, "##", false))
+		}
+	})
+
+	t.Run("Generated leading zero allowed when configured", func(t *testing.T) {
+		leadingZero := false
+		for i := 0; i < 500 && !leadingZero; i++ {
+			result := fillPattern(gofakeit.GlobalFaker, "##", true)
+			assert.Regexp(t, "^[0-9]{2}$", result)
+			leadingZero = result[0] == '0'
+		}
+		assert.True(t, leadingZero, "expected some values to start with 0")
+	})
+
+	t.Run("Literal leading zero is kept", func(t *testing.T) {
+		for _, allow := range []bool{false, true} {
+			assert.Regexp(t, "^0[0-9]{4}$", fillPattern(gofakeit.GlobalFaker, "0####", allow))
+		}
+	})
+
+	t.Run("Column option", func(t *testing.T) {
+		tables := []types.Table{{
+			Name:    "addresses",
+			Columns: []types.Column{{Name: "zip", Pattern: "#####", AllowLeadingZero: true}},
+		}}
+		leadingZero := false
+		err := generateRecords(types.Schema{Tables: tables}, 500, func(record Record) error {
+			zip := record.Data["zip"].(string)
+			assert.Regexp(t, "^[0-9]{5}$", zip)
+			leadingZero = leadingZero || zip[0] == '0'
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, leadingZero, "expected some zip codes to start with 0")
+	})
+}
+
+func TestGenerateObjects(t *testing.T) {
+	col := types.Column{
+		Name: "line_items",
+		Type: "objects",
+		ObjectsConfig: types.ObjectsConfig{
+			Min: 1,
+			Max: 4,
+			Fields: []types.Column{
+				{Name: "sku", Pattern: "SKU###"},
+				{Name: "quantity", Type: "int", Range: types.Range{Min: 1, Max: 10}},
+			},
+		},
+	}
+
+	for i := 0; i < 50; i++ {
+		items, ok := generateColumnValue(col, gofakeit.GlobalFaker).([]interface{})
+		assert.True(t, ok)
+		assert.GreaterOrEqual(t, len(items), 1)
+		assert.LessOrEqual(t, len(items), 4)
+
+		for _, item := range items {
+			object, ok := item.(map[string]interface{})
+			assert.True(t, ok)
+			assert.Len(t, object, 2)
+			assert.Regexp(t, `^SKU\d{3}$`, object["sku"])
+			assert.Contains(t, object, "quantity")
+		}
+	}
+}
+
+func TestCorrelatedChoices(t *testing.T) {
+	states := map[string]string{
+		"San Francisco": "California",
+		"Austin":        "Texas",
+		"Seattle":       "Washington",
+	}
+	tables := []types.Table{{
+		Name: "addresses",
+		Columns: []types.Column{
+			{Name: "id", Pattern: "A####"},
+			{Name: "city"},
+			{Name: "state"},
+		},
+		Choices: []map[string]string{
+			{"city": "San Francisco", "state": "California"},
+			{"city": "Austin", "state": "Texas"},
+			{"city": "Seattle", "state": "Washington"},
+		},
+	}}
+
+	cities := make(map[string]bool)
+	err := generateRecords(types.Schema{Tables: tables}, 300, func(record Record) error {
+		city, _ := record.Data["city"].(string)
+		assert.Contains(t, states, city)
+		assert.Equal(t, states[city], record.Data["state"], "city %s paired with wrong state", city)
+		cities[city] = true
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, cities, 3)
+}
+
+func TestConditionalColumn(t *testing.T) {
+	tables := []types.Table{{
+		Name: "orders",
+		Columns: []types.Column{
+			// Declared before status to show conditions see every unconditional column
+			{Name: "cancellation_reason", Value: []string{"out of stock", "customer request"}, When: `fields.status == "CANCELLED"`},
+			{Name: "id", Pattern: "O####"},
+			{Name: "status", Value: []string{"NEW", "CANCELLED"}},
+		},
+	}}
+
+	statuses := make(map[interface{}]int)
+	err := generateRecords(types.Schema{Tables: tables}, 200, func(record Record) error {
+		statuses[record.Data["status"]]++
+		if record.Data["status"] == "CANCELLED" {
+			assert.Contains(t, []string{"out of stock", "customer request"}, record.Data["cancellation_reason"])
+		} else {
+			assert.NotContains(t, record.Data, "cancellation_reason")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Greater(t, statuses["NEW"], 0)
+	assert.Greater(t, statuses["CANCELLED"], 0)
+}
+
+func TestColumnDefault(t *testing.T) {
+	tables := []types.Table{
+		{
+			// Generated first, before any customer exists
+			Name:     "orders",
+			Priority: 2,
+			Columns: []types.Column{
+				{Name: "customer_id", Foreign: "customers.id", Default: "GUEST"},
+				{Name: "quantity", Type: "int", When: "false", Default: "1"},
+			},
+		},
+		{
+			Name:     "customers",
+			Priority: 1,
+			Columns:  []types.Column{{Name: "id", Pattern: "C####", Parent: true}},
+		},
+	}
+
+	err := generateRecords(types.Schema{Tables: tables}, 5, func(record Record) error {
+		if record.Table == "orders" {
+			assert.Equal(t, "GUEST", record.Data["customer_id"])
+			assert.Equal(t, 1, record.Data["quantity"])
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+
+	// Optional foreign keys drawn as null stay null instead of taking the default
+	tables[0].Priority = 0
+	tables[0].DependsOn = "customers"
+	tables[0].Columns[0].NullProbability = 0.5
+	tables[0].Columns[0].Mandatory = true
+	nulls := 0
+	err = generateRecords(types.Schema{Tables: tables}, 200, func(record Record) error {
+		if record.Table == "orders" {
+			switch customerID := record.Data["customer_id"]; customerID {
+			case nil:
+				nulls++
+			default:
+				assert.Regexp(t, `^C\d{4}$`, customerID)
+			}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.InDelta(t, 100, nulls, 40)
+}
+
+func TestConstColumn(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: events
+  columns:
+  - name: id
+    pattern: "E####"
+  - name: source
+    const: generator
+  - name: tenant_id
+    type: int
+    const: 42
+  - name: sampled
+    type: bool
+    const: true
+`)
+
+	sink := &MockDataSink{}
+	assert.NoError(t, GenerateData(sink, 20, manifestPath))
+	assert.Len(t, sink.Records, 20)
+	for _, record := range sink.Records {
+		assert.Equal(t, "generator", record["source"])
+		assert.Equal(t, 42, record["tenant_id"])
+		assert.Equal(t, true, record["sampled"])
+	}
+}
+
+func TestUniqueColumns(t *testing.T) {
+	newTables := func(col types.Column) types.Schema {
+		col.Validation.Unique = true
+		return types.Schema{Tables: []types.Table{{Name: "users", Columns: []types.Column{col}}}}
+	}
+
+	tests := []struct {
+		name    string
+		column  types.Column
+		count   int
+		wantErr string
+	}{
+		{
+			name:   "Int range fully used",
+			column: types.Column{Name: "seat", Type: "int", Range: types.Range{Min: 1, Max: 5}},
+			count:  5,
+		},
+		{
+			name:    "Int range exhausted",
+			column:  types.Column{Name: "seat", Type: "int", Range: types.Range{Min: 1, Max: 5}},
+			count:   6,
+			wantErr: "no unique value for table users column seat after 1000 attempts, 5 values already used",
+		},
+		{
+			name:   "Values",
+			column: types.Column{Name: "code", Value: []string{"A", "B", "C"}},
+			count:  3,
+		},
+		{
+			name:   "UUIDs",
+			column: types.Column{Name: "id", Type: "uuid"},
+			count:  500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := make(map[interface{}]bool)
+			err := generateRecords(newTables(tt.column), tt.count, func(record Record) error {
+				value := record.Data[tt.column.Name]
+				assert.False(t, seen[value], "duplicate value %v", value)
+				seen[value] = true
+				return nil
+			})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, seen, tt.count)
+		})
+	}
+}
+
+func TestUniquePerParent(t *testing.T) {
+	schema := types.Schema{Tables: []types.Table{
+		{Name: "orders", Count: 5, Columns: []types.Column{{Name: "id", Pattern: "O####", Parent: true, Validation: types.Validation{Unique: true}}}},
+		{Name: "order_lines", DependsOn: "orders", Count: 200, Columns: []types.Column{
+			// Declared before the foreign key that scopes it
+			{Name: "line_number", Type: "int", Range: types.Range{Min: 1, Max: 100}, Validation: types.Validation{UniquePerParent: "order_id"}},
+			{Name: "order_id", Foreign: "orders.id"},
+		}},
+	}}
+
+	lines := make(map[string]map[interface{}]bool)  // Line numbers used per order
+	orders := make(map[interface{}]map[string]bool) // Orders using each line number
+	err := generateRecords(schema, 0, func(record Record) error {
+		if record.Table != "order_lines" {
+			return nil
+		}
+		order, line := record.Data["order_id"].(string), record.Data["line_number"]
+		if lines[order] == nil {
+			lines[order] = make(map[interface{}]bool)
+		}
+		assert.False(t, lines[order][line], "order %s repeats line number %v", order, line)
+		lines[order][line] = true
+		if orders[line] == nil {
+			orders[line] = make(map[string]bool)
+		}
+		orders[line][order] = true
+		return nil
+	})
+	assert.NoError(t, err)
+
+	reused := 0
+	for _, byOrder := range orders {
+		if len(byOrder) > 1 {
+			reused++
+		}
+	}
+	assert.Positive(t, reused, "no line number is used by more than one order")
+
+	// A parent whose children exhaust the values fails like a unique column
+	schema.Tables[0].Count = 1
+	schema.Tables[1].Count = 101
+	err = generateRecords(schema, 0, func(Record) error { return nil })
+	assert.ErrorContains(t, err, "no unique value for table order_lines column line_number for order_id O")
+}
+
+func TestUUIDv7Ordering(t *testing.T) {
+	col := types.Column{Name: "id", Type: "uuid", Version: 7}
+
+	var previous string
+	for i := 0; i < 1000; i++ {
+		id := generateColumnValue(col, gofakeit.GlobalFaker).(string)
+		assert.Greater(t, id, previous, "UUID %d does not sort after the one before it", i)
+		previous = id
+	}
+
+	// The random bits after the variant come from the faker, so seeded runs repeat them
+	first := generateColumnValue(col, gofakeit.New(3)).(string)
+	second := generateColumnValue(col, gofakeit.New(3)).(string)
+	assert.Equal(t, first[20:], second[20:])
+	assert.NotEqual(t, first[20:], generateColumnValue(col, gofakeit.New(4)).(string)[20:])
+}
+
+func TestCompositeUnique(t *testing.T) {
+	tables := []types.Table{{
+		Name: "visits",
+		Columns: []types.Column{
+			{Name: "user_id", Value: []string{"U1", "U2", "U3"}},
+			{Name: "date", Value: []string{"2024-01-01", "2024-01-02", "2024-01-03"}},
+		},
+		Unique: [][]string{{"user_id", "date"}},
+	}}
+
+	t.Run("No duplicate pairs", func(t *testing.T) {
+		pairs := make(map[string]bool)
+		err := generateRecords(types.Schema{Tables: tables}, 9, func(record Record) error {
+			pair := fmt.Sprintf("%v/%v", record.Data["user_id"], record.Data["date"])
+			assert.False(t, pairs[pair], "duplicate pair %s", pair)
+			pairs[pair] = true
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Len(t, pairs, 9)
+	})
+
+	t.Run("Combinations exhausted", func(t *testing.T) {
+		err := generateRecords(types.Schema{Tables: tables}, 10, func(Record) error { return nil })
+		assert.EqualError(t, err, "no unique combination for table visits columns user_id, date after 1000 attempts, 9 combinations already used")
+	})
+}
+
+func TestEnsureUniqueTuple(t *testing.T) {
+	faker := gofakeit.New(1)
+
+	t.Run("Only accepted values are recorded", func(t *testing.T) {
+		table := types.Table{
+			Name: "t",
+			Columns: []types.Column{
+				{Name: "a", Value: []string{"1", "2", "3"}, Validation: types.Validation{Unique: true}},
+				{Name: "b", Value: []string{"x"}},
+			},
+		}
+		// The row recorded a=1, 2 belongs to an earlier row and (1, x) is taken
+		tableData := map[string]interface{}{"a": "1", "b": "x"}
+		uniqueValues := map[string]map[string]bool{"t.a": {"1": true, "2": true}}
+		uniqueTuples := map[string]map[string]bool{"t(a,b)": {"1\x00x": true}}
+
+		err := ensureUniqueTuple(table, []string{"a", "b"}, nil, tableData, foreignKeys{}, uniqueValues, uniqueTuples, faker, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "3", tableData["a"])
+		assert.Equal(t, map[string]bool{"2": true, "3": true}, uniqueValues["t.a"], "the replaced value is released")
+	})
+
+	t.Run("Unset conditional columns stay empty", func(t *testing.T) {
+		table := types.Table{
+			Name: "t",
+			Columns: []types.Column{
+				{Name: "a", Value: []string{"1"}, When: "false"},
+				{Name: "b", Value: []string{"x", "y"}},
+			},
+		}
+		tableData := map[string]interface{}{"b": "x"}
+		uniqueTuples := map[string]map[string]bool{"t(a,b)": {"<nil>\x00x": true}}
+
+		err := ensureUniqueTuple(table, []string{"a", "b"}, map[string]bool{"a": true}, tableData, foreignKeys{}, map[string]map[string]bool{}, uniqueTuples, faker, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"b": "y"}, tableData)
+	})
+}
+
 func TestGenerateDataWithRelations(t *testing.T) {
 	manifestContent := `
 tables:
@@ -450,6 +1266,94 @@ func TestGenerateJSON(t *testing.T) {
 				assert.LessOrEqual(t, age, 65)
 			},
 		},
+		{
+			name: "Nested object",
+			config: types.JSONConfig{
+				{
+					Name: "profile",
+					Type: "object",
+					Fields: types.JSONConfig{
+						{Name: "email", Type: "email"},
+						{
+							Name: "address",
+							Type: "object",
+							Fields: types.JSONConfig{
+								{Name: "city", Type: "string"},
+							},
+						},
+					},
+				},
+			},
+			verify: func(t *testing.T, result interface{}) {
+				jsonObj := result.(map[string]interface{})
+				profile, ok := jsonObj["profile"].(map[string]interface{})
+				assert.True(t, ok)
+				assert.Contains(t, profile["email"], "@")
+
+				address, ok := profile["address"].(map[string]interface{})
+				assert.True(t, ok)
+				assert.IsType(t, "", address["city"])
+			},
+		},
+		{
+			name: "Array of objects",
+			config: types.JSONConfig{
+				{
+					Name:  "items",
+					Type:  "array",
+					Items: types.ArrayConfig{ElementType: "object", MinLength: 2, MaxLength: 4},
+					Fields: types.JSONConfig{
+						{Name: "sku", Type: "string"},
+						{Name: "quantity", Type: "int", Range: types.Range{Min: 1, Max: 5}},
+					},
+				},
+				{
+					Name:  "scores",
+					Type:  "array",
+					Items: types.ArrayConfig{ElementType: "int", MinLength: 3, MaxLength: 3},
+					Range: types.Range{Min: 0, Max: 10},
+				},
+			},
+			verify: func(t *testing.T, result interface{}) {
+				jsonObj := result.(map[string]interface{})
+				items, ok := jsonObj["items"].([]interface{})
+				assert.True(t, ok)
+				assert.GreaterOrEqual(t, len(items), 2)
+				assert.LessOrEqual(t, len(items), 4)
+				for _, item := range items {
+					obj, ok := item.(map[string]interface{})
+					assert.True(t, ok)
+					assert.Contains(t, obj, "sku")
+					quantity := obj["quantity"].(int)
+					assert.GreaterOrEqual(t, quantity, 1)
+					assert.LessOrEqual(t, quantity, 5)
+				}
+
+				scores, ok := jsonObj["scores"].([]interface{})
+				assert.True(t, ok)
+				assert.Len(t, scores, 3)
+				for _, score := range scores {
+					assert.LessOrEqual(t, score.(int), 10)
+				}
+			},
+		},
+		{
+			name: "Column-style field config",
+			config: types.JSONConfig{
+				{Name: "code", Type: "string", Pattern: "REF-####"},
+				{Name: "status", Type: "string", Value: []string{"active", "inactive"}},
+				{Name: "joined", Type: "date", Format: "02/01/2006"},
+				{Name: "nickname", Type: "string", NullProbability: 1},
+			},
+			verify: func(t *testing.T, result interface{}) {
+				jsonObj := result.(map[string]interface{})
+				assert.Regexp(t, "^REF-[0-9]{4}$", jsonObj["code"])
+				assert.Contains(t, []string{"active", "inactive"}, jsonObj["status"])
+				assert.Regexp(t, "^[0-9]{2}/[0-9]{2}/[0-9]{4}$", jsonObj["joined"])
+				assert.Contains(t, jsonObj, "nickname")
+				assert.Nil(t, jsonObj["nickname"])
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -519,7 +1423,8 @@ func TestParseValue(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := parseValue(tt.value, tt.fields)
+			result, err := parseValue(tt.value, tt.fields, nil)
+			assert.NoError(t, err)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
@@ -611,7 +1516,7 @@ func TestTimeArithmeticRules(t *testing.T) {
 			}
 
 			// Apply rules
-			applyRules(tt.rules, testFields)
+			assert.NoError(t, applyRules(tt.rules, testFields, nil, false))
 
 			// Check results
 			for key, expectedValue := range tt.expectedFields {
@@ -621,6 +1526,52 @@ func TestTimeArithmeticRules(t *testing.T) {
 	}
 }
 
+func TestRuleCases(t *testing.T) {
+	tiers := []types.Rule{{
+		Cases: []types.RuleCase{
+			{When: "fields.score >= 80", Then: map[string]string{"tier": "gold"}},
+			{When: "fields.score >= 50", Then: map[string]string{"tier": "silver"}},
+		},
+		Otherwise: map[string]string{"tier": "bronze"},
+	}}
+
+	tests := []struct {
+		score int
+		tier  string
+	}{
+		{score: 95, tier: "gold"},
+		{score: 80, tier: "gold"},
+		{score: 79, tier: "silver"},
+		{score: 50, tier: "silver"},
+		{score: 49, tier: "bronze"},
+		{score: 0, tier: "bronze"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.score), func(t *testing.T) {
+			fields := map[string]interface{}{"score": tt.score}
+			assert.NoError(t, applyRules(tiers, fields, nil, false))
+			assert.Equal(t, tt.tier, fields["tier"])
+		})
+	}
+
+	// The rule's own when is the first branch, cases follow it
+	rule := types.Rule{
+		When:  "fields.score >= 90",
+		Then:  map[string]string{"tier": "platinum"},
+		Cases: tiers[0].Cases,
+	}
+	for score, tier := range map[int]string{90: "platinum", 85: "gold", 10: ""} {
+		fields := map[string]interface{}{"score": score}
+		assert.NoError(t, applyRules([]types.Rule{rule}, fields, nil, false))
+		if tier == "" {
+			assert.NotContains(t, fields, "tier")
+		} else {
+			assert.Equal(t, tier, fields["tier"])
+		}
+	}
+}
+
 func TestGenerateDataWithTimeRules(t *testing.T) {
 	// Create a temporary manifest file for testing
 	manifestContent := `
@@ -765,7 +1716,7 @@ func TestExprEvaluation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := evaluateExpression(tt.expression, tt.fields)
+			result, err := evaluateExpression(tt.expression, tt.fields, nil)
 			assert.NoError(t, err)
 			assert.Equal(t, tt.want, result, "Expression evaluation failed for: %s", tt.name)
 		})
@@ -823,7 +1774,7 @@ func TestStringManipulationRules(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := evaluateExpression(tt.expression, tt.fields)
+			result, err := evaluateExpression(tt.expression, tt.fields, nil)
 			assert.NoError(t, err)
 			assert.Equal(t, tt.want, result, "Expression evaluation failed for: %s", tt.name)
 		})
@@ -884,6 +1835,61 @@ func TestMapGenerator(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "Map with int keys and float values",
+			config: types.MapConfig{
+				MinEntries: 2,
+				MaxEntries: 4,
+				KeyType:    "int",
+				ValueType:  "float",
+			},
+			validate: func(t *testing.T, value interface{}) {
+				m, ok := value.(map[string]interface{})
+				assert.True(t, ok)
+				assert.GreaterOrEqual(t, len(m), 2)
+				assert.LessOrEqual(t, len(m), 4)
+
+				for k, v := range m {
+					_, err := strconv.Atoi(k)
+					assert.NoError(t, err)
+					assert.IsType(t, 0.0, v)
+				}
+			},
+		},
+		{
+			name: "Map with uuid keys and typed predefined values",
+			config: types.MapConfig{
+				MinEntries: 1,
+				MaxEntries: 3,
+				Values:     []string{"true", "false"},
+				KeyType:    "uuid",
+				ValueType:  "bool",
+			},
+			validate: func(t *testing.T, value interface{}) {
+				m, ok := value.(map[string]interface{})
+				assert.True(t, ok)
+				assert.GreaterOrEqual(t, len(m), 1)
+
+				for k, v := range m {
+					_, err := uuid.Parse(k)
+					assert.NoError(t, err)
+					assert.IsType(t, true, v)
+				}
+			},
+		},
+		{
+			name: "Map without bounds",
+			config: types.MapConfig{
+				KeyType:   "string",
+				ValueType: "int",
+			},
+			validate: func(t *testing.T, value interface{}) {
+				m, ok := value.(map[string]interface{})
+				assert.True(t, ok)
+				assert.GreaterOrEqual(t, len(m), 1)
+				assert.LessOrEqual(t, len(m), 3)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -895,6 +1901,24 @@ func TestMapGenerator(t *testing.T) {
 	}
 }
 
+func TestMapColumnLevelTypes(t *testing.T) {
+	col := types.Column{
+		Name:      "scores",
+		Type:      "map",
+		KeyType:   "int",
+		ValueType: "float",
+		MapConfig: types.MapConfig{MinEntries: 1, MaxEntries: 3},
+	}
+
+	m, ok := NewValueGenerator(col, gofakeit.GlobalFaker).Generate().(map[string]interface{})
+	assert.True(t, ok)
+	for k, v := range m {
+		_, err := strconv.Atoi(k)
+		assert.NoError(t, err)
+		assert.IsType(t, 0.0, v)
+	}
+}
+
 func TestSetGenerator(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -949,6 +1973,105 @@ func TestSetGenerator(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "Set exhausting predefined values",
+			config: types.SetConfig{
+				MinElements: 3,
+				MaxElements: 5,
+				Values:      []string{"value1", "value2", "value1"},
+			},
+			validate: func(t *testing.T, value interface{}) {
+				// Manifests like this are rejected at load time, the generator
+				// itself clamps to the distinct values available
+				set, ok := value.([]interface{})
+				assert.True(t, ok)
+				assert.ElementsMatch(t, []interface{}{"value1", "value2"}, set)
+			},
+		},
+		{
+			name: "Set filling min from a small pool",
+			config: types.SetConfig{
+				MinElements: 3,
+				MaxElements: 3,
+				Values:      []string{"a", "b", "c"},
+			},
+			validate: func(t *testing.T, value interface{}) {
+				set, ok := value.([]interface{})
+				assert.True(t, ok)
+				assert.ElementsMatch(t, []interface{}{"a", "b", "c"}, set)
+			},
+		},
+		{
+			name: "Set with pattern",
+			config: types.SetConfig{
+				MinElements: 2,
+				MaxElements: 2,
+				Pattern:     "SKU###",
+			},
+			validate: func(t *testing.T, value interface{}) {
+				set, ok := value.([]interface{})
+				assert.True(t, ok)
+				assert.Len(t, set, 2)
+				for _, v := range set {
+					assert.Regexp(t, "^SKU[0-9]{3}$", v)
+				}
+			},
+		},
+		{
+			name: "Set without bounds",
+			config: types.SetConfig{
+				ElementType: "string",
+			},
+			validate: func(t *testing.T, value interface{}) {
+				set, ok := value.([]interface{})
+				assert.True(t, ok)
+				assert.GreaterOrEqual(t, len(set), 1)
+				assert.LessOrEqual(t, len(set), 3)
+			},
+		},
+		{
+			name: "Set of formatted dates",
+			config: types.SetConfig{
+				MinElements: 2,
+				MaxElements: 4,
+				Element: &types.Column{
+					Type:   "date",
+					Format: "02/01/2006",
+					Range:  types.Range{Min: "01/01/2024", Max: "31/12/2024"},
+				},
+			},
+			validate: func(t *testing.T, value interface{}) {
+				set, ok := value.([]interface{})
+				assert.True(t, ok)
+				assert.GreaterOrEqual(t, len(set), 2)
+				assert.LessOrEqual(t, len(set), 4)
+
+				seen := make(map[interface{}]bool)
+				for _, v := range set {
+					assert.False(t, seen[v], "duplicate element %v", v)
+					seen[v] = true
+
+					str, ok := v.(string)
+					assert.True(t, ok)
+					date, err := time.Parse("02/01/2006", str)
+					assert.NoError(t, err)
+					assert.Equal(t, 2024, date.Year())
+				}
+			},
+		},
+		{
+			name: "Set with inverted bounds",
+			config: types.SetConfig{
+				MinElements: 2,
+				MaxElements: 1,
+				ElementType: "string",
+			},
+			validate: func(t *testing.T, value interface{}) {
+				set, ok := value.([]interface{})
+				assert.True(t, ok)
+				assert.Len(t, set, 2)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1008,6 +2131,27 @@ func TestListGenerator(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "List of ranged ints",
+			config: types.ListConfig{
+				MinElements: 3,
+				MaxElements: 5,
+				Element:     &types.Column{Type: "int", Range: types.Range{Min: 10, Max: 20}},
+			},
+			validate: func(t *testing.T, value interface{}) {
+				list, ok := value.([]interface{})
+				assert.True(t, ok)
+				assert.GreaterOrEqual(t, len(list), 3)
+				assert.LessOrEqual(t, len(list), 5)
+
+				for _, v := range list {
+					n, ok := v.(int)
+					assert.True(t, ok)
+					assert.GreaterOrEqual(t, n, 10)
+					assert.LessOrEqual(t, n, 20)
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1071,7 +2215,7 @@ func TestUDTGenerator(t *testing.T) {
 			types.RegisterGenerateUDT(func(g *types.UDTGenerator) interface{} {
 				result := make(map[string]interface{})
 				for _, field := range g.Config.Fields {
-					result[field.Name] = generateColumnValue(field)
+					result[field.Name] = generateColumnValue(field, g.Rand())
 				}
 				return result
 			})
@@ -1143,7 +2287,7 @@ func TestTupleGenerator(t *testing.T) {
 			types.RegisterGenerateTuple(func(g *types.TupleGenerator) interface{} {
 				result := make([]interface{}, len(g.Config.Elements))
 				for i, element := range g.Config.Elements {
-					result[i] = generateColumnValue(element)
+					result[i] = generateColumnValue(element, g.Rand())
 				}
 				return result
 			})
@@ -1154,3 +2298,244 @@ func TestTupleGenerator(t *testing.T) {
 		})
 	}
 }
+
+// writeTempManifest writes the manifest content to a temporary file and returns its path
+func writeTempManifest(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	return path
+}
+
+// loadTempManifest writes content to a temporary manifest file and loads its schema
+func loadTempManifest(t *testing.T, content string) *types.Schema {
+	t.Helper()
+	schema, err := LoadSchema(writeTempManifest(t, content))
+	if err != nil {
+		t.Fatalf("Failed to load manifest: %v", err)
+	}
+	return schema
+}
+
+func TestGenerateStream(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: customers
+  priority: 1
+  columns:
+  - name: id
+    pattern: "C####"
+    parent: true
+- name: orders
+  priority: 2
+  depends_on: customers
+  columns:
+  - name: id
+    pattern: "O####"
+  - name: customer_id
+    foreign: "customers.id"
+`)
+
+	stream, err := GenerateStream(context.Background(), 4, manifestPath)
+	assert.NoError(t, err)
+
+	counts := make(map[string]int)
+	customerIDs := make(map[interface{}]bool)
+	for record := range stream.Records() {
+		counts[record.Table]++
+		switch record.Table {
+		case "customers":
+			customerIDs[record.Data["id"]] = true
+		case "orders":
+			assert.True(t, customerIDs[record.Data["customer_id"]], "order references unknown customer")
+		}
+	}
+
+	assert.Equal(t, map[string]int{"customers": 4, "orders": 4}, counts)
+	assert.NoError(t, stream.Err())
+}
+
+func TestGenerateStreamDuration(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: customers
+  columns:
+  - name: id
+    pattern: "C########"
+    parent: true
+  - name: seq
+    type: int
+    range:
+      min: 1
+      max: 1
+    counter: {}
+- name: orders
+  depends_on: customers
+  columns:
+  - name: id
+    pattern: "O########"
+  - name: customer_id
+    foreign: "customers.id"
+`)
+
+	duration := 100 * time.Millisecond
+	start := time.Now()
+	stream, err := GenerateStream(context.Background(), 4, manifestPath, WithDuration(duration))
+	assert.NoError(t, err)
+
+	counts := make(map[string]int)
+	customerIDs := make(map[interface{}]bool)
+	for record := range stream.Records() {
+		counts[record.Table]++
+		switch record.Table {
+		case "customers":
+			customerIDs[record.Data["id"]] = true
+			assert.Equal(t, counts["customers"], record.Data["seq"], "counters continue across rounds")
+		case "orders":
+			assert.True(t, customerIDs[record.Data["customer_id"]], "order references unknown customer")
+		}
+	}
+	elapsed := time.Since(start)
+
+	assert.GreaterOrEqual(t, elapsed, duration)
+	assert.Less(t, elapsed, duration+time.Second, "generation stops once the duration elapses")
+	assert.Greater(t, counts["customers"], 4, "tables are generated in rounds")
+	assert.Greater(t, counts["orders"], 0)
+}
+
+func TestGenerateRecordsDurationDefaults(t *testing.T) {
+	tables := []types.Table{{
+		Name:    "events",
+		Columns: []types.Column{{Name: "id", Pattern: "E########", Validation: types.Validation{Unique: true}}},
+	}}
+
+	// Without a count each round generates durationRoundSize records per table
+	n := 0
+	err := generateRecords(types.Schema{Tables: tables}, 0, func(record Record) error {
+		n++
+		return nil
+	}, WithDuration(50*time.Millisecond))
+	assert.NoError(t, err)
+	assert.Greater(t, n, 0)
+
+	// Aggregates would hold their records for the whole run
+	tables = append(tables, types.Table{
+		Name: "totals",
+		Columns: []types.Column{
+			{Name: "id", Pattern: "T###", Parent: true},
+			{Name: "events", Type: "int", Aggregate: types.Aggregate{Function: "count", Foreign: "items.total_id"}},
+		},
+	}, types.Table{
+		Name:      "items",
+		DependsOn: "totals",
+		Columns:   []types.Column{{Name: "total_id", Foreign: "totals.id"}},
+	})
+	err = generateRecords(types.Schema{Tables: tables}, 1, func(record Record) error { return nil }, WithDuration(time.Millisecond))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "aggregate columns can't be generated with a duration")
+	}
+}
+
+func TestCheckUniqueBound(t *testing.T) {
+	values := map[string]map[string]bool{"users.id": {"1": true, "2": true}}
+	tuples := map[string]map[string]bool{"users(a,b)": {"x\x00y": true}}
+	assert.NoError(t, checkUniqueBound(values, tuples, 3))
+	assert.Error(t, checkUniqueBound(values, tuples, 2))
+}
+
+func TestNullableForeignKey(t *testing.T) {
+	tables := []types.Table{
+		{
+			Name:     "customers",
+			Priority: 2,
+			Columns: []types.Column{
+				{Name: "id", Pattern: "C######", Parent: true},
+			},
+		},
+		{
+			Name:      "orders",
+			Priority:  1,
+			DependsOn: "customers",
+			Columns: []types.Column{
+				{Name: "id", Pattern: "O######"},
+				{Name: "coupon_id", Foreign: "customers.id", NullProbability: 0.3, Mandatory: true},
+			},
+		},
+	}
+
+	customerIDs := make(map[interface{}]bool)
+	nulls := 0
+	const count = 2000
+	err := generateRecords(types.Schema{Tables: tables}, count, func(record Record) error {
+		switch record.Table {
+		case "customers":
+			customerIDs[record.Data["id"]] = true
+		case "orders":
+			couponID := record.Data["coupon_id"]
+			if couponID == nil {
+				nulls++
+			} else {
+				assert.True(t, customerIDs[couponID], "order references unknown customer")
+			}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.3, float64(nulls)/count, 0.05)
+}
+
+func TestGenerateStreamMissingManifest(t *testing.T) {
+	stream, err := GenerateStream(context.Background(), 1, filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+	assert.Nil(t, stream)
+}
+
+func TestGenerateStreamError(t *testing.T) {
+	// A unique column with two possible values can't fill five rows
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: flags
+  columns:
+  - name: flag
+    value: ["on", "off"]
+    validation:
+      unique: true
+`)
+
+	stream, err := GenerateStream(context.Background(), 5, manifestPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	n := 0
+	for range stream.Records() {
+		n++
+	}
+	assert.Equal(t, 2, n)
+	assert.Error(t, stream.Err())
+}
+
+func TestGenerateStreamCancel(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: customers
+  columns:
+  - name: id
+    pattern: "C########"
+`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := GenerateStream(ctx, 1000, manifestPath, WithDuration(time.Hour))
+	if !assert.NoError(t, err) {
+		return
+	}
+	<-stream.Records()
+	cancel()
+
+	// The generator stops and closes the channel instead of running for the hour
+	for range stream.Records() {
+	}
+	assert.ErrorIs(t, stream.Err(), context.Canceled)
+}
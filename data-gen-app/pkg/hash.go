@@ -0,0 +1,52 @@
+package pkg
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// hashAlgorithms maps the supported hash_config algorithms to their constructors
+var hashAlgorithms = map[string]func() hash.Hash{
+	"":       sha256.New,
+	"sha256": sha256.New,
+	"sha1":   sha1.New,
+	"md5":    md5.New,
+}
+
+// applyHashes sets every hash column to the digest of its source columns, which
+// must already hold their values. Nil sources hash as empty strings.
+func applyHashes(columns []types.Column, tableData map[string]interface{}) {
+	for _, col := range columns {
+		if col.Type != "hash" {
+			continue
+		}
+		tableData[col.Name] = hashValue(col.HashConfig, tableData)
+	}
+}
+
+// hashValue digests the configured fields of tableData and encodes the sum
+func hashValue(cfg types.HashConfig, tableData map[string]interface{}) string {
+	values := make([]string, len(cfg.Fields))
+	for i, field := range cfg.Fields {
+		if value := tableData[field]; value != nil {
+			values[i] = fmt.Sprint(value)
+		}
+	}
+
+	// Algorithms are checked when the manifest is loaded
+	h := hashAlgorithms[cfg.Algorithm]()
+	h.Write([]byte(strings.Join(values, cfg.Separator)))
+	sum := h.Sum(nil)
+	if cfg.Encoding == "base64" {
+		return base64.StdEncoding.EncodeToString(sum)
+	}
+	return hex.EncodeToString(sum)
+}
@@ -0,0 +1,59 @@
+package pkg
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+func TestHashColumns(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: users
+  columns:
+  - name: email
+    pattern: "user####@example.com"
+  - name: tenant
+    value: ["acme", "globex"]
+  - name: surrogate_key
+    type: hash
+    hash_config:
+      fields: [tenant, email]
+  - name: email_md5
+    type: hash
+    hash_config:
+      fields: [email]
+      algorithm: md5
+      encoding: base64
+`)
+
+	stream, err := GenerateStream(context.Background(), 20, manifestPath)
+	assert.NoError(t, err)
+
+	for record := range stream.Records() {
+		email := record.Data["email"].(string)
+		tenant := record.Data["tenant"].(string)
+
+		sum := sha256.Sum256([]byte(tenant + email))
+		assert.Equal(t, hex.EncodeToString(sum[:]), record.Data["surrogate_key"])
+
+		md5Sum := md5.Sum([]byte(email))
+		assert.Equal(t, base64.StdEncoding.EncodeToString(md5Sum[:]), record.Data["email_md5"])
+	}
+}
+
+func TestHashValue(t *testing.T) {
+	data := map[string]interface{}{"id": 42, "name": "Ada"}
+	sum := sha256.Sum256([]byte("42|Ada|"))
+
+	// Nil sources hash as empty strings
+	cfg := types.HashConfig{Fields: []string{"id", "name", "missing"}, Separator: "|"}
+	assert.Equal(t, hex.EncodeToString(sum[:]), hashValue(cfg, data))
+	assert.Equal(t, hashValue(cfg, data), hashValue(cfg, data))
+}
@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"fmt"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+// einPrefixes are the campus prefixes the IRS assigns employer identification numbers from
+var einPrefixes = []int{
+	1, 2, 3, 4, 5, 6, 10, 11, 12, 13, 14, 15, 16, 20, 21, 22, 23, 24, 25, 26, 27,
+	30, 31, 32, 33, 34, 35, 36, 37, 38, 39, 40, 41, 42, 43, 44, 45, 46, 47, 48,
+	50, 51, 52, 53, 54, 55, 56, 57, 58, 59, 60, 61, 62, 63, 64, 65, 66, 67, 68,
+	71, 72, 73, 74, 75, 76, 77, 80, 81, 82, 83, 84, 85, 86, 87, 88, 90, 91, 92,
+	93, 94, 95, 98, 99,
+}
+
+// ssn generates a structurally valid US social security number: the area is never
+// 000, 666 or 900 and above, and neither the group nor the serial is all zeros
+func ssn(faker *gofakeit.Faker) string {
+	area := faker.IntRange(1, 898)
+	if area >= 666 {
+		area++
+	}
+	return fmt.Sprintf("%03d-%02d-%04d", area, faker.IntRange(1, 99), faker.IntRange(1, 9999))
+}
+
+// ein generates a US employer identification number with an assigned prefix
+func ein(faker *gofakeit.Faker) string {
+	return fmt.Sprintf("%02d-%07d", einPrefixes[faker.IntN(len(einPrefixes))], faker.IntRange(0, 9999999))
+}
+
+// withLuhnCheckDigit replaces the last digit of s with the Luhn check digit of
+// the digits before it, other characters such as separators are kept and
+// ignored. Strings without digits are returned unchanged.
+func withLuhnCheckDigit(s string) string {
+	b := []byte(s)
+	last := -1
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] >= '0' && b[i] <= '9' {
+			last = i
+			break
+		}
+	}
+	if last < 0 {
+		return s
+	}
+
+	// Walking left from the check digit, every second digit is doubled
+	sum, double := 0, true
+	for i := last - 1; i >= 0; i-- {
+		if b[i] < '0' || b[i] > '9' {
+			continue
+		}
+		d := int(b[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	b[last] = byte((10-sum%10)%10) + '0'
+	return string(b)
+}
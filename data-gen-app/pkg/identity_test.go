@@ -0,0 +1,75 @@
+package pkg
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// luhnValid reports whether the digits of s pass the Luhn checksum
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		d := int(s[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func TestWithLuhnCheckDigit(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "79927398710", want: "79927398713"},
+		{input: "4111-1111-1111-1110", want: "4111-1111-1111-1111"},
+		{input: "ACCT-0000", want: "ACCT-0000"},
+		{input: "no digits", want: "no digits"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.want, withLuhnCheckDigit(tt.input))
+		})
+	}
+}
+
+func TestLuhnColumn(t *testing.T) {
+	col := types.Column{Name: "account", Type: "string", Pattern: "ACC-####-####-###", Luhn: true}
+	for i := 0; i < 200; i++ {
+		value := columnValue(col, foreignKeys{}, gofakeit.GlobalFaker, nil).(string)
+		assert.Regexp(t, `^ACC-[0-9]{4}-[0-9]{4}-[0-9]{3}$`, value)
+		assert.True(t, luhnValid(value), "%s fails the Luhn check", value)
+	}
+}
+
+func TestIdentityNumbers(t *testing.T) {
+	for i := 0; i < 500; i++ {
+		value := generateColumnValue(types.Column{Name: "ssn", Type: "ssn"}, gofakeit.GlobalFaker).(string)
+		assert.Regexp(t, `^[0-9]{3}-[0-9]{2}-[0-9]{4}$`, value)
+		parts := strings.Split(value, "-")
+		area, _ := strconv.Atoi(parts[0])
+		assert.True(t, area > 0 && area != 666 && area < 900, "invalid area in %s", value)
+		assert.NotEqual(t, "00", parts[1])
+		assert.NotEqual(t, "0000", parts[2])
+
+		value = generateColumnValue(types.Column{Name: "ein", Type: "ein"}, gofakeit.GlobalFaker).(string)
+		assert.Regexp(t, `^[0-9]{2}-[0-9]{7}$`, value)
+		prefix, _ := strconv.Atoi(value[:2])
+		assert.Contains(t, einPrefixes, prefix)
+	}
+}
@@ -0,0 +1,185 @@
+package infer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+const (
+	// DefaultSampleRows is how many CSV rows are inspected when no limit is given
+	DefaultSampleRows = 100
+	// maxEnumValues is the most distinct values a string column may have to become a value list
+	maxEnumValues = 10
+)
+
+// timeLayouts are the timestamp layouts recognised in CSV values, most specific first
+var timeLayouts = []struct {
+	layout string
+	typ    string
+}{
+	{"2006-01-02 15:04:05", "timestamp"},
+	{time.RFC3339, "timestamp"},
+	{"2006-01-02", "date"},
+}
+
+// FromCSV reads a CSV header and up to sampleRows rows and returns a best-guess schema
+// with a single table. Each column gets the narrowest type that parses every sampled
+// value, with ranges from the sampled values, and low-cardinality strings become value lists.
+func FromCSV(r io.Reader, tableName string, sampleRows int) (*types.Schema, error) {
+	if sampleRows <= 0 {
+		sampleRows = DefaultSampleRows
+	}
+
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %v", err)
+	}
+
+	samples := make([][]string, len(header))
+	rows := 0
+	for ; rows < sampleRows; rows++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read csv row %d: %v", rows+1, err)
+		}
+		for i := range header {
+			if i < len(record) {
+				samples[i] = append(samples[i], record[i])
+			}
+		}
+	}
+
+	table := types.Table{Name: tableName}
+	for i, name := range header {
+		table.Columns = append(table.Columns, inferColumn(strings.TrimSpace(name), samples[i], rows))
+	}
+	return &types.Schema{Tables: []types.Table{table}}, nil
+}
+
+// inferColumn guesses a column from its sampled values, empty values are treated as null
+func inferColumn(name string, values []string, rows int) types.Column {
+	col := types.Column{Name: name}
+
+	var present []string
+	for _, value := range values {
+		if value != "" {
+			present = append(present, value)
+		}
+	}
+	if len(present) == 0 {
+		return col
+	}
+	col.Mandatory = len(present) == rows
+
+	switch {
+	case all(present, isBool):
+		col.Type = "bool"
+	case all(present, isInt):
+		col.Type = "int"
+		col.Range = numericRange(present)
+	case all(present, isFloat):
+		col.Type = "decimal"
+		col.Range = numericRange(present)
+	default:
+		for _, candidate := range timeLayouts {
+			if all(present, func(v string) bool { _, err := time.Parse(candidate.layout, v); return err == nil }) {
+				col.Type = candidate.typ
+				col.Format = candidate.layout
+				col.Range = timeRange(present, candidate.layout)
+				return col
+			}
+		}
+		col.Type = "string"
+		if distinct := distinctValues(present); len(distinct) <= maxEnumValues && len(distinct) < len(present) {
+			col.Value = distinct
+		}
+	}
+	return col
+}
+
+func all(values []string, match func(string) bool) bool {
+	for _, value := range values {
+		if !match(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func isBool(value string) bool {
+	switch strings.ToLower(value) {
+	case "true", "false":
+		return true
+	}
+	return false
+}
+
+func isInt(value string) bool {
+	_, err := strconv.Atoi(value)
+	return err == nil
+}
+
+func isFloat(value string) bool {
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
+
+// numericRange returns the smallest and largest sampled numbers, as ints when every value is one
+func numericRange(values []string) types.Range {
+	lo, _ := strconv.ParseFloat(values[0], 64)
+	hi := lo
+	for _, value := range values[1:] {
+		f, _ := strconv.ParseFloat(value, 64)
+		if f < lo {
+			lo = f
+		}
+		if f > hi {
+			hi = f
+		}
+	}
+	if all(values, isInt) {
+		return types.Range{Min: int(lo), Max: int(hi)}
+	}
+	return types.Range{Min: lo, Max: hi}
+}
+
+// timeRange returns the earliest and latest sampled times formatted with layout
+func timeRange(values []string, layout string) types.Range {
+	lo, _ := time.Parse(layout, values[0])
+	hi := lo
+	for _, value := range values[1:] {
+		t, _ := time.Parse(layout, value)
+		if t.Before(lo) {
+			lo = t
+		}
+		if t.After(hi) {
+			hi = t
+		}
+	}
+	return types.Range{Min: lo.Format(layout), Max: hi.Format(layout)}
+}
+
+// distinctValues returns the sorted distinct values
+func distinctValues(values []string) []string {
+	seen := make(map[string]bool)
+	var distinct []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			distinct = append(distinct, value)
+		}
+	}
+	sort.Strings(distinct)
+	return distinct
+}
@@ -0,0 +1,52 @@
+package infer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+func TestFromCSV(t *testing.T) {
+	input := `id,age,score,active,signup_date,last_login,tier,comment
+1,34,9.5,true,2024-01-05,2024-01-05 10:00:00,gold,first
+2,27,7.25,false,2024-02-10,2024-02-11 08:30:00,silver,
+3,45,8,true,2024-03-15,2024-03-15 19:45:10,gold,third
+4,19,6.5,false,2024-01-20,2024-01-21 12:00:00,silver,fourth
+`
+
+	schema, err := FromCSV(strings.NewReader(input), "users", 0)
+	assert.NoError(t, err)
+	assert.Len(t, schema.Tables, 1)
+	table := schema.Tables[0]
+	assert.Equal(t, "users", table.Name)
+
+	expected := []types.Column{
+		{Name: "id", Type: "int", Mandatory: true, Range: types.Range{Min: 1, Max: 4}},
+		{Name: "age", Type: "int", Mandatory: true, Range: types.Range{Min: 19, Max: 45}},
+		{Name: "score", Type: "decimal", Mandatory: true, Range: types.Range{Min: 6.5, Max: 9.5}},
+		{Name: "active", Type: "bool", Mandatory: true},
+		{Name: "signup_date", Type: "date", Format: "2006-01-02", Mandatory: true,
+			Range: types.Range{Min: "2024-01-05", Max: "2024-03-15"}},
+		{Name: "last_login", Type: "timestamp", Format: "2006-01-02 15:04:05", Mandatory: true,
+			Range: types.Range{Min: "2024-01-05 10:00:00", Max: "2024-03-15 19:45:10"}},
+		{Name: "tier", Type: "string", Mandatory: true, Value: []string{"gold", "silver"}},
+		{Name: "comment", Type: "string"},
+	}
+	assert.Equal(t, expected, table.Columns)
+}
+
+func TestFromCSVSampleRows(t *testing.T) {
+	input := "code\n1\n2\nabc\n"
+
+	// Only the first two rows are sampled, so the column still looks numeric
+	schema, err := FromCSV(strings.NewReader(input), "codes", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "int", schema.Tables[0].Columns[0].Type)
+}
+
+func TestFromCSVEmpty(t *testing.T) {
+	_, err := FromCSV(strings.NewReader(""), "empty", 0)
+	assert.Error(t, err)
+}
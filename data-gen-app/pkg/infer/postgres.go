@@ -0,0 +1,91 @@
+package infer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// Querier is the subset of *pg.DB used to introspect a table
+type Querier interface {
+	Query(model, query interface{}, params ...interface{}) (pg.Result, error)
+}
+
+var _ Querier = (*pg.DB)(nil)
+
+// pgColumn is a row of information_schema.columns
+type pgColumn struct {
+	ColumnName string `pg:"column_name"`
+	DataType   string `pg:"data_type"`
+	UDTName    string `pg:"udt_name"`
+	IsNullable string `pg:"is_nullable"`
+}
+
+const columnsQuery = `SELECT column_name, data_type, udt_name, is_nullable
+FROM information_schema.columns
+WHERE table_schema = ? AND table_name = ?
+ORDER BY ordinal_position`
+
+// FromPostgres introspects a table through information_schema and returns a schema with a
+// single table whose columns have matching types, NOT NULL columns become mandatory
+func FromPostgres(db Querier, schemaName string, tableName string) (*types.Schema, error) {
+	if schemaName == "" {
+		schemaName = "public"
+	}
+
+	var columns []pgColumn
+	if _, err := db.Query(&columns, columnsQuery, schemaName, tableName); err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s.%s: %v", schemaName, tableName, err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table not found: %s.%s", schemaName, tableName)
+	}
+
+	table := types.Table{Name: tableName}
+	for _, column := range columns {
+		col := types.Column{
+			Name:      column.ColumnName,
+			Mandatory: column.IsNullable == "NO",
+		}
+		if column.DataType == "ARRAY" {
+			col.Type = "list"
+			col.ElementType = pgType(strings.TrimPrefix(column.UDTName, "_"))
+			col.ListConfig = types.ListConfig{MinElements: 1, MaxElements: 3, ElementType: col.ElementType}
+		} else {
+			col.Type = pgType(column.DataType)
+		}
+		if col.Type == "date" {
+			col.Format = "2006-01-02"
+		}
+		table.Columns = append(table.Columns, col)
+	}
+	return &types.Schema{Tables: []types.Table{table}}, nil
+}
+
+// pgType maps a Postgres data type, or the udt_name of an array element, to a manifest type
+func pgType(dataType string) string {
+	switch dataType {
+	case "smallint", "integer", "int2", "int4":
+		return "int"
+	case "bigint", "int8":
+		return "bigint"
+	case "numeric", "decimal":
+		return "decimal"
+	case "real", "double precision", "float4", "float8":
+		return "float"
+	case "boolean", "bool":
+		return "bool"
+	case "uuid":
+		return "uuid"
+	case "date":
+		return "date"
+	case "timestamp without time zone", "timestamp with time zone", "timestamp", "timestamptz":
+		return "timestamp"
+	case "json", "jsonb":
+		return "json"
+	default:
+		return "string"
+	}
+}
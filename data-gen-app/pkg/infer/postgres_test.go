@@ -0,0 +1,69 @@
+package infer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// mockQuerier answers the information_schema query with fixed rows
+type mockQuerier struct {
+	columns []pgColumn
+	err     error
+	params  []interface{}
+}
+
+func (m *mockQuerier) Query(model, query interface{}, params ...interface{}) (pg.Result, error) {
+	m.params = params
+	if m.err != nil {
+		return nil, m.err
+	}
+	*model.(*[]pgColumn) = m.columns
+	return nil, nil
+}
+
+func TestFromPostgres(t *testing.T) {
+	db := &mockQuerier{
+		columns: []pgColumn{
+			{ColumnName: "id", DataType: "uuid", UDTName: "uuid", IsNullable: "NO"},
+			{ColumnName: "age", DataType: "integer", UDTName: "int4", IsNullable: "YES"},
+			{ColumnName: "balance", DataType: "numeric", UDTName: "numeric", IsNullable: "NO"},
+			{ColumnName: "active", DataType: "boolean", UDTName: "bool", IsNullable: "NO"},
+			{ColumnName: "birthday", DataType: "date", UDTName: "date", IsNullable: "YES"},
+			{ColumnName: "created_at", DataType: "timestamp with time zone", UDTName: "timestamptz", IsNullable: "NO"},
+			{ColumnName: "profile", DataType: "jsonb", UDTName: "jsonb", IsNullable: "YES"},
+			{ColumnName: "scores", DataType: "ARRAY", UDTName: "_int4", IsNullable: "YES"},
+			{ColumnName: "name", DataType: "character varying", UDTName: "varchar", IsNullable: "NO"},
+		},
+	}
+
+	schema, err := FromPostgres(db, "", "users")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"public", "users"}, db.params)
+
+	expected := []types.Column{
+		{Name: "id", Type: "uuid", Mandatory: true},
+		{Name: "age", Type: "int"},
+		{Name: "balance", Type: "decimal", Mandatory: true},
+		{Name: "active", Type: "bool", Mandatory: true},
+		{Name: "birthday", Type: "date", Format: "2006-01-02"},
+		{Name: "created_at", Type: "timestamp", Mandatory: true},
+		{Name: "profile", Type: "json"},
+		{Name: "scores", Type: "list", ElementType: "int",
+			ListConfig: types.ListConfig{MinElements: 1, MaxElements: 3, ElementType: "int"}},
+		{Name: "name", Type: "string", Mandatory: true},
+	}
+	assert.Equal(t, "users", schema.Tables[0].Name)
+	assert.Equal(t, expected, schema.Tables[0].Columns)
+}
+
+func TestFromPostgresErrors(t *testing.T) {
+	_, err := FromPostgres(&mockQuerier{}, "sales", "missing")
+	assert.EqualError(t, err, "table not found: sales.missing")
+
+	_, err = FromPostgres(&mockQuerier{err: errors.New("connection refused")}, "", "users")
+	assert.EqualError(t, err, "failed to read columns of public.users: connection refused")
+}
@@ -0,0 +1,187 @@
+package pkg
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// Orphan is a child row whose foreign key matches no row of the parent table
+type Orphan struct {
+	File    string // CSV file holding the child row
+	Line    int    // Line of the row in the file, the header is line 1
+	Column  string // Child column as table.column
+	Foreign string // Referenced parent column as table.column
+	Value   string
+}
+
+func (o Orphan) String() string {
+	return fmt.Sprintf("%s:%d: %s = %q has no parent in %s", o.File, o.Line, o.Column, o.Value, o.Foreign)
+}
+
+// IntegrityOption configures CheckCSVIntegrity
+type IntegrityOption func(*integrityOptions)
+
+type integrityOptions struct {
+	nullToken string
+}
+
+// IntegrityNullToken treats token as a null foreign key, as written by a CSV sink
+// created with sink.WithNullToken(token)
+func IntegrityNullToken(token string) IntegrityOption {
+	return func(o *integrityOptions) {
+		o.nullToken = token
+	}
+}
+
+// CheckCSVIntegrity reads the CSV files written for the manifest's tables in
+// outputDir, including gzipped and split files, and returns every child row whose
+// foreign key is missing from the parent table's file. Empty foreign keys, and
+// those holding the null token, are optional relationships and never orphans;
+// external keys are not checked.
+func CheckCSVIntegrity(schema *types.Schema, outputDir string, opts ...IntegrityOption) ([]Orphan, error) {
+	var o integrityOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	tables := make(map[string]bool)
+	for _, table := range schema.Tables {
+		tables[table.Name] = true
+	}
+
+	parentValues := make(map[string]map[string]bool) // Values per parent table.column, read once
+	var orphans []Orphan
+	for _, table := range schema.Tables {
+		for _, col := range table.Columns {
+			if col.Foreign == "" {
+				continue
+			}
+			parentTable, parentColumn, _ := strings.Cut(col.Foreign, ".")
+			if !tables[parentTable] {
+				continue
+			}
+			if parentValues[col.Foreign] == nil {
+				values := make(map[string]bool)
+				err := readCSVColumn(outputDir, parentTable, parentColumn, func(file string, line int, value string) {
+					values[value] = true
+				})
+				if err != nil {
+					return nil, err
+				}
+				parentValues[col.Foreign] = values
+			}
+
+			parents := parentValues[col.Foreign]
+			err := readCSVColumn(outputDir, table.Name, col.Name, func(file string, line int, value string) {
+				if value != "" && value != o.nullToken && !parents[value] {
+					orphans = append(orphans, Orphan{
+						File:    file,
+						Line:    line,
+						Column:  table.Name + "." + col.Name,
+						Foreign: col.Foreign,
+						Value:   value,
+					})
+				}
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return orphans, nil
+}
+
+// tableCSVFiles returns the CSV files of a table, users.csv or its split files
+// users_001.csv, ..., each optionally gzipped. Other tables sharing the prefix,
+// such as users_2fa, are not matched.
+func tableCSVFiles(outputDir string, tableName string) ([]string, error) {
+	splitFile := regexp.MustCompile(`^` + regexp.QuoteMeta(tableName) + `_[0-9]{3,}\.csv(\.gz)?$`)
+	var files []string
+	for _, pattern := range []string{tableName + ".csv", tableName + ".csv.gz", tableName + "_*.csv", tableName + "_*.csv.gz"} {
+		matches, err := filepath.Glob(filepath.Join(outputDir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			if name := filepath.Base(match); name == tableName+".csv" || name == tableName+".csv.gz" || splitFile.MatchString(name) {
+				files = append(files, match)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no CSV output for table %s in %s", tableName, outputDir)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// readCSVColumn calls fn with the value of column for every row of the table's CSV files
+func readCSVColumn(outputDir string, tableName string, column string, fn func(file string, line int, value string)) error {
+	files, err := tableCSVFiles(outputDir, tableName)
+	if err != nil {
+		return err
+	}
+	for _, path := range files {
+		if err := readCSVFileColumn(path, column, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readCSVFileColumn(path string, column string, fn func(file string, line int, value string)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	var input io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		defer gz.Close()
+		input = gz
+	}
+
+	reader := csv.NewReader(input)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read header of %s: %v", path, err)
+	}
+	index := -1
+	for i, name := range header {
+		if name == column {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("%s has no column %s", path, column)
+	}
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		line, _ := reader.FieldPos(0)
+		fn(path, line, row[index])
+	}
+}
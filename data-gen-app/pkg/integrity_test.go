@@ -0,0 +1,144 @@
+package pkg
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/sink"
+)
+
+func TestCheckCSVIntegrity(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: customers
+  columns:
+  - name: id
+    pattern: "C######"
+    parent: true
+- name: orders
+  depends_on: customers
+  count: 20
+  columns:
+  - name: id
+    pattern: "O######"
+  - name: customer_id
+    foreign: customers.id
+`)
+	schema, err := LoadSchema(manifestPath)
+	assert.NoError(t, err)
+
+	outputDir := t.TempDir()
+	csvSink, err := sink.NewCSVSink(outputDir, schema, sink.WithMaxRowsPerFile(8))
+	assert.NoError(t, err)
+	assert.NoError(t, GenerateData(csvSink, 5, manifestPath))
+
+	orphans, err := CheckCSVIntegrity(schema, outputDir)
+	assert.NoError(t, err)
+	assert.Empty(t, orphans)
+
+	// Point one child row of the second split file at a customer that does not exist
+	ordersPath := filepath.Join(outputDir, "orders_002.csv")
+	file, err := os.Open(ordersPath)
+	assert.NoError(t, err)
+	rows, err := csv.NewReader(file).ReadAll()
+	file.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "customer_id", rows[0][1])
+	rows[3][1] = "C-MISSING"
+
+	file, err = os.Create(ordersPath)
+	assert.NoError(t, err)
+	writer := csv.NewWriter(file)
+	assert.NoError(t, writer.WriteAll(rows))
+	file.Close()
+
+	orphans, err = CheckCSVIntegrity(schema, outputDir)
+	assert.NoError(t, err)
+	assert.Equal(t, []Orphan{{
+		File:    ordersPath,
+		Line:    4,
+		Column:  "orders.customer_id",
+		Foreign: "customers.id",
+		Value:   "C-MISSING",
+	}}, orphans)
+}
+
+func TestCheckCSVIntegrityNullToken(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: customers
+  columns:
+  - name: id
+    pattern: "C######"
+    parent: true
+- name: orders
+  depends_on: customers
+  columns:
+  - name: id
+    pattern: "O######"
+  - name: customer_id
+    foreign: customers.id
+    null_probability: 0.5
+`)
+	schema, err := LoadSchema(manifestPath)
+	assert.NoError(t, err)
+
+	outputDir := t.TempDir()
+	csvSink, err := sink.NewCSVSink(outputDir, schema, sink.WithNullToken(`\N`))
+	assert.NoError(t, err)
+	assert.NoError(t, GenerateData(csvSink, 50, manifestPath))
+
+	// Without the token the null foreign keys look like orphans
+	orphans, err := CheckCSVIntegrity(schema, outputDir)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, orphans)
+	for _, orphan := range orphans {
+		assert.Equal(t, `\N`, orphan.Value)
+	}
+
+	orphans, err = CheckCSVIntegrity(schema, outputDir, IntegrityNullToken(`\N`))
+	assert.NoError(t, err)
+	assert.Empty(t, orphans)
+}
+
+func TestCheckCSVIntegrityMissingOutput(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: customers
+  columns:
+  - name: id
+    parent: true
+- name: orders
+  depends_on: customers
+  columns:
+  - name: customer_id
+    foreign: customers.id
+`)
+	schema, err := LoadSchema(manifestPath)
+	assert.NoError(t, err)
+	_, err = CheckCSVIntegrity(schema, t.TempDir())
+	assert.ErrorContains(t, err, "no CSV output for table customers")
+}
+
+func TestTableCSVFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"users.csv", "users_001.csv", "users_002.csv.gz", "users_2fa.csv", "users_2fa_001.csv", "users_01.csv"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+
+	// Tables sharing the prefix, such as users_2fa, are not split files of users
+	files, err := tableCSVFiles(dir, "users")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "users.csv"),
+		filepath.Join(dir, "users_001.csv"),
+		filepath.Join(dir, "users_002.csv.gz"),
+	}, files)
+
+	files, err = tableCSVFiles(dir, "users_2fa")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "users_2fa.csv"), filepath.Join(dir, "users_2fa_001.csv")}, files)
+}
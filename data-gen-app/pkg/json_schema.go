@@ -0,0 +1,177 @@
+package pkg
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// jsonSchemaDraft07 identifies the JSON Schema version of the schemas JSONSchema returns
+const jsonSchemaDraft07 = "http://json-schema.org/draft-07/schema#"
+
+// JSONSchemaDocument is a draft-07 JSON Schema, or one of its subschemas. Type
+// is a type name, or a list of them when a value may also be null.
+type JSONSchemaDocument struct {
+	Schema      string                         `json:"$schema,omitempty"`
+	Title       string                         `json:"title,omitempty"`
+	Type        interface{}                    `json:"type,omitempty"`
+	Format      string                         `json:"format,omitempty"`
+	Enum        []interface{}                  `json:"enum,omitempty"`
+	Properties  map[string]*JSONSchemaDocument `json:"properties,omitempty"`
+	Required    []string                       `json:"required,omitempty"`
+	Items       *JSONSchemaDocument            `json:"items,omitempty"`
+	Definitions map[string]*JSONSchemaDocument `json:"definitions,omitempty"`
+}
+
+// JSONSchema describes the records generated from the manifest as they are
+// written as JSON, with one definition per table under #/definitions/<table>.
+// Mandatory columns are required, columns with values or a const are enums, and
+// nested values are typed as objects and arrays without describing their fields.
+func JSONSchema(schema *types.Schema) *JSONSchemaDocument {
+	document := &JSONSchemaDocument{
+		Schema:      jsonSchemaDraft07,
+		Title:       "Generated records",
+		Definitions: make(map[string]*JSONSchemaDocument, len(schema.Tables)),
+	}
+	for _, table := range schema.Tables {
+		document.Definitions[table.Name] = tableJSONSchema(table)
+	}
+	return document
+}
+
+// WriteJSONSchema writes document to w as indented JSON
+func WriteJSONSchema(w io.Writer, document *JSONSchemaDocument) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+// tableJSONSchema describes the records of table as an object with a property per column
+func tableJSONSchema(table types.Table) *JSONSchemaDocument {
+	// Correlated choices assign their string values over the columns' own
+	choices := make(map[string][]interface{})
+	for _, choice := range table.Choices {
+		for name, value := range choice {
+			choices[name] = appendEnum(choices[name], value)
+		}
+	}
+
+	tableSchema := &JSONSchemaDocument{
+		Title:      table.Name,
+		Type:       "object",
+		Properties: make(map[string]*JSONSchemaDocument, len(table.Columns)),
+	}
+	for _, col := range table.Columns {
+		property := columnJSONSchema(col)
+		if values, ok := choices[col.Name]; ok {
+			property = &JSONSchemaDocument{Type: "string", Enum: values}
+		}
+		if col.Mandatory {
+			// Mandatory columns are always present, holding null when no value was generated
+			tableSchema.Required = append(tableSchema.Required, col.Name)
+			if col.SoftDelete != nil || ((col.When != "" || col.Foreign != "") && col.Default == "") {
+				property.allowNull()
+			}
+		}
+		tableSchema.Properties[col.Name] = property
+	}
+	return tableSchema
+}
+
+// columnJSONSchema describes the non-null values of col, following the
+// precedence of columnValue
+func columnJSONSchema(col types.Column) *JSONSchemaDocument {
+	var property *JSONSchemaDocument
+	switch valueSource(col) {
+	case "aggregate":
+		if col.Aggregate.Function == "count" {
+			property = &JSONSchemaDocument{Type: "integer"}
+		} else {
+			property = &JSONSchemaDocument{Type: "number"}
+		}
+	case "const":
+		value, _ := literalValue(col, col.Const)
+		return &JSONSchemaDocument{Enum: []interface{}{value}}
+	case "value":
+		property = &JSONSchemaDocument{Type: "string"}
+		for _, value := range col.Value {
+			property.Enum = appendEnum(property.Enum, value)
+		}
+	case "foreign", "pattern", "hash":
+		// Parent keys are stored as strings whatever the parent column's type
+		property = &JSONSchemaDocument{Type: "string"}
+	default:
+		property = generatedJSONSchema(col)
+	}
+
+	// Defaults fill in for values that were not generated
+	if col.Default != "" && property.Enum != nil {
+		value, _ := literalValue(col, col.Default)
+		property.Enum = appendEnum(property.Enum, value)
+	}
+	return property
+}
+
+// generatedJSONSchema describes the values generated for the type of col
+func generatedJSONSchema(col types.Column) *JSONSchemaDocument {
+	if _, ok := registeredType(col.Type); ok {
+		// Registered types may generate anything
+		return &JSONSchemaDocument{}
+	}
+	if len(col.ValuesFiles) > 0 {
+		return &JSONSchemaDocument{Type: "string"}
+	}
+
+	switch col.Type {
+	case "int", "bigint", "long":
+		if col.Width > 0 {
+			// Padded to a fixed width, 42 becomes "0000042"
+			return &JSONSchemaDocument{Type: "string"}
+		}
+		return &JSONSchemaDocument{Type: "integer"}
+	case "float", "decimal":
+		return &JSONSchemaDocument{Type: "number"}
+	case "bool":
+		return &JSONSchemaDocument{Type: "boolean"}
+	case "timestamp":
+		if isEpochFormat(col.Format) {
+			return &JSONSchemaDocument{Type: "integer"}
+		}
+		return &JSONSchemaDocument{Type: "string", Format: "date-time"}
+	case "date":
+		if col.Format == "2006-01-02" {
+			return &JSONSchemaDocument{Type: "string", Format: "date"}
+		}
+		return &JSONSchemaDocument{Type: "string"}
+	case "json", "map", "udt":
+		return &JSONSchemaDocument{Type: "object"}
+	case "list", "set", "tuple":
+		return &JSONSchemaDocument{Type: "array"}
+	case "objects":
+		return &JSONSchemaDocument{Type: "array", Items: &JSONSchemaDocument{Type: "object"}}
+	default:
+		return &JSONSchemaDocument{Type: "string"}
+	}
+}
+
+// allowNull lets the described value be null as well, which both the enum
+// and the type must then accept
+func (s *JSONSchemaDocument) allowNull() {
+	if s.Enum != nil {
+		s.Enum = appendEnum(s.Enum, nil)
+	}
+	if name, ok := s.Type.(string); ok {
+		s.Type = []string{name, "null"}
+	}
+}
+
+// appendEnum adds value to enum unless it is already listed
+func appendEnum(enum []interface{}, value interface{}) []interface{} {
+	for _, existing := range enum {
+		if existing == value {
+			return enum
+		}
+	}
+	return append(enum, value)
+}
@@ -0,0 +1,199 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const jsonSchemaManifest = `
+tables:
+- name: customers
+  columns:
+  - name: id
+    pattern: "C######"
+    parent: true
+    mandatory: true
+  - name: age
+    type: int
+    range:
+      min: 18
+      max: 90
+  - name: score
+    type: float
+  - name: active
+    type: bool
+    mandatory: true
+  - name: status
+    value: [active, closed]
+    mandatory: true
+  - name: country
+    const: NZ
+  - name: joined_at
+    type: timestamp
+  - name: member_no
+    type: int
+    width: 6
+- name: orders
+  depends_on: customers
+  columns:
+  - name: id
+    type: uuid
+    mandatory: true
+  - name: customer_id
+    foreign: customers.id
+    mandatory: true
+    null_probability: 0.2
+  - name: placed_on
+    type: date
+    format: "2006-01-02"
+  - name: placed_at
+    type: timestamp
+    format: unix_ms
+  - name: note
+    type: sentence
+    when: "fields.customer_id != nil"
+  - name: priority
+    value: [low, high]
+    mandatory: true
+    when: "fields.customer_id != nil"
+`
+
+func TestJSONSchema(t *testing.T) {
+	manifestPath := writeTempManifest(t, jsonSchemaManifest)
+	schema, err := LoadSchema(manifestPath)
+	assert.NoError(t, err)
+	document := JSONSchema(schema)
+	assert.Equal(t, jsonSchemaDraft07, document.Schema)
+
+	customers, orders := document.Definitions["customers"], document.Definitions["orders"]
+	assert.Equal(t, []string{"id", "active", "status"}, customers.Required)
+	assert.Equal(t, "integer", customers.Properties["age"].Type)
+	assert.Equal(t, "number", customers.Properties["score"].Type)
+	assert.Equal(t, &JSONSchemaDocument{Type: "string", Enum: []interface{}{"active", "closed"}}, customers.Properties["status"])
+	assert.Equal(t, []interface{}{"NZ"}, customers.Properties["country"].Enum)
+	assert.Equal(t, &JSONSchemaDocument{Type: "string", Format: "date-time"}, customers.Properties["joined_at"])
+	assert.Equal(t, "string", customers.Properties["member_no"].Type)
+	assert.Equal(t, []string{"string", "null"}, orders.Properties["customer_id"].Type)
+	assert.Equal(t, "date", orders.Properties["placed_on"].Format)
+	assert.Equal(t, "integer", orders.Properties["placed_at"].Type)
+	// Null when the condition does not hold, so the enum and type both allow it
+	assert.Equal(t, &JSONSchemaDocument{Type: []string{"string", "null"}, Enum: []interface{}{"low", "high", nil}}, orders.Properties["priority"])
+
+	// Every generated record, as written as JSON, validates against its table's schema
+	var encoded bytes.Buffer
+	assert.NoError(t, WriteJSONSchema(&encoded, document))
+	var decoded map[string]interface{}
+	assert.NoError(t, json.Unmarshal(encoded.Bytes(), &decoded))
+	definitions := decoded["definitions"].(map[string]interface{})
+
+	rows, err := Generate(manifestPath, 50)
+	assert.NoError(t, err)
+	var nullPriorities int
+	for _, record := range rows["orders"] {
+		if record["priority"] == nil {
+			nullPriorities++
+		}
+	}
+	assert.Positive(t, nullPriorities)
+	for table, records := range rows {
+		for _, record := range records {
+			payload, err := json.Marshal(record)
+			assert.NoError(t, err)
+			var value interface{}
+			assert.NoError(t, json.Unmarshal(payload, &value))
+			assert.NoError(t, validateJSONSchema(definitions[table], value), "%s record %s", table, payload)
+		}
+	}
+
+	// A record breaking the schema is caught
+	assert.Error(t, validateJSONSchema(definitions["customers"], map[string]interface{}{"id": "C1", "active": true, "status": "open"}))
+	assert.Error(t, validateJSONSchema(definitions["customers"], map[string]interface{}{"id": "C1", "status": "active"}))
+}
+
+// validateJSONSchema checks value against the draft-07 keywords JSONSchema emits:
+// type, enum, required, properties and items
+func validateJSONSchema(schema interface{}, value interface{}) error {
+	rules := schema.(map[string]interface{})
+	if types, ok := rules["type"]; ok && !jsonTypeMatches(types, value) {
+		return fmt.Errorf("%v is not of type %v", value, types)
+	}
+	if enum, ok := rules["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			found = found || reflect.DeepEqual(allowed, value)
+		}
+		if !found {
+			return fmt.Errorf("%v is not one of %v", value, enum)
+		}
+	}
+	if object, ok := value.(map[string]interface{}); ok {
+		for _, name := range asSlice(rules["required"]) {
+			if _, present := object[name.(string)]; !present {
+				return fmt.Errorf("required property %s is missing", name)
+			}
+		}
+		properties, _ := rules["properties"].(map[string]interface{})
+		for name, property := range properties {
+			if field, present := object[name]; present {
+				if err := validateJSONSchema(property, field); err != nil {
+					return fmt.Errorf("%s: %v", name, err)
+				}
+			}
+		}
+	}
+	if items, ok := rules["items"]; ok {
+		for _, element := range asSlice(value) {
+			if err := validateJSONSchema(items, element); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonTypeMatches reports whether value has the JSON type, or one of the types, given
+func jsonTypeMatches(types interface{}, value interface{}) bool {
+	if name, ok := types.(string); ok {
+		types = []interface{}{name}
+	}
+	for _, name := range asSlice(types) {
+		switch v := value.(type) {
+		case nil:
+			if name == "null" {
+				return true
+			}
+		case bool:
+			if name == "boolean" {
+				return true
+			}
+		case float64:
+			if name == "number" || (name == "integer" && v == math.Trunc(v)) {
+				return true
+			}
+		case string:
+			if name == "string" {
+				return true
+			}
+		case []interface{}:
+			if name == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if name == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func asSlice(value interface{}) []interface{} {
+	slice, _ := value.([]interface{})
+	return slice
+}
@@ -0,0 +1,80 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// locale holds the names used for name columns in one language. gofakeit only
+// ships English data, so other languages are drawn from these lists with the
+// run's faker, keeping seeded runs reproducible.
+type locale struct {
+	firstNames []string
+	lastNames  []string
+}
+
+// locales maps language codes to their name data, English uses gofakeit's own
+var locales = map[string]*locale{
+	"de": {
+		firstNames: []string{"Lukas", "Leon", "Maximilian", "Felix", "Jonas", "Paul", "Elias", "Finn", "Jürgen", "Klaus",
+			"Mia", "Emma", "Hannah", "Lea", "Sophie", "Lena", "Marie", "Anna", "Ursula", "Katharina"},
+		lastNames: []string{"Müller", "Schmidt", "Schneider", "Fischer", "Weber", "Meyer", "Wagner", "Becker", "Schulz", "Hoffmann",
+			"Schäfer", "Koch", "Bauer", "Richter", "Klein", "Wolf", "Schröder", "Neumann", "Schwarz", "Zimmermann"},
+	},
+	"es": {
+		firstNames: []string{"Alejandro", "Javier", "Carlos", "Diego", "Pablo", "Sergio", "Miguel", "Álvaro", "Andrés", "Jorge",
+			"Lucía", "María", "Carmen", "Sofía", "Isabel", "Elena", "Paula", "Marta", "Inés", "Pilar"},
+		lastNames: []string{"García", "Fernández", "González", "Rodríguez", "López", "Martínez", "Sánchez", "Pérez", "Gómez", "Martín",
+			"Jiménez", "Ruiz", "Hernández", "Díaz", "Moreno", "Muñoz", "Álvarez", "Romero", "Navarro", "Torres"},
+	},
+	"fr": {
+		firstNames: []string{"Louis", "Gabriel", "Jules", "Hugo", "Lucas", "Théo", "Mathis", "Étienne", "François", "Benoît",
+			"Camille", "Chloé", "Léa", "Manon", "Inès", "Juliette", "Amélie", "Margaux", "Élodie", "Clémence"},
+		lastNames: []string{"Martin", "Bernard", "Dubois", "Thomas", "Robert", "Richard", "Petit", "Durand", "Leroy", "Moreau",
+			"Simon", "Laurent", "Lefèvre", "Michel", "Garcia", "David", "Bertrand", "Roux", "Vincent", "Fournier"},
+	},
+}
+
+// lookupLocale resolves a locale such as de, de_DE or fr-FR to its name data,
+// English and the empty locale resolve to nil so gofakeit's data is used
+func lookupLocale(code string) (*locale, error) {
+	parts := strings.FieldsFunc(code, func(r rune) bool { return r == '_' || r == '-' || r == '.' })
+	if len(parts) == 0 {
+		return nil, nil
+	}
+	language := strings.ToLower(parts[0])
+	if language == "en" {
+		return nil, nil
+	}
+	loc, ok := locales[language]
+	if !ok {
+		supported := []string{"en"}
+		for name := range locales {
+			supported = append(supported, name)
+		}
+		sort.Strings(supported)
+		return nil, fmt.Errorf("unsupported locale %q, use one of %s", code, strings.Join(supported, ", "))
+	}
+	return loc, nil
+}
+
+// name returns a full name in the locale's language
+func (l *locale) name(faker *gofakeit.Faker) string {
+	return faker.RandomString(l.firstNames) + " " + faker.RandomString(l.lastNames)
+}
+
+// localizes reports whether col is a name column that the locale generates,
+// the columns the string generator would otherwise fill with English names
+func (l *locale) localizes(col types.Column) bool {
+	if l == nil || (col.Type != "" && col.Type != "string") || len(col.ValuesFiles) > 0 {
+		return false
+	}
+	if _, registered := registeredType(col.Type); registered {
+		return false
+	}
+	return strings.Contains(col.Name, "name")
+}
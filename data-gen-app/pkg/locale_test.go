@@ -0,0 +1,58 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLocale(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: customers
+  columns:
+  - name: full_name
+    type: string
+  - name: code
+    type: string
+    pattern: "C###"
+`)
+
+	records, err := Generate(manifestPath, 50, WithLocale("de_DE"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, record := range records["customers"] {
+		parts := strings.SplitN(record["full_name"].(string), " ", 2)
+		if assert.Len(t, parts, 2) {
+			assert.Contains(t, locales["de"].firstNames, parts[0])
+			assert.Contains(t, locales["de"].lastNames, parts[1])
+		}
+		assert.Regexp(t, "^C[0-9]{3}$", record["code"])
+	}
+
+	_, err = Generate(manifestPath, 1, WithLocale("xx"))
+	assert.EqualError(t, err, `unsupported locale "xx", use one of de, en, es, fr`)
+}
+
+func TestLookupLocale(t *testing.T) {
+	tests := []struct {
+		code string
+		want *locale
+	}{
+		{code: "", want: nil},
+		{code: "en_US", want: nil},
+		{code: "fr", want: locales["fr"]},
+		{code: "es-ES", want: locales["es"]},
+		{code: "DE_de.UTF-8", want: locales["de"]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			loc, err := lookupLocale(tt.code)
+			assert.NoError(t, err)
+			assert.Same(t, tt.want, loc)
+		})
+	}
+}
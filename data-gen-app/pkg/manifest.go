@@ -0,0 +1,32 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sujanks/data-gen-app/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// WriteManifest writes schema to path as a YAML manifest, refusing to overwrite an existing file
+func WriteManifest(path string, schema *types.Schema) error {
+	data, err := yaml.Marshal(schema)
+	if err != nil {
+		return fmt.Errorf("failed to serialize manifest: %v", err)
+	}
+	return writeNewFile(path, data)
+}
+
+// writeNewFile creates path with data, failing if the file already exists
+func writeNewFile(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/sink"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+func TestWriteManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inferred.yaml")
+	schema := &types.Schema{
+		Tables: []types.Table{{
+			Name: "users",
+			Columns: []types.Column{
+				{Name: "id", Type: "int", Mandatory: true, Range: types.Range{Min: 1, Max: 100}},
+				{Name: "tier", Type: "string", Value: []string{"gold", "silver"}},
+			},
+		}},
+	}
+
+	assert.NoError(t, WriteManifest(path, schema))
+	loaded, err := LoadSchema(path)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.Tables[0].Columns[1], loaded.Tables[0].Columns[1])
+	assert.Equal(t, "int", loaded.Tables[0].Columns[0].Type)
+
+	assert.Error(t, WriteManifest(path, schema))
+}
+
+func TestLoadSchemaFrom(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "tiers.txt"), []byte("gold\nsilver\n"), 0644))
+
+	schema, err := LoadSchemaFrom(strings.NewReader(`
+tables:
+- name: customers
+  columns:
+  - name: id
+    pattern: "C####"
+    parent: true
+  - name: tier
+    values_file:
+    - path: tiers.txt
+- name: orders
+  depends_on: customers
+  columns:
+  - name: customer_id
+    foreign: customers.id
+`), dir)
+	assert.NoError(t, err)
+	assert.Len(t, schema.Tables, 2)
+	// Values files are resolved relative to the directory given
+	assert.Equal(t, []string{"gold", "silver"}, schema.Tables[0].Columns[1].ValuesFiles[0].Values)
+
+	memorySink := sink.NewMemorySink()
+	assert.NoError(t, GenerateSchema(memorySink, 5, schema))
+	assert.Len(t, memorySink.Records("customers"), 5)
+	assert.Len(t, memorySink.Records("orders"), 5)
+
+	_, err = LoadSchemaFrom(strings.NewReader("tables: [oops"), dir)
+	assert.Error(t, err)
+	assert.Error(t, ValidateFrom(strings.NewReader("tables: [oops"), dir))
+}
@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+const (
+	maskLast4 = "last4"
+	maskEmail = "email"
+	maskFixed = "fixed"
+	// defaultMaskChar replaces masked characters when the mask sets no char
+	defaultMaskChar = "*"
+)
+
+// applyMasks masks the string values of masked columns, other values are left alone
+func applyMasks(columns []types.Column, tableData map[string]interface{}) {
+	for _, col := range columns {
+		if col.Mask.Strategy == "" {
+			continue
+		}
+		if value, ok := tableData[col.Name].(string); ok {
+			tableData[col.Name] = maskValue(col.Mask, value)
+		}
+	}
+}
+
+// applyPadding renders the integer values of columns with a width as zero-padded
+// strings, 42 with width 7 becomes 0000042. Wider numbers are kept whole.
+func applyPadding(columns []types.Column, tableData map[string]interface{}) {
+	for _, col := range columns {
+		if col.Width <= 0 {
+			continue
+		}
+		switch value := tableData[col.Name].(type) {
+		case int, int64:
+			tableData[col.Name] = fmt.Sprintf("%0*d", col.Width, value)
+		}
+	}
+}
+
+// maskValue applies the mask's strategy to value:
+//
+//	last4  4111-1111-1111-1234 -> ****-****-****-1234, separators are kept
+//	email  john.doe@example.com -> j*******@example.com
+//	fixed  anything -> the mask's value
+func maskValue(mask types.Mask, value string) string {
+	char := mask.Char
+	if char == "" {
+		char = defaultMaskChar
+	}
+
+	switch mask.Strategy {
+	case maskLast4:
+		// Count letters and digits from the end, masking those before the last four
+		runes := []rune(value)
+		kept := 0
+		masked := make([]string, len(runes))
+		for i := len(runes) - 1; i >= 0; i-- {
+			r := runes[i]
+			switch {
+			case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+				masked[i] = string(r)
+			case kept < 4:
+				masked[i] = string(r)
+				kept++
+			default:
+				masked[i] = char
+			}
+		}
+		return strings.Join(masked, "")
+	case maskEmail:
+		local, domain, found := strings.Cut(value, "@")
+		if !found {
+			return strings.Repeat(char, len([]rune(value)))
+		}
+		runes := []rune(local)
+		if len(runes) <= 1 {
+			return strings.Repeat(char, len(runes)) + "@" + domain
+		}
+		return string(runes[0]) + strings.Repeat(char, len(runes)-1) + "@" + domain
+	case maskFixed:
+		return mask.Value
+	default:
+		return value
+	}
+}
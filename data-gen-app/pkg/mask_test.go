@@ -0,0 +1,129 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+func TestMaskValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		mask  types.Mask
+		value string
+		want  string
+	}{
+		{
+			name:  "Last four of a card number",
+			mask:  types.Mask{Strategy: "last4"},
+			value: "4111-1111-1111-1234",
+			want:  "****-****-****-1234",
+		},
+		{
+			name:  "Last four with custom char",
+			mask:  types.Mask{Strategy: "last4", Char: "X"},
+			value: "AB123456",
+			want:  "XXXX3456",
+		},
+		{
+			name:  "Last four of a short value",
+			mask:  types.Mask{Strategy: "last4"},
+			value: "123",
+			want:  "123",
+		},
+		{
+			name:  "Email local part",
+			mask:  types.Mask{Strategy: "email"},
+			value: "john.doe@example.com",
+			want:  "j*******@example.com",
+		},
+		{
+			name:  "Email with one character local part",
+			mask:  types.Mask{Strategy: "email"},
+			value: "j@example.com",
+			want:  "*@example.com",
+		},
+		{
+			name:  "Not an email",
+			mask:  types.Mask{Strategy: "email", Char: "#"},
+			value: "nobody",
+			want:  "######",
+		},
+		{
+			name:  "Fixed",
+			mask:  types.Mask{Strategy: "fixed", Value: "REDACTED"},
+			value: "123-45-6789",
+			want:  "REDACTED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, maskValue(tt.mask, tt.value))
+		})
+	}
+}
+
+func TestMaskColumns(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: payments
+  columns:
+  - name: card_number
+    pattern: "4111-####-####-####"
+    mask:
+      strategy: last4
+  - name: email
+    pattern: "user####@example.com"
+    mask:
+      strategy: email
+`)
+
+	stream, err := GenerateStream(context.Background(), 10, manifestPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for record := range stream.Records() {
+		assert.Regexp(t, `^\*{4}-\*{4}-\*{4}-\d{4}$`, record.Data["card_number"])
+		assert.Regexp(t, `^u\*{7}@example\.com$`, record.Data["email"])
+	}
+}
+
+func TestPaddedColumns(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: accounts
+  columns:
+  - name: account_number
+    type: int
+    width: 7
+    range:
+      min: 1
+      max: 99999
+  - name: balance
+    type: int
+    range:
+      min: 1
+      max: 99
+`)
+
+	stream, err := GenerateStream(context.Background(), 100, manifestPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for record := range stream.Records() {
+		number, ok := record.Data["account_number"].(string)
+		if assert.True(t, ok) {
+			assert.Len(t, number, 7)
+			assert.Regexp(t, `^00[0-9]{5}$`, number)
+		}
+		assert.IsType(t, 0, record.Data["balance"])
+	}
+
+	tableData := map[string]interface{}{"wide": 123456789, "negative": -42}
+	applyPadding([]types.Column{{Name: "wide", Type: "int", Width: 4}, {Name: "negative", Type: "int", Width: 5}}, tableData)
+	assert.Equal(t, "123456789", tableData["wide"])
+	assert.Equal(t, "-0042", tableData["negative"])
+}
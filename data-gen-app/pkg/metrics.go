@@ -0,0 +1,41 @@
+package pkg
+
+import "time"
+
+// Metrics collects how long the records of each table took, by table
+type Metrics map[string]*TableMetrics
+
+// TableMetrics times the records generated for a table during a run
+type TableMetrics struct {
+	Records int // Records generated
+	// Duration is the time spent generating the records and handing them to the
+	// sink, so a slow sink shows up here as well as slow generators
+	Duration time.Duration
+}
+
+// WithMetrics records per-table record counts and durations into metrics
+func WithMetrics(metrics Metrics) Option {
+	return func(o *options) {
+		o.metrics = metrics
+	}
+}
+
+// RecordsPerSecond is the table's throughput, zero before any time was measured
+func (m *TableMetrics) RecordsPerSecond() float64 {
+	if m.Duration <= 0 {
+		return 0
+	}
+	return float64(m.Records) / m.Duration.Seconds()
+}
+
+// observe counts a record of table whose generation began at started
+func (m Metrics) observe(table string, started time.Time) {
+	if m == nil {
+		return
+	}
+	if m[table] == nil {
+		m[table] = &TableMetrics{}
+	}
+	m[table].Records++
+	m[table].Duration += time.Since(started)
+}
@@ -0,0 +1,44 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMetrics(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: customers
+  columns:
+  - name: id
+    pattern: "C####"
+    parent: true
+- name: orders
+  depends_on: customers
+  count: 30
+  columns:
+  - name: customer_id
+    foreign: "customers.id"
+  - name: code
+    pattern: "???-####"
+`)
+
+	metrics := Metrics{}
+	err := GenerateData(&MockDataSink{}, 20, manifestPath, WithMetrics(metrics))
+	assert.NoError(t, err)
+
+	assert.Len(t, metrics, 2)
+	assert.Equal(t, 20, metrics["customers"].Records)
+	assert.Equal(t, 30, metrics["orders"].Records)
+	for table, m := range metrics {
+		assert.Positive(t, m.Duration, table)
+		assert.Positive(t, m.RecordsPerSecond(), table)
+	}
+}
+
+func TestRecordsPerSecond(t *testing.T) {
+	assert.Equal(t, 500.0, (&TableMetrics{Records: 1000, Duration: 2 * time.Second}).RecordsPerSecond())
+	assert.Equal(t, 0.0, (&TableMetrics{Records: 1000}).RecordsPerSecond())
+}
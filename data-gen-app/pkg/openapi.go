@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/sujanks/data-gen-app/pkg/sink"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// OpenAPIExample is an Example Object of an OpenAPI spec holding a generated record
+type OpenAPIExample struct {
+	Summary string                 `json:"summary"`
+	Value   map[string]interface{} `json:"value"`
+}
+
+// OpenAPIExamples generates n records per table from the manifest and returns
+// them as OpenAPI examples named <table>_1, <table>_2, ... The records are
+// generated together, so foreign keys reference the parents' examples.
+func OpenAPIExamples(schema *types.Schema, n int, opts ...Option) (map[string]OpenAPIExample, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("examples per table must be at least 1, got %d", n)
+	}
+
+	// Every table gets n examples, whatever count the manifest sets
+	counts := make(map[string]int, len(schema.Tables))
+	for _, table := range schema.Tables {
+		counts[table.Name] = n
+	}
+	memorySink := sink.NewMemorySink()
+	if err := GenerateSchema(memorySink, n, schema, append(opts, WithTableCounts(counts))...); err != nil {
+		return nil, err
+	}
+
+	examples := make(map[string]OpenAPIExample)
+	for _, table := range schema.Tables {
+		for i, record := range memorySink.Records(table.Name) {
+			examples[fmt.Sprintf("%s_%d", table.Name, i+1)] = OpenAPIExample{
+				Summary: fmt.Sprintf("Example %s record %d", table.Name, i+1),
+				Value:   record,
+			}
+		}
+	}
+	return examples, nil
+}
+
+// WriteOpenAPIExamples writes examples to w as the components section of an
+// OpenAPI spec, in JSON which YAML specs accept as well. Operations reference
+// them with $ref: '#/components/examples/<table>_1'.
+func WriteOpenAPIExamples(w io.Writer, examples map[string]OpenAPIExample) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]interface{}{
+		"components": map[string]interface{}{"examples": examples},
+	})
+}
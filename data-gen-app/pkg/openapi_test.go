@@ -0,0 +1,85 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPIExamples(t *testing.T) {
+	schema := loadTempManifest(t, `
+tables:
+- name: customers
+  count: 100
+  columns:
+  - name: id
+    pattern: "C####"
+    parent: true
+  - name: name
+    type: sentence
+  - name: age
+    type: int
+  - name: joined_at
+    type: timestamp
+  - name: tags
+    type: list
+    list_config:
+      element_type: string
+      min: 1
+      max: 2
+- name: orders
+  depends_on: customers
+  columns:
+  - name: id
+    type: uuid
+  - name: customer_id
+    foreign: customers.id
+  - name: status
+    value: [NEW]
+  - name: note
+    type: sentence
+    when: "fields.status == 'NEW'"
+  - name: tracking_code
+    pattern: "TR####"
+    when: "fields.status == 'SHIPPED'"
+`)
+
+	examples, err := OpenAPIExamples(schema, 2)
+	assert.NoError(t, err)
+	assert.Len(t, examples, 4)
+
+	nonNull := map[string][]string{
+		"customers": {"id", "name", "age", "joined_at", "tags"},
+		"orders":    {"id", "customer_id", "status", "note"},
+	}
+	for _, name := range []string{"customers_1", "customers_2", "orders_1", "orders_2"} {
+		example, ok := examples[name]
+		assert.True(t, ok, name)
+		table := name[:len(name)-2]
+		for _, column := range nonNull[table] {
+			assert.NotNil(t, example.Value[column], "%s.%s", name, column)
+		}
+	}
+	// Conditional columns follow the generated status
+	assert.NotContains(t, examples["orders_1"].Value, "tracking_code")
+	assert.Equal(t, "Example orders record 1", examples["orders_1"].Summary)
+
+	// Written as the components section of a spec, timestamps as strings
+	var encoded bytes.Buffer
+	assert.NoError(t, WriteOpenAPIExamples(&encoded, examples))
+	var spec struct {
+		Components struct {
+			Examples map[string]struct {
+				Value map[string]interface{} `json:"value"`
+			} `json:"examples"`
+		} `json:"components"`
+	}
+	assert.NoError(t, json.Unmarshal(encoded.Bytes(), &spec))
+	assert.Len(t, spec.Components.Examples, 4)
+	assert.IsType(t, "", spec.Components.Examples["customers_1"].Value["joined_at"])
+
+	_, err = OpenAPIExamples(schema, 0)
+	assert.Error(t, err)
+}
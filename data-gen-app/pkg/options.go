@@ -0,0 +1,148 @@
+package pkg
+
+import (
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// Option configures optional behaviour of a generation run
+type Option func(*options)
+
+type options struct {
+	progress      func(Progress)
+	progressEvery int
+	parentKeys    map[string][]string
+	tableCounts   map[string]int
+	stats         Stats
+	faker         *gofakeit.Faker
+	locale        string
+	strict        bool
+	duration      time.Duration
+	maxParentKeys int
+	metrics       Metrics
+}
+
+// Progress reports how far a generation run has got
+type Progress struct {
+	Records int            // Records generated so far, across all tables
+	Tables  map[string]int // Records generated so far per table
+	Elapsed time.Duration  // Time since generation started
+}
+
+// WithProgress calls fn after every `every` generated records
+func WithProgress(every int, fn func(Progress)) Option {
+	return func(o *options) {
+		o.progressEvery = every
+		o.progress = fn
+	}
+}
+
+// WithParentKeys seeds foreign key lookups with keys, for example loaded with
+// LoadParentKeys from a previous run, and records the run's parent keys into it
+func WithParentKeys(keys map[string][]string) Option {
+	return func(o *options) {
+		o.parentKeys = keys
+	}
+}
+
+// WithTableCounts sets the number of records for individual tables, taking
+// precedence over both the table's count in the manifest and the run's count
+func WithTableCounts(counts map[string]int) Option {
+	return func(o *options) {
+		o.tableCounts = counts
+	}
+}
+
+// WithFaker draws every generated value from faker instead of gofakeit's global
+// faker, so a run seeded with gofakeit.New(seed) is reproducible and does not
+// share random state with concurrent runs
+func WithFaker(faker *gofakeit.Faker) Option {
+	return func(o *options) {
+		o.faker = faker
+	}
+}
+
+// WithLocale generates name columns in the language of locale, such as de,
+// fr_FR or es-ES. English, the default, uses gofakeit's names.
+func WithLocale(locale string) Option {
+	return func(o *options) {
+		o.locale = locale
+	}
+}
+
+// WithStrict makes expression errors fail the run: conditions and rule values
+// that do not compile or run, and conditions that are not boolean. Without it
+// they are logged and generation carries on.
+func WithStrict(strict bool) Option {
+	return func(o *options) {
+		o.strict = strict
+	}
+}
+
+// WithDuration keeps generating until d has elapsed instead of stopping after
+// count records per table. Tables are generated in rounds of their usual count,
+// in dependency order, so later rounds may reference parents of earlier ones.
+// Generation stops mid-round once the time is up.
+func WithDuration(d time.Duration) Option {
+	return func(o *options) {
+		o.duration = d
+	}
+}
+
+// WithMaxParentKeys caps the keys kept per parent column at n, a uniform random
+// sample of every key generated, so runs with huge parent tables use bounded
+// memory. Foreign columns then reference parents of the sample only. Zero, the
+// default, keeps every key. Unique values and the parent records read through
+// parent. are not capped, runs with a duration bound them separately.
+func WithMaxParentKeys(n int) Option {
+	return func(o *options) {
+		o.maxParentKeys = n
+	}
+}
+
+// tableCount resolves how many records to generate for table
+func (o *options) tableCount(table types.Table, count int) int {
+	if n, ok := o.tableCounts[table.Name]; ok {
+		return n
+	}
+	if table.Count > 0 {
+		return table.Count
+	}
+	return count
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// trackProgress wraps emit so the progress callback fires as records are generated
+func (o *options) trackProgress(emit func(Record) error) func(Record) error {
+	if o.progress == nil || o.progressEvery <= 0 {
+		return emit
+	}
+
+	start := time.Now()
+	total := 0
+	tables := make(map[string]int)
+	return func(record Record) error {
+		if err := emit(record); err != nil {
+			return err
+		}
+		total++
+		tables[record.Table]++
+		if total%o.progressEvery == 0 {
+			snapshot := make(map[string]int, len(tables))
+			for table, n := range tables {
+				snapshot[table] = n
+			}
+			o.progress(Progress{Records: total, Tables: snapshot, Elapsed: time.Since(start)})
+		}
+		return nil
+	}
+}
@@ -0,0 +1,215 @@
+package pkg
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithProgress(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: customers
+  priority: 2
+  columns:
+  - name: id
+    pattern: "C####"
+    parent: true
+- name: orders
+  priority: 1
+  depends_on: customers
+  columns:
+  - name: customer_id
+    foreign: "customers.id"
+`)
+
+	var reports []Progress
+	err := GenerateData(&MockDataSink{}, 25, manifestPath, WithProgress(10, func(p Progress) {
+		reports = append(reports, p)
+	}))
+	assert.NoError(t, err)
+
+	// 50 records in total report after the 10th, 20th, 30th, 40th and 50th
+	assert.Len(t, reports, 5)
+	for i, report := range reports {
+		assert.Equal(t, (i+1)*10, report.Records)
+	}
+	assert.Equal(t, map[string]int{"customers": 20}, reports[1].Tables)
+	assert.Equal(t, map[string]int{"customers": 25, "orders": 5}, reports[2].Tables)
+	assert.Equal(t, map[string]int{"customers": 25, "orders": 25}, reports[4].Tables)
+	assert.True(t, reports[4].Elapsed >= reports[0].Elapsed)
+}
+
+func TestWithProgressDisabled(t *testing.T) {
+	o := newOptions([]Option{WithProgress(0, func(Progress) { t.Fatal("progress reported") })})
+	emit := o.trackProgress(func(Record) error { return nil })
+	assert.NoError(t, emit(Record{Table: "users"}))
+}
+
+func TestTableCounts(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: users
+  count: 3
+  columns:
+  - name: id
+    pattern: "U####"
+- name: orders
+  count: 7
+  columns:
+  - name: id
+    pattern: "O####"
+- name: events
+  columns:
+  - name: id
+    pattern: "E####"
+`)
+
+	countTables := func(opts ...Option) map[string]int {
+		counts := make(map[string]int)
+		stream, err := GenerateStream(context.Background(), 5, manifestPath, opts...)
+		assert.NoError(t, err)
+		for record := range stream.Records() {
+			counts[record.Table]++
+		}
+		return counts
+	}
+
+	// Manifest counts win over the run's count
+	assert.Equal(t, map[string]int{"users": 3, "orders": 7, "events": 5}, countTables())
+
+	// Overrides win over both
+	overrides := WithTableCounts(map[string]int{"orders": 1, "events": 2})
+	assert.Equal(t, map[string]int{"users": 3, "orders": 1, "events": 2}, countTables(overrides))
+}
+
+func TestWithFaker(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: customers
+  columns:
+  - name: id
+    pattern: "C####"
+    parent: true
+  - name: name
+    type: string
+  - name: score
+    type: int
+    range:
+      min: 1
+      max: 1000
+  - name: tags
+    type: list
+    list_config:
+      max_elements: 3
+      values: ["new", "vip", "churned"]
+  - name: profile
+    type: json
+    json_config:
+    - name: age
+      type: int
+- name: orders
+  depends_on: customers
+  columns:
+  - name: customer_id
+    foreign: customers.id
+  - name: status
+    value: ["NEW", "PAID", "SHIPPED"]
+`)
+
+	generate := func(seed uint64) map[string][]map[string]interface{} {
+		rows, err := Generate(manifestPath, 20, WithFaker(gofakeit.New(seed)))
+		assert.NoError(t, err)
+		return rows
+	}
+
+	// Seeded runs are reproducible, even when they run concurrently
+	var wg sync.WaitGroup
+	concurrent := make([]map[string][]map[string]interface{}, 4)
+	for i := range concurrent {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			concurrent[i] = generate(uint64(i%2 + 1))
+		}(i)
+	}
+	wg.Wait()
+
+	first, second := generate(1), generate(2)
+	assert.Equal(t, first, concurrent[0])
+	assert.Equal(t, first, concurrent[2])
+	assert.Equal(t, second, concurrent[1])
+	assert.Equal(t, second, concurrent[3])
+
+	// Differently seeded runs diverge
+	assert.NotEqual(t, first, second)
+}
+
+func TestWithStrict(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		wantErr string
+	}{
+		{
+			name: "Malformed condition",
+			rule: `
+  rules:
+  - when: "fields.score >"
+    then:
+      tier: gold`,
+			wantErr: "table scores: error evaluating rule condition: unexpected token EOF",
+		},
+		{
+			name: "Non-boolean condition",
+			rule: `
+  rules:
+  - when: "fields.score + 1"
+    then:
+      tier: gold`,
+			wantErr: "table scores: error evaluating rule condition: expression did not evaluate to a boolean",
+		},
+		{
+			name: "Malformed value",
+			rule: `
+  rules:
+  - when: "true"
+    then:
+      tier: "${upper(}"`,
+			wantErr: "table scores: error evaluating rule value for tier",
+		},
+		{
+			name: "Malformed column condition",
+			rule: `
+  - name: bonus
+    type: int
+    when: "fields.score =="`,
+			wantErr: "error evaluating condition for table scores column bonus",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifestPath := writeTempManifest(t, `
+tables:
+- name: scores
+  columns:
+  - name: score
+    type: int
+    range:
+      min: 0
+      max: 100`+tt.rule+"\n")
+
+			// Lenient runs log the error and keep generating
+			records, err := Generate(manifestPath, 3)
+			assert.NoError(t, err)
+			assert.Len(t, records["scores"], 3)
+
+			_, err = Generate(manifestPath, 3, WithStrict(true))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
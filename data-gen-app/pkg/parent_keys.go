@@ -0,0 +1,78 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+// LoadParentKeys reads parent keys saved by SaveParentKeys, keyed by table.column
+func LoadParentKeys(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read parent keys: %v", err)
+	}
+
+	keys := make(map[string][]string)
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse parent keys %s: %v", path, err)
+	}
+	return keys, nil
+}
+
+// SaveParentKeys writes parent keys to path as JSON so a later run can reference them
+func SaveParentKeys(path string, keys map[string][]string) error {
+	data, err := json.Marshal(keys)
+	if err != nil {
+		return fmt.Errorf("failed to serialize parent keys: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write parent keys: %v", err)
+	}
+	return nil
+}
+
+// keySampler retains the parent keys foreign columns pick from. With a size it
+// keeps a reservoir sample: every key offered so far has the same chance of
+// being among the at most size keys retained.
+type keySampler struct {
+	faker *gofakeit.Faker
+	size  int // Keys retained per column, zero for all of them
+}
+
+// add offers key to keys after seen earlier keys were offered, returning the retained keys
+func (s keySampler) add(keys []string, seen int, key string) []string {
+	if s.size <= 0 || len(keys) < s.size {
+		return append(keys, key)
+	}
+	if i := s.faker.IntN(seen + 1); i < s.size {
+		keys[i] = key
+	}
+	return keys
+}
+
+// trim samples the columns of keys holding more than size keys, such as keys
+// loaded from a previous run, down to size and returns how many each held
+func (s keySampler) trim(keys map[string][]string) map[string]int {
+	seen := make(map[string]int, len(keys))
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		seen[name] = len(keys[name])
+		names = append(names, name)
+	}
+	if s.size <= 0 {
+		return seen
+	}
+	// Sorted so that seeded runs sample the same keys
+	sort.Strings(names)
+	for _, name := range names {
+		if len(keys[name]) > s.size {
+			s.faker.ShuffleStrings(keys[name])
+			keys[name] = keys[name][:s.size]
+		}
+	}
+	return seen
+}
@@ -0,0 +1,175 @@
+package pkg
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParentKeysRoundTrip(t *testing.T) {
+	customersManifest := writeTempManifest(t, `
+tables:
+- name: customers
+  columns:
+  - name: id
+    pattern: "C######"
+    parent: true
+`)
+	ordersManifest := writeTempManifest(t, `
+external_keys:
+- customers.id
+tables:
+- name: orders
+  columns:
+  - name: id
+    pattern: "O######"
+  - name: customer_id
+    foreign: customers.id
+`)
+	keysPath := filepath.Join(t.TempDir(), "keys.json")
+
+	// First run generates parents and saves their keys
+	keys := make(map[string][]string)
+	customers := &MockDataSink{}
+	assert.NoError(t, GenerateData(customers, 10, customersManifest, WithParentKeys(keys)))
+	assert.NoError(t, SaveParentKeys(keysPath, keys))
+
+	// Second run generates children referencing the loaded keys
+	loaded, err := LoadParentKeys(keysPath)
+	assert.NoError(t, err)
+	assert.Equal(t, keys, loaded)
+
+	customerIDs := make(map[interface{}]bool)
+	for _, customer := range customers.Records {
+		customerIDs[customer["id"]] = true
+	}
+
+	orders := &MockDataSink{}
+	assert.NoError(t, GenerateData(orders, 20, ordersManifest, WithParentKeys(loaded)))
+	assert.Len(t, orders.Records, 20)
+	for _, order := range orders.Records {
+		assert.True(t, customerIDs[order["customer_id"]], "order references unknown customer %v", order["customer_id"])
+	}
+}
+
+func TestExternalKeysNotLoaded(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+external_keys:
+- customers.id
+tables:
+- name: orders
+  columns:
+  - name: customer_id
+    foreign: customers.id
+`)
+
+	err := GenerateData(&MockDataSink{}, 1, manifestPath)
+	assert.EqualError(t, err, "no parent keys loaded for external key customers.id")
+}
+
+func TestLoadParentKeysMissingFile(t *testing.T) {
+	_, err := LoadParentKeys(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestMaxParentKeys(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: customers
+  count: 2000
+  columns:
+  - name: id
+    pattern: "C######"
+    parent: true
+    validation:
+      unique: true
+  - name: status
+    value: [active, closed]
+- name: orders
+  depends_on: customers
+  count: 500
+  columns:
+  - name: customer_id
+    foreign: customers.id
+  - name: active_customer_id
+    foreign: customers.id
+    foreign_filter: "parent.customers.status == 'active'"
+`)
+	keys := make(map[string][]string)
+	rows, err := Generate(manifestPath, 0, WithParentKeys(keys), WithMaxParentKeys(50), WithFaker(gofakeit.New(7)))
+	assert.NoError(t, err)
+	assert.Len(t, keys["customers.id"], 50)
+
+	customers := make(map[string]string)
+	for _, customer := range rows["customers"] {
+		customers[customer["id"].(string)] = customer["status"].(string)
+	}
+	retained := make(map[string]bool)
+	for _, key := range keys["customers.id"] {
+		assert.Contains(t, customers, key)
+		retained[key] = true
+	}
+
+	referenced, activeReferenced := make(map[string]bool), make(map[string]bool)
+	for _, order := range rows["orders"] {
+		id := order["customer_id"].(string)
+		assert.True(t, retained[id], "order references %s outside the sample", id)
+		referenced[id] = true
+
+		active := order["active_customer_id"].(string)
+		assert.Equal(t, "active", customers[active])
+		activeReferenced[active] = true
+	}
+	assert.LessOrEqual(t, len(referenced), 50)
+	assert.LessOrEqual(t, len(activeReferenced), 50)
+	// The sample spans the whole table rather than its first rows
+	assert.NotEqual(t, rows["customers"][49]["id"], keys["customers.id"][49])
+}
+
+func TestKeySampler(t *testing.T) {
+	t.Run("Every key is equally likely to be retained", func(t *testing.T) {
+		sampler := keySampler{faker: gofakeit.New(1), size: 10}
+		retained := make([]int, 100)
+		for trial := 0; trial < 2000; trial++ {
+			var keys []string
+			for i := 0; i < 100; i++ {
+				keys = sampler.add(keys, i, fmt.Sprint(i))
+			}
+			assert.Len(t, keys, 10)
+			for _, key := range keys {
+				var i int
+				fmt.Sscan(key, &i)
+				retained[i]++
+			}
+		}
+		// Each key is kept in a tenth of the trials, about 200 times
+		first, last := 0, 0
+		for i := 0; i < 50; i++ {
+			first += retained[i]
+			last += retained[50+i]
+		}
+		assert.InDelta(t, 10000, first, 500)
+		assert.InDelta(t, 10000, last, 500)
+	})
+
+	t.Run("Loaded keys are trimmed", func(t *testing.T) {
+		keys := map[string][]string{"customers.id": {"a", "b", "c", "d", "e"}, "stores.id": {"s"}}
+		seen := keySampler{faker: gofakeit.New(1), size: 3}.trim(keys)
+		assert.Equal(t, map[string]int{"customers.id": 5, "stores.id": 1}, seen)
+		assert.Len(t, keys["customers.id"], 3)
+		assert.Subset(t, []string{"a", "b", "c", "d", "e"}, keys["customers.id"])
+		assert.Equal(t, []string{"s"}, keys["stores.id"])
+	})
+
+	t.Run("No size keeps every key", func(t *testing.T) {
+		var keys []string
+		sampler := keySampler{size: 0}
+		for i := 0; i < 100; i++ {
+			keys = sampler.add(keys, i, fmt.Sprint(i))
+		}
+		assert.Len(t, keys, 100)
+	})
+}
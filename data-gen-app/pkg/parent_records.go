@@ -0,0 +1,119 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// parentRecords keeps the records of parent tables by key, for the foreign keys
+// whose child rules read the parent row. Keys are table.column as in foreign.
+type parentRecords map[string]map[string]map[string]interface{}
+
+// newParentRecords tracks the parents referenced by tables whose conditions or
+// rules read parent, including tables with schema rules. Other parents are never
+// stored so only their keys are kept in memory.
+func newParentRecords(schema types.Schema) parentRecords {
+	ruled := make(map[string]bool)
+	for _, rule := range schema.Rules {
+		ruled[rule.Table] = true
+	}
+
+	records := make(parentRecords)
+	for _, table := range schema.Tables {
+		if !ruled[table.Name] && !readsParent(table) {
+			continue
+		}
+		for _, col := range table.Columns {
+			if col.Foreign != "" {
+				records[col.Foreign] = make(map[string]map[string]interface{})
+			}
+		}
+	}
+	return records
+}
+
+// readsParent reports whether a condition or rule of table mentions parent, a
+// false positive only costs the memory of keeping the parent records
+func readsParent(table types.Table) bool {
+	var expressions []string
+	addRules := func(rules []types.Rule) {
+		for _, rule := range rules {
+			expressions = append(expressions, rule.When)
+			for _, branch := range rule.Cases {
+				expressions = append(expressions, branch.When)
+				for _, value := range branch.Then {
+					expressions = append(expressions, value)
+				}
+			}
+			for _, values := range []map[string]string{rule.Then, rule.Otherwise} {
+				for _, value := range values {
+					expressions = append(expressions, value)
+				}
+			}
+		}
+	}
+	for _, col := range table.Columns {
+		expressions = append(expressions, col.When)
+		addRules(col.Rules)
+	}
+	addRules(table.Rules)
+
+	for _, expression := range expressions {
+		if strings.Contains(expression, "parent") {
+			return true
+		}
+	}
+	return false
+}
+
+// store remembers record under each of its parent keys that children look up
+func (p parentRecords) store(table types.Table, record map[string]interface{}) {
+	for _, col := range table.Columns {
+		keyName := fmt.Sprintf("%s.%s", table.Name, col.Name)
+		if byKey, tracked := p[keyName]; tracked && col.Parent {
+			byKey[fmt.Sprint(record[col.Name])] = record
+		}
+	}
+}
+
+// prune drops the stored records whose key is no longer among the retained parent keys
+func (p parentRecords) prune(parentKeyValues map[string][]string) {
+	for keyName, byKey := range p {
+		retained := make(map[string]bool, len(parentKeyValues[keyName]))
+		for _, key := range parentKeyValues[keyName] {
+			retained[key] = true
+		}
+		for key := range byKey {
+			if !retained[key] {
+				delete(byKey, key)
+			}
+		}
+	}
+}
+
+// lookup returns the parent records referenced by record's foreign keys, keyed
+// by parent table, or nil when there are none. The first foreign column wins when
+// several reference one table, and keys without a stored record, such as null or
+// external keys, are left out.
+func (p parentRecords) lookup(table types.Table, record map[string]interface{}) map[string]interface{} {
+	var parents map[string]interface{}
+	for _, col := range table.Columns {
+		byKey, tracked := p[col.Foreign]
+		if !tracked || record[col.Name] == nil {
+			continue
+		}
+		parentTable, _, _ := strings.Cut(col.Foreign, ".")
+		if _, found := parents[parentTable]; found {
+			continue
+		}
+		if parent, ok := byKey[fmt.Sprint(record[col.Name])]; ok {
+			if parents == nil {
+				parents = make(map[string]interface{})
+			}
+			parents[parentTable] = parent
+		}
+	}
+	return parents
+}
@@ -0,0 +1,93 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+func TestRulesReadParentRecords(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: members
+  columns:
+  - name: id
+    pattern: "M######"
+    parent: true
+    validation:
+      unique: true
+  - name: tier
+    value: ["gold", "silver", "basic"]
+- name: orders
+  depends_on: members
+  count: 100
+  columns:
+  - name: member_id
+    foreign: members.id
+  - name: discount
+    type: int
+    rules:
+    - cases:
+      - when: 'parent.members.tier == "gold"'
+        then:
+          discount: "20"
+      - when: 'parent.members.tier == "silver"'
+        then:
+          discount: "10"
+      otherwise:
+        discount: "0"
+  - name: perk
+    when: 'parent.members.tier == "gold"'
+    value: ["lounge"]
+  rules:
+  - when: "true"
+    then:
+      member_tier: "${parent.members.tier}"
+`)
+
+	records, err := Generate(manifestPath, 30, WithStrict(true))
+	if !assert.NoError(t, err) {
+		return
+	}
+	tiers := make(map[interface{}]string)
+	for _, member := range records["members"] {
+		tiers[member["id"]] = member["tier"].(string)
+	}
+
+	discounts := map[string]int{"gold": 20, "silver": 10, "basic": 0}
+	seen := make(map[string]bool)
+	for _, order := range records["orders"] {
+		tier := tiers[order["member_id"]]
+		seen[tier] = true
+		assert.Equal(t, discounts[tier], order["discount"], "discount for tier %s", tier)
+		assert.Equal(t, tier, order["member_tier"])
+		if tier == "gold" {
+			assert.Equal(t, "lounge", order["perk"])
+		} else {
+			assert.NotContains(t, order, "perk")
+		}
+	}
+	assert.Len(t, seen, 3, "every tier should be referenced by 100 orders")
+}
+
+func TestReadsParent(t *testing.T) {
+	assert.False(t, readsParent(types.Table{
+		Columns: []types.Column{{Name: "total", Rules: []types.Rule{{When: "fields.total > 10"}}}},
+	}))
+	assert.True(t, readsParent(types.Table{
+		Columns: []types.Column{{Name: "perk", When: `parent.members.tier == "gold"`}},
+	}))
+	assert.True(t, readsParent(types.Table{
+		Rules: []types.Rule{{Cases: []types.RuleCase{{When: "true", Then: map[string]string{"tier": "${parent.members.tier}"}}}}},
+	}))
+}
+
+func TestParentRecordsPrune(t *testing.T) {
+	records := parentRecords{"users.id": {
+		"U1": {"id": "U1"},
+		"U2": {"id": "U2"},
+	}}
+	records.prune(map[string][]string{"users.id": {"U2"}})
+	assert.Equal(t, parentRecords{"users.id": {"U2": {"id": "U2"}}}, records)
+}
@@ -0,0 +1,27 @@
+package pkg
+
+import (
+	"fmt"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+// Named phone formats, any other format is a pattern whose # become digits
+const (
+	phoneNational = "national" // (415) 555-0123, the default
+	phoneE164     = "e164"     // +14155550123
+)
+
+// phoneNumber generates a phone number in format. Named formats produce valid
+// North American numbers, whose area code and exchange never start with 0 or 1.
+func phoneNumber(faker *gofakeit.Faker, format string) string {
+	area, exchange, line := faker.IntRange(200, 999), faker.IntRange(200, 999), faker.IntRange(0, 9999)
+	switch format {
+	case "", phoneNational:
+		return fmt.Sprintf("(%d) %d-%04d", area, exchange, line)
+	case phoneE164:
+		return fmt.Sprintf("+1%d%d%04d", area, exchange, line)
+	default:
+		return fillPattern(faker, format, false)
+	}
+}
@@ -0,0 +1,32 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+func TestPhoneNumber(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{name: "Default", format: "", want: `^\([2-9][0-9]{2}\) [2-9][0-9]{2}-[0-9]{4}$`},
+		{name: "National", format: "national", want: `^\([2-9][0-9]{2}\) [2-9][0-9]{2}-[0-9]{4}$`},
+		{name: "E.164", format: "e164", want: `^\+1[2-9][0-9]{2}[2-9][0-9]{6}$`},
+		{name: "Pattern", format: "+1-###-###-####", want: `^\+1-[0-9]{3}-[0-9]{3}-[0-9]{4}$`},
+		{name: "Pattern with leading digit", format: "07### ######", want: `^07[0-9]{3} [0-9]{6}$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			col := types.Column{Name: "phone", Type: "phone", Format: tt.format}
+			for i := 0; i < 100; i++ {
+				assert.Regexp(t, tt.want, generateColumnValue(col, gofakeit.GlobalFaker))
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package pkg
+
+import (
+	"sync"
+
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// TypeFactory creates the generator for a column of a registered type
+type TypeFactory func(col types.Column) types.ValueGenerator
+
+var (
+	registryMu    sync.RWMutex
+	typeFactories = make(map[string]TypeFactory)
+)
+
+// RegisterType adds a custom column type, or replaces a built-in one, so manifests can
+// use `type: name` without changing this package. It is usually called from an init
+// function, before any manifest is loaded.
+func RegisterType(name string, factory TypeFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	typeFactories[name] = factory
+}
+
+// registeredType returns the factory registered for a column type
+func registeredType(name string) (TypeFactory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	factory, ok := typeFactories[name]
+	return factory, ok
+}
@@ -0,0 +1,59 @@
+package pkg
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// ssnGenerator produces sequential SSN-shaped values following the column's range min
+type ssnGenerator struct {
+	next int
+}
+
+func (g *ssnGenerator) Generate() interface{} {
+	g.next++
+	return fmt.Sprintf("900-00-%04d", g.next)
+}
+
+func TestRegisterType(t *testing.T) {
+	RegisterType("ssn", func(col types.Column) types.ValueGenerator {
+		start, _ := col.Range.Min.(int)
+		return &ssnGenerator{next: start}
+	})
+	t.Cleanup(func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+		delete(typeFactories, "ssn")
+	})
+
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: people
+  columns:
+  - name: ssn
+    type: ssn
+    range:
+      min: 41
+`)
+
+	rows, err := Generate(manifestPath, 3)
+	assert.NoError(t, err)
+	if assert.Len(t, rows["people"], 3) {
+		// A new generator is created per value, so each starts again from min
+		assert.Equal(t, "900-00-0042", rows["people"][0]["ssn"])
+	}
+
+	// Unregistered types are still rejected when the manifest is loaded
+	manifestPath = writeTempManifest(t, `
+tables:
+- name: cars
+  columns:
+  - name: vin
+    type: vin
+`)
+	_, err = Generate(manifestPath, 1)
+	assert.ErrorContains(t, err, `table cars column vin has type "vin"`)
+}
@@ -0,0 +1,11 @@
+package pkg
+
+import _ "embed"
+
+//go:embed scaffold.yaml
+var scaffoldManifest []byte
+
+// WriteScaffold writes a commented example manifest to path, refusing to overwrite an existing file
+func WriteScaffold(path string) error {
+	return writeNewFile(path, scaffoldManifest)
+}
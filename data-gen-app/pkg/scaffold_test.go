@@ -0,0 +1,25 @@
+package pkg
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteScaffold(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "example.yaml")
+	assert.NoError(t, WriteScaffold(path))
+
+	tables, err := LoadSchema(path)
+	assert.NoError(t, err)
+	assert.Len(t, tables.Tables, 2)
+	assert.NoError(t, Validate(path))
+
+	sink := &MockDataSink{}
+	assert.NoError(t, GenerateData(sink, 5, path))
+	assert.Len(t, sink.Records, 10)
+
+	// An existing manifest is never overwritten
+	assert.Error(t, WriteScaffold(path))
+}
@@ -0,0 +1,14 @@
+package pkg
+
+import (
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// schemaRulesByTable groups the schema's cross-table rules by the table they apply to
+func schemaRulesByTable(schema types.Schema) map[string][]types.Rule {
+	rules := make(map[string][]types.Rule)
+	for _, rule := range schema.Rules {
+		rules[rule.Table] = append(rules[rule.Table], rule.Rule)
+	}
+	return rules
+}
@@ -0,0 +1,88 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+func TestSchemaRulesClampToParent(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+rules:
+- table: orders
+  when: "fields.total > parent.customers.credit_limit"
+  then:
+    total: "${parent.customers.credit_limit}"
+    capped: "true"
+tables:
+- name: customers
+  columns:
+  - name: id
+    pattern: "C######"
+    parent: true
+    validation:
+      unique: true
+  - name: credit_limit
+    type: float
+    range:
+      min: 100
+      max: 500
+- name: orders
+  depends_on: customers
+  count: 200
+  columns:
+  - name: id
+    pattern: "O######"
+  - name: customer_id
+    foreign: customers.id
+  - name: total
+    type: float
+    range:
+      min: 0
+      max: 1000
+`)
+
+	records, err := Generate(manifestPath, 10)
+	if !assert.NoError(t, err) {
+		return
+	}
+	limits := make(map[interface{}]float64)
+	for _, customer := range records["customers"] {
+		limits[customer["id"]] = customer["credit_limit"].(float64)
+	}
+
+	capped := 0
+	for _, order := range records["orders"] {
+		limit, ok := limits[order["customer_id"]]
+		if !assert.True(t, ok, "order references unknown customer %v", order["customer_id"]) {
+			continue
+		}
+		assert.LessOrEqual(t, order["total"].(float64), limit)
+		if order["capped"] == true {
+			assert.Equal(t, limit, order["total"])
+			capped++
+		}
+	}
+	// Totals range up to 1000 against limits of at most 500, so many orders are capped
+	assert.Greater(t, capped, 0)
+}
+
+func TestValidateSchemaRules(t *testing.T) {
+	schema := &types.Schema{
+		Tables: []types.Table{{Name: "orders"}},
+		Rules: []types.SchemaRule{
+			{Table: "orders", Rule: types.Rule{When: "true"}},
+			{Rule: types.Rule{When: "true"}},
+			{Table: "invoices", Rule: types.Rule{When: "true"}},
+		},
+	}
+	assert.EqualError(t, validateSchemaRules(schema), "schema rule validation failed: "+
+		"schema rule 1 has no table, schema rule 2 applies to unknown table invoices")
+
+	schema.Rules = schema.Rules[:1]
+	assert.NoError(t, validateSchemaRules(schema))
+
+	schema.Rules[0].When = "fields.total >"
+	assert.ErrorContains(t, validateRuleExpressions(schema), `schema rule 0: when "fields.total >"`)
+}
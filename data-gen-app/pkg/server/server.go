@@ -0,0 +1,244 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/sujanks/data-gen-app/pkg"
+	"github.com/sujanks/data-gen-app/pkg/sink"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+const (
+	// maxManifestSize bounds the manifest accepted in a request body
+	maxManifestSize = 1 << 20
+	formatJSON      = "json"
+	formatCSV       = "csv"
+)
+
+// maxRecords bounds the records parameter and maxRows the rows generated across
+// every table, including tables whose count the manifest sets
+const (
+	maxRecords = 100000
+	maxRows    = 1000000
+)
+
+// NewHandler returns the HTTP handler serving POST /generate. The request body is a
+// manifest and the query parameters are records, format (json or csv) and, for csv
+// output of a manifest with several tables, table.
+func NewHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/generate", handleGenerate)
+	return mux
+}
+
+func handleGenerate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	count, err := strconv.Atoi(query.Get("records"))
+	if err != nil || count < 0 {
+		http.Error(w, fmt.Sprintf("invalid records parameter %q", query.Get("records")), http.StatusBadRequest)
+		return
+	}
+	if count > maxRecords {
+		http.Error(w, fmt.Sprintf("records parameter %d exceeds the limit of %d", count, maxRecords), http.StatusBadRequest)
+		return
+	}
+	format := query.Get("format")
+	if format == "" {
+		format = formatJSON
+	}
+	if format != formatJSON && format != formatCSV {
+		http.Error(w, fmt.Sprintf("unsupported format %q, use json or csv", format), http.StatusBadRequest)
+		return
+	}
+
+	// The manifest has no directory on disk, so it can't read values files
+	schema, err := pkg.LoadSchemaFrom(http.MaxBytesReader(w, r.Body, maxManifestSize), "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows := 0
+	for _, estimate := range pkg.Estimate(schema, count) {
+		if rows += estimate.Rows; rows > maxRows {
+			http.Error(w, fmt.Sprintf("manifest would generate more than %d rows", maxRows), http.StatusBadRequest)
+			return
+		}
+	}
+
+	out := &responseSink{w: w, schema: schema, written: make(map[string]bool)}
+	if format == formatCSV {
+		if out.table, err = csvTable(schema, query.Get("table")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Rows are written as they are generated, so a failure can only change the
+	// status until the first one has been sent
+	err = pkg.GenerateSchema(out, count, schema)
+	switch {
+	case err != nil && !out.started:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	case err != nil:
+		log.Printf("generation failed after the response started: %v", err)
+	default:
+		writeError(out.finish())
+	}
+}
+
+// csvTable picks the table to render as CSV, which may be omitted for single table manifests
+func csvTable(schema *types.Schema, name string) (*types.Table, error) {
+	if name == "" {
+		if len(schema.Tables) != 1 {
+			return nil, fmt.Errorf("csv format needs a table parameter when the manifest has %d tables", len(schema.Tables))
+		}
+		return &schema.Tables[0], nil
+	}
+	for i := range schema.Tables {
+		if schema.Tables[i].Name == name {
+			return &schema.Tables[i], nil
+		}
+	}
+	return nil, fmt.Errorf("table not found: %s", name)
+}
+
+// responseSink writes records to the response as they are generated: a JSON
+// object mapping each table to its rows or, when table is set, that table's CSV
+// rows. Generation emits each table's records together, in dependency order.
+type responseSink struct {
+	w       http.ResponseWriter
+	schema  *types.Schema
+	table   *types.Table    // Table written as CSV, nil for JSON
+	csv     *sink.CSVWriter // Set once the CSV header has been written
+	started bool            // The response has been started
+	current string          // Table whose JSON array is open
+	written map[string]bool // Tables whose JSON array has been written
+	rows    int             // Rows in the open JSON array
+}
+
+// start sets the content type and writes the beginning of the response
+func (s *responseSink) start() error {
+	s.started = true
+	if s.table != nil {
+		s.w.Header().Set("Content-Type", "text/csv")
+		var err error
+		s.csv, err = sink.NewCSVWriter(s.w, s.table)
+		return err
+	}
+	s.w.Header().Set("Content-Type", "application/json")
+	_, err := io.WriteString(s.w, "{")
+	return err
+}
+
+// InsertRecord writes one record, opening the JSON array of its table when it is the first
+func (s *responseSink) InsertRecord(tableName string, data map[string]interface{}) error {
+	if !s.started {
+		if err := s.start(); err != nil {
+			return err
+		}
+	}
+	if s.table != nil {
+		if tableName != s.table.Name {
+			return nil
+		}
+		return s.csv.Write(data)
+	}
+
+	if tableName != s.current {
+		if err := s.openArray(tableName); err != nil {
+			return err
+		}
+	}
+	if s.rows > 0 {
+		if _, err := io.WriteString(s.w, ","); err != nil {
+			return err
+		}
+	}
+	row, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	s.rows++
+	_, err = s.w.Write(row)
+	return err
+}
+
+// openArray closes the open JSON array, if any, and opens the array of tableName
+func (s *responseSink) openArray(tableName string) error {
+	if s.current != "" {
+		if _, err := io.WriteString(s.w, "],"); err != nil {
+			return err
+		}
+	}
+	name, err := json.Marshal(tableName)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.w, "%s:[", name); err != nil {
+		return err
+	}
+	s.current = tableName
+	s.written[tableName] = true
+	s.rows = 0
+	return nil
+}
+
+// Flush writes buffered CSV rows to the response
+func (s *responseSink) Flush() error {
+	if s.csv != nil {
+		return s.csv.Flush()
+	}
+	return nil
+}
+
+// Close flushes buffered rows. The response is only completed by finish, so a
+// failed run is never mistaken for a complete one.
+func (s *responseSink) Close() error {
+	return s.Flush()
+}
+
+// finish completes the response once every record has been written, adding an
+// empty array for every table without rows
+func (s *responseSink) finish() error {
+	if !s.started {
+		if err := s.start(); err != nil {
+			return err
+		}
+	}
+	if s.table != nil {
+		return s.csv.Flush()
+	}
+	for _, table := range s.schema.Tables {
+		if !s.written[table.Name] {
+			if err := s.openArray(table.Name); err != nil {
+				return err
+			}
+		}
+	}
+	if s.current != "" {
+		if _, err := io.WriteString(s.w, "]"); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(s.w, "}")
+	return err
+}
+
+// writeError logs failures once the response has started, when the status can no longer change
+func writeError(err error) {
+	if err != nil {
+		log.Printf("failed to write response: %v", err)
+	}
+}
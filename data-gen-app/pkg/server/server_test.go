@@ -0,0 +1,186 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testManifest = `
+tables:
+- name: customers
+  priority: 2
+  columns:
+  - name: id
+    pattern: "C####"
+    parent: true
+  - name: tier
+    value: ["gold", "silver"]
+- name: orders
+  priority: 1
+  depends_on: customers
+  columns:
+  - name: id
+    pattern: "O####"
+  - name: customer_id
+    foreign: "customers.id"
+`
+
+func TestHandleGenerate(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		query      string
+		manifest   string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "Wrong method",
+			method:     http.MethodGet,
+			query:      "records=1",
+			manifest:   testManifest,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "Invalid record count",
+			method:     http.MethodPost,
+			query:      "records=many",
+			manifest:   testManifest,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `invalid records parameter "many"`,
+		},
+		{
+			name:       "Too many records",
+			method:     http.MethodPost,
+			query:      "records=100001",
+			manifest:   testManifest,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "records parameter 100001 exceeds the limit of 100000",
+		},
+		{
+			name:       "Too many rows across tables",
+			method:     http.MethodPost,
+			query:      "records=10",
+			manifest:   "tables:\n- name: users\n  count: 2000000\n  columns:\n  - name: id\n    pattern: \"U###\"\n",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "manifest would generate more than 1000000 rows",
+		},
+		{
+			name:       "Unsupported format",
+			method:     http.MethodPost,
+			query:      "records=1&format=xml",
+			manifest:   testManifest,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `unsupported format "xml"`,
+		},
+		{
+			name:       "Invalid manifest",
+			method:     http.MethodPost,
+			query:      "records=1",
+			manifest:   "tables:\n- name: users\n  columns:\n  - name: id\n    type: money\n",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `table users column id has type "money"`,
+		},
+		{
+			name:       "Values file",
+			method:     http.MethodPost,
+			query:      "records=1",
+			manifest:   "tables:\n- name: users\n  columns:\n  - name: name\n    values_file:\n    - path: /etc/passwd\n",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "values file /etc/passwd is not allowed in a manifest without a directory",
+		},
+		{
+			name:       "CSV without table for several tables",
+			method:     http.MethodPost,
+			query:      "records=1&format=csv",
+			manifest:   testManifest,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "csv format needs a table parameter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/generate?"+tt.query, strings.NewReader(tt.manifest))
+			rec := httptest.NewRecorder()
+
+			NewHandler().ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestHandleGenerateJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/generate?records=3", strings.NewReader(testManifest))
+	rec := httptest.NewRecorder()
+
+	NewHandler().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var body map[string][]map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Len(t, body["customers"], 3)
+	assert.Len(t, body["orders"], 3)
+
+	customerIDs := make(map[interface{}]bool)
+	for _, customer := range body["customers"] {
+		customerIDs[customer["id"]] = true
+	}
+	for _, order := range body["orders"] {
+		assert.True(t, customerIDs[order["customer_id"]], "order references unknown customer")
+	}
+}
+
+func TestHandleGenerateCSV(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/generate?records=2&format=csv&table=customers", strings.NewReader(testManifest))
+	rec := httptest.NewRecorder()
+
+	NewHandler().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, []string{"id", "tier"}, rows[0])
+	for _, row := range rows[1:] {
+		assert.Regexp(t, `^C\d{4}$`, row[0])
+		assert.Contains(t, []string{"gold", "silver"}, row[1])
+	}
+}
+
+func TestHandleGenerateJSONEmpty(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/generate?records=0", strings.NewReader(testManifest))
+	rec := httptest.NewRecorder()
+
+	NewHandler().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"customers": [], "orders": []}`, rec.Body.String())
+}
+
+func TestHandleGenerateFailure(t *testing.T) {
+	// The unique column runs out of values after two rows have been streamed
+	manifest := "tables:\n- name: flags\n  columns:\n  - name: flag\n    value: [\"on\", \"off\"]\n    validation:\n      unique: true\n"
+	req := httptest.NewRequest(http.MethodPost, "/generate?records=5", strings.NewReader(manifest))
+	rec := httptest.NewRecorder()
+
+	NewHandler().ServeHTTP(rec, req)
+
+	// The response is left incomplete rather than passing for a full one
+	var body map[string]interface{}
+	assert.Error(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.True(t, strings.HasPrefix(rec.Body.String(), `{"flags":[{`))
+}
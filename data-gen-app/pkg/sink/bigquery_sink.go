@@ -0,0 +1,247 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// BigQuerySink implements DataSink interface by writing newline-delimited JSON
+// ready for BigQuery load jobs: values are converted to the JSON type of their
+// column, timestamps are written as RFC 3339, and a <table>.schema.json file
+// describing every table's columns is written next to the data on Close
+type BigQuerySink struct {
+	lines     *JSONLSink
+	outputDir string
+	schema    *types.Schema
+	tableMap  map[string]*types.Table // Cache for quick table lookup
+}
+
+// bigQueryField is a column of a BigQuery table schema, in the JSON format
+// accepted by bq load --schema
+type bigQueryField struct {
+	Name   string          `json:"name"`
+	Type   string          `json:"type"`
+	Mode   string          `json:"mode"`
+	Fields []bigQueryField `json:"fields,omitempty"`
+}
+
+// NewBigQuerySink creates a sink writing <table>.jsonl files and their schemas
+// to outputDir, compressing the data files when compression is set
+func NewBigQuerySink(outputDir string, compression string, schema *types.Schema) (*BigQuerySink, error) {
+	lines, err := NewJSONLSink(outputDir, compression)
+	if err != nil {
+		return nil, err
+	}
+
+	tableMap := make(map[string]*types.Table)
+	for i := range schema.Tables {
+		table := &schema.Tables[i]
+		tableMap[table.Name] = table
+	}
+
+	return &BigQuerySink{
+		lines:     lines,
+		outputDir: outputDir,
+		schema:    schema,
+		tableMap:  tableMap,
+	}, nil
+}
+
+// InsertRecord converts the record to BigQuery JSON types and appends it to the table's file
+func (s *BigQuerySink) InsertRecord(tableName string, record map[string]interface{}) error {
+	table, exists := s.tableMap[tableName]
+	if !exists {
+		return fmt.Errorf("table not found: %s", tableName)
+	}
+
+	row := make(map[string]interface{}, len(record))
+	for name, value := range record {
+		row[name] = value
+	}
+	for _, col := range table.Columns {
+		if value, ok := row[col.Name]; ok {
+			row[col.Name] = bigQueryValue(col, value)
+		}
+	}
+	return s.lines.InsertRecord(tableName, row)
+}
+
+// Flush writes any buffered lines to their files
+func (s *BigQuerySink) Flush() error {
+	return s.lines.Flush()
+}
+
+// Close closes the data files and writes a schema file for every table
+func (s *BigQuerySink) Close() error {
+	err := s.lines.Close()
+	for _, table := range s.schema.Tables {
+		if schemaErr := s.writeSchema(table); schemaErr != nil && err == nil {
+			err = schemaErr
+		}
+	}
+	return err
+}
+
+// writeSchema writes the BigQuery schema of table to <table>.schema.json
+func (s *BigQuerySink) writeSchema(table types.Table) error {
+	fields := make([]bigQueryField, 0, len(table.Columns))
+	for _, col := range table.Columns {
+		fields = append(fields, bigQuerySchemaField(col))
+	}
+	payload, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize schema for %s: %v", table.Name, err)
+	}
+	path := filepath.Join(s.outputDir, table.Name+".schema.json")
+	if err := os.WriteFile(path, append(payload, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write schema for %s: %v", table.Name, err)
+	}
+	return nil
+}
+
+// bigQuerySchemaField describes col as a BigQuery column, every column is
+// nullable except collections, which are repeated
+func bigQuerySchemaField(col types.Column) bigQueryField {
+	field := bigQueryField{Name: col.Name, Type: "STRING", Mode: "NULLABLE"}
+	switch col.Type {
+	case "int", "bigint", "long":
+		if col.Width == 0 {
+			field.Type = "INTEGER"
+		}
+	case "float", "decimal":
+		field.Type = "FLOAT"
+	case "bool":
+		field.Type = "BOOLEAN"
+	case "timestamp":
+		field.Type = "TIMESTAMP"
+	case "date":
+		if col.Format == "" || col.Format == "2006-01-02" {
+			field.Type = "DATE"
+		}
+	case "json", "map", "tuple":
+		field.Type = "JSON"
+	case "udt":
+		field.Type = "RECORD"
+		field.Fields = bigQuerySchemaFields(col.UDTConfig.Fields)
+	case "objects":
+		field.Type = "RECORD"
+		field.Mode = "REPEATED"
+		field.Fields = bigQuerySchemaFields(col.ObjectsConfig.Fields)
+	case "list", "set":
+		element := collectionElement(col)
+		element.Name = col.Name
+		field = bigQuerySchemaField(element)
+		field.Mode = "REPEATED"
+	}
+	return field
+}
+
+func bigQuerySchemaFields(columns []types.Column) []bigQueryField {
+	fields := make([]bigQueryField, 0, len(columns))
+	for _, col := range columns {
+		fields = append(fields, bigQuerySchemaField(col))
+	}
+	return fields
+}
+
+// collectionElement returns the column describing the elements of a list or set
+func collectionElement(col types.Column) types.Column {
+	element, elementType, values, pattern := col.ListConfig.Element, col.ListConfig.ElementType, col.ListConfig.Values, col.ListConfig.Pattern
+	if col.Type == "set" {
+		element, elementType, values, pattern = col.SetConfig.Element, col.SetConfig.ElementType, col.SetConfig.Values, col.SetConfig.Pattern
+	}
+	switch {
+	case len(values) > 0 || pattern != "":
+		return types.Column{Type: "string"}
+	case element != nil:
+		return *element
+	case elementType != "":
+		return types.Column{Type: elementType}
+	default:
+		return types.Column{Type: col.ElementType}
+	}
+}
+
+// bigQueryValue converts value to the JSON type BigQuery expects for col, values
+// that do not convert are left as they are
+func bigQueryValue(col types.Column, value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case map[string]interface{}:
+		if col.Type == "udt" {
+			return bigQueryFields(col.UDTConfig.Fields, v)
+		}
+		return v
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, element := range v {
+			switch col.Type {
+			case "objects":
+				if fields, ok := element.(map[string]interface{}); ok {
+					element = bigQueryFields(col.ObjectsConfig.Fields, fields)
+				}
+			case "list", "set":
+				element = bigQueryValue(collectionElement(col), element)
+			}
+			converted[i] = element
+		}
+		return converted
+	}
+
+	switch col.Type {
+	case "int", "bigint", "long":
+		if s, ok := value.(string); ok && col.Width == 0 {
+			if n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64); err == nil {
+				return n
+			}
+		}
+	case "float", "decimal":
+		switch v := value.(type) {
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f
+			}
+		case int:
+			return float64(v)
+		}
+	case "bool":
+		if s, ok := value.(string); ok {
+			if b, err := strconv.ParseBool(s); err == nil {
+				return b
+			}
+		}
+	case "timestamp":
+		// Epoch timestamps are loaded as RFC 3339 like every other timestamp
+		if epoch, ok := value.(int64); ok {
+			if col.Format == "unix_ms" {
+				return time.UnixMilli(epoch).UTC().Format(time.RFC3339Nano)
+			}
+			return time.Unix(epoch, 0).UTC().Format(time.RFC3339Nano)
+		}
+	}
+	return value
+}
+
+// bigQueryFields converts the fields of a nested record
+func bigQueryFields(columns []types.Column, fields map[string]interface{}) map[string]interface{} {
+	converted := make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		converted[name] = value
+	}
+	for _, col := range columns {
+		if value, ok := converted[col.Name]; ok {
+			converted[col.Name] = bigQueryValue(col, value)
+		}
+	}
+	return converted
+}
@@ -0,0 +1,93 @@
+package sink
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+func TestBigQuerySink(t *testing.T) {
+	tempDir := t.TempDir()
+	schema := &types.Schema{
+		Tables: []types.Table{
+			{
+				Name: "orders",
+				Columns: []types.Column{
+					{Name: "id", Type: "int"},
+					{Name: "account", Type: "int", Width: 6},
+					{Name: "amount", Type: "decimal"},
+					{Name: "paid", Type: "bool"},
+					{Name: "created_at", Type: "timestamp"},
+					{Name: "shipped_at", Type: "timestamp", Format: "unix"},
+					{Name: "due", Type: "date"},
+					{Name: "scores", Type: "list", ListConfig: types.ListConfig{ElementType: "int"}},
+					{Name: "address", Type: "udt", UDTConfig: types.UDTConfig{Fields: []types.Column{
+						{Name: "city", Type: "string"},
+						{Name: "zip", Type: "int"},
+					}}},
+				},
+			},
+			{Name: "refunds", Columns: []types.Column{{Name: "id", Type: "uuid"}}},
+		},
+	}
+
+	sink, err := NewBigQuerySink(tempDir, "", schema)
+	if !assert.NoError(t, err) {
+		return
+	}
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.FixedZone("CET", 3600))
+	assert.NoError(t, sink.InsertRecord("orders", map[string]interface{}{
+		"id":         "42",
+		"account":    "000042",
+		"amount":     12,
+		"paid":       "true",
+		"created_at": created,
+		"shipped_at": int64(1709287200),
+		"due":        "2024-03-15",
+		"scores":     []interface{}{"7", 9},
+		"address":    map[string]interface{}{"city": "Springfield", "zip": "12345"},
+	}))
+	assert.NoError(t, sink.Close())
+
+	content, err := os.ReadFile(filepath.Join(tempDir, "orders.jsonl"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"id": 42,
+		"account": "000042",
+		"amount": 12.0,
+		"paid": true,
+		"created_at": "2024-03-01T08:30:00Z",
+		"shipped_at": "2024-03-01T10:00:00Z",
+		"due": "2024-03-15",
+		"scores": [7, 9],
+		"address": {"city": "Springfield", "zip": 12345}
+	}`, string(content))
+
+	content, err = os.ReadFile(filepath.Join(tempDir, "orders.schema.json"))
+	assert.NoError(t, err)
+	var fields []bigQueryField
+	assert.NoError(t, json.Unmarshal(content, &fields))
+	assert.Equal(t, []bigQueryField{
+		{Name: "id", Type: "INTEGER", Mode: "NULLABLE"},
+		{Name: "account", Type: "STRING", Mode: "NULLABLE"},
+		{Name: "amount", Type: "FLOAT", Mode: "NULLABLE"},
+		{Name: "paid", Type: "BOOLEAN", Mode: "NULLABLE"},
+		{Name: "created_at", Type: "TIMESTAMP", Mode: "NULLABLE"},
+		{Name: "shipped_at", Type: "TIMESTAMP", Mode: "NULLABLE"},
+		{Name: "due", Type: "DATE", Mode: "NULLABLE"},
+		{Name: "scores", Type: "INTEGER", Mode: "REPEATED"},
+		{Name: "address", Type: "RECORD", Mode: "NULLABLE", Fields: []bigQueryField{
+			{Name: "city", Type: "STRING", Mode: "NULLABLE"},
+			{Name: "zip", Type: "INTEGER", Mode: "NULLABLE"},
+		}},
+	}, fields)
+
+	// Tables without records still get a schema
+	_, err = os.Stat(filepath.Join(tempDir, "refunds.schema.json"))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,140 @@
+package sink
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gocql/gocql"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// cqlSession is the subset of a Cassandra session used by the sink
+type cqlSession interface {
+	Exec(stmt string, values ...interface{}) error
+	Close()
+}
+
+// gocqlSession adapts *gocql.Session to cqlSession
+type gocqlSession struct {
+	session *gocql.Session
+}
+
+func (s *gocqlSession) Exec(stmt string, values ...interface{}) error {
+	return s.session.Query(stmt, values...).Exec()
+}
+
+func (s *gocqlSession) Close() {
+	s.session.Close()
+}
+
+// CassandraSink implements DataSink interface by executing CQL inserts,
+// tables and user-defined types are expected to exist already
+type CassandraSink struct {
+	session  cqlSession
+	keyspace string
+	mu       sync.Mutex
+	tableMap map[string]*types.Table // Cache for quick table lookup
+}
+
+// NewCassandraSink connects to the cluster at hosts and inserts into keyspace
+func NewCassandraSink(hosts []string, keyspace string, schema *types.Schema) (*CassandraSink, error) {
+	cluster := gocql.NewCluster(hosts...)
+	cluster.Keyspace = keyspace
+	session, err := cluster.CreateSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to cassandra: %v", err)
+	}
+	return newCassandraSink(&gocqlSession{session: session}, keyspace, schema), nil
+}
+
+func newCassandraSink(session cqlSession, keyspace string, schema *types.Schema) *CassandraSink {
+	// Create a map for quick table lookup
+	tableMap := make(map[string]*types.Table)
+	for i := range schema.Tables {
+		table := &schema.Tables[i]
+		tableMap[table.Name] = table
+	}
+
+	return &CassandraSink{
+		session:  session,
+		keyspace: keyspace,
+		tableMap: tableMap,
+	}
+}
+
+// InsertRecord executes an INSERT for the record's non-nil columns
+func (s *CassandraSink) InsertRecord(tableName string, record map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	table, exists := s.tableMap[tableName]
+	if !exists {
+		return fmt.Errorf("table not found: %s", tableName)
+	}
+
+	// Skip nil values so Cassandra does not write tombstones for them. gocql
+	// marshals string-keyed maps to CQL maps, and slices to sets, lists and
+	// tuples, so those values bind without conversion.
+	var names []string
+	var placeholders []string
+	var args []interface{}
+	for _, col := range table.Columns {
+		value, ok := record[col.Name]
+		if !ok || value == nil {
+			continue
+		}
+		names = append(names, col.Name)
+		placeholders = append(placeholders, "?")
+		args = append(args, cqlValue(col, value))
+	}
+
+	stmt := fmt.Sprintf("INSERT INTO %s.%s (%s) VALUES (%s)",
+		s.keyspace, tableName, strings.Join(names, ", "), strings.Join(placeholders, ", "))
+	if err := s.session.Exec(stmt, args...); err != nil {
+		return fmt.Errorf("failed to insert into %s: %v", tableName, err)
+	}
+	return nil
+}
+
+// Flush is a no-op since every record is executed as it is inserted
+func (s *CassandraSink) Flush() error {
+	return nil
+}
+
+// Close closes the session
+func (s *CassandraSink) Close() error {
+	s.session.Close()
+	return nil
+}
+
+// cqlValue wraps UDT values, and the elements of objects columns stored as
+// list<frozen<udt>>, so they bind through gocql.UDTMarshaler
+func cqlValue(col types.Column, value interface{}) interface{} {
+	if fields, ok := value.(map[string]interface{}); ok && col.Type == "udt" {
+		return &udtValue{fields: fields}
+	}
+	if objects, ok := value.([]interface{}); ok && col.Type == "objects" {
+		elements := make([]interface{}, len(objects))
+		for i, object := range objects {
+			if fields, ok := object.(map[string]interface{}); ok {
+				elements[i] = &udtValue{fields: fields}
+			} else {
+				elements[i] = object
+			}
+		}
+		return elements
+	}
+	return value
+}
+
+// udtValue marshals a generated UDT field by field, in whatever order the
+// server-side type declares them
+type udtValue struct {
+	fields map[string]interface{}
+}
+
+// MarshalUDT implements gocql.UDTMarshaler
+func (u *udtValue) MarshalUDT(name string, info gocql.TypeInfo) ([]byte, error) {
+	return gocql.Marshal(info, u.fields[name])
+}
@@ -0,0 +1,134 @@
+package sink
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// mockCQLSession records executed statements and their bound values
+type mockCQLSession struct {
+	statements []string
+	args       [][]interface{}
+	closed     bool
+}
+
+func (m *mockCQLSession) Exec(stmt string, values ...interface{}) error {
+	m.statements = append(m.statements, stmt)
+	m.args = append(m.args, values)
+	return nil
+}
+
+func (m *mockCQLSession) Close() {
+	m.closed = true
+}
+
+func TestCassandraSink(t *testing.T) {
+	schema := &types.Schema{
+		Tables: []types.Table{
+			{
+				Name: "users",
+				Columns: []types.Column{
+					{Name: "id", Type: "uuid"},
+					{Name: "user_preferences", Type: "map"},
+					{Name: "tags", Type: "set"},
+					{Name: "nickname", Type: "string"},
+				},
+			},
+		},
+	}
+
+	session := &mockCQLSession{}
+	sink := newCassandraSink(session, "app", schema)
+
+	preferences := map[string]interface{}{"theme": "dark", "language": "en"}
+	tags := []interface{}{"urgent", "normal"}
+	err := sink.InsertRecord("users", map[string]interface{}{
+		"id":               "5b1a9d5e-1c2f-4d4e-9f3a-2b6c7d8e9f00",
+		"user_preferences": preferences,
+		"tags":             tags,
+		"nickname":         nil,
+	})
+	assert.NoError(t, err)
+
+	// Nil columns are left out of the statement
+	assert.Equal(t, []string{"INSERT INTO app.users (id, user_preferences, tags) VALUES (?, ?, ?)"}, session.statements)
+	assert.Equal(t, []interface{}{"5b1a9d5e-1c2f-4d4e-9f3a-2b6c7d8e9f00", preferences, tags}, session.args[0])
+
+	assert.Error(t, sink.InsertRecord("missing", map[string]interface{}{}))
+	assert.NoError(t, sink.Close())
+	assert.True(t, session.closed)
+}
+
+func TestCassandraSinkUDT(t *testing.T) {
+	schema := &types.Schema{
+		Tables: []types.Table{
+			{
+				Name: "users",
+				Columns: []types.Column{
+					{Name: "id", Type: "string"},
+					{Name: "address", Type: "udt", UDTConfig: types.UDTConfig{Name: "address_type"}},
+				},
+			},
+		},
+	}
+
+	session := &mockCQLSession{}
+	sink := newCassandraSink(session, "app", schema)
+
+	address := map[string]interface{}{"street": "Main St", "city": "Springfield"}
+	err := sink.InsertRecord("users", map[string]interface{}{
+		"id":      "USER001",
+		"address": address,
+	})
+	assert.NoError(t, err)
+
+	// UDT values are bound through gocql's UDT marshaler
+	marshaler, ok := session.args[0][1].(gocql.UDTMarshaler)
+	assert.True(t, ok)
+
+	info := gocql.NewNativeType(4, gocql.TypeText, "")
+	data, err := marshaler.MarshalUDT("city", info)
+	assert.NoError(t, err)
+	assert.Equal(t, "Springfield", string(data))
+
+	data, err = marshaler.MarshalUDT("zip", info)
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+}
+
+func TestCassandraSinkObjects(t *testing.T) {
+	schema := &types.Schema{
+		Tables: []types.Table{
+			{
+				Name: "orders",
+				Columns: []types.Column{
+					{Name: "id", Type: "string"},
+					{Name: "items", Type: "objects"},
+				},
+			},
+		},
+	}
+
+	session := &mockCQLSession{}
+	sink := newCassandraSink(session, "app", schema)
+
+	err := sink.InsertRecord("orders", map[string]interface{}{
+		"id":    "ORDER001",
+		"items": []interface{}{map[string]interface{}{"sku": "SKU1"}, map[string]interface{}{"sku": "SKU2"}},
+	})
+	assert.NoError(t, err)
+
+	// Each sub-record binds as a UDT element of a list<frozen<udt>>
+	items, ok := session.args[0][1].([]interface{})
+	assert.True(t, ok)
+	assert.Len(t, items, 2)
+	marshaler, ok := items[1].(gocql.UDTMarshaler)
+	assert.True(t, ok)
+
+	data, err := marshaler.MarshalUDT("sku", gocql.NewNativeType(4, gocql.TypeText, ""))
+	assert.NoError(t, err)
+	assert.Equal(t, "SKU2", string(data))
+}
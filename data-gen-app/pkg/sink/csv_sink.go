@@ -1,29 +1,174 @@
 package sink
 
 import (
+	"compress/gzip"
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/sujanks/data-gen-app/pkg/types"
 )
 
-// CSVSink implements DataSink interface for CSV file output
+// csvTimeFormat renders timestamps of columns without a declared format
+const csvTimeFormat = "2006-01-02 15:04:05"
+
+// Float notations accepted as the format of float and decimal columns
+const (
+	FloatFixed      = "fixed"      // 1234.50
+	FloatScientific = "scientific" // 1.23e+03
+)
+
+// floatFormat renders float values as fixed or scientific notation with scale
+// digits after the decimal point, a scale of -1 uses as many as the value needs
+type floatFormat struct {
+	scale      int
+	scientific bool
+}
+
+// defaultFloatFormat writes floats with two decimals
+var defaultFloatFormat = floatFormat{scale: 2}
+
+// forColumn returns the format for col, its scale and format override the sink's
+func (f floatFormat) forColumn(col types.Column) floatFormat {
+	if col.Scale != nil {
+		f.scale = *col.Scale
+	}
+	switch col.Format {
+	case FloatFixed:
+		f.scientific = false
+	case FloatScientific:
+		f.scientific = true
+	}
+	return f
+}
+
+func (f floatFormat) format(v float64) string {
+	if f.scientific {
+		return strconv.FormatFloat(v, 'e', f.scale, 64)
+	}
+	return strconv.FormatFloat(v, 'f', f.scale, 64)
+}
+
+// CSVSink implements DataSink interface for CSV file output. It is safe for
+// concurrent use: records of the same or different tables may be inserted from
+// many goroutines, files are opened and their headers written exactly once, and
+// records inserted after Close are rejected rather than reopening the files.
 type CSVSink struct {
-	outputDir string
-	writers   map[string]*csv.Writer
-	files     map[string]*os.File
-	headers   map[string][]string
-	mu        sync.Mutex
-	schema    *types.Schema
-	tableMap  map[string]*types.Table // Cache for quick table lookup
+	outputDir   string
+	compression string
+	appendMode  bool // Add rows to existing files instead of truncating them
+	// declaredOrder renders UDT/JSON sub-objects in config order instead of sorted
+	declaredOrder bool
+	// maxRowsPerFile rolls each table over to a new numbered file, zero keeps a single file
+	maxRowsPerFile int
+	floats         floatFormat // Default format of float columns
+	nullToken      string      // Written for nil values
+	writers        map[string]*csvWriter
+	files          map[string]*outputFile
+	headers        map[string][]string
+	rowCounts      map[string]int // Rows written to each table's current file
+	fileCounts     map[string]int // Files opened per table
+	mu             sync.Mutex
+	closed         bool // Set by Close, later inserts fail
+	schema         *types.Schema
+	tableMap       map[string]*types.Table // Cache for quick table lookup
+	// quoteColumns, by name or table.column, and with quoteStrings every string value are always quoted
+	quoteColumns map[string]bool
+	quoteStrings bool
+	// flushEvery flushes a table's file after every flushEvery rows, zero only when flushed or closed
+	flushEvery int
+}
+
+// CSVOption configures optional CSVSink behaviour
+type CSVOption func(*CSVSink)
+
+// WithCompression compresses every output file, e.g. with CompressionGzip
+func WithCompression(compression string) CSVOption {
+	return func(s *CSVSink) {
+		s.compression = compression
+	}
+}
+
+// WithDeclaredFieldOrder renders UDT and JSON sub-objects in the order their
+// fields are declared in the manifest rather than sorted alphabetically
+func WithDeclaredFieldOrder() CSVOption {
+	return func(s *CSVSink) {
+		s.declaredOrder = true
+	}
+}
+
+// WithMaxRowsPerFile splits each table across users_001.csv, users_002.csv, ...
+// holding at most maxRows rows each, every file starting with the header
+func WithMaxRowsPerFile(maxRows int) CSVOption {
+	return func(s *CSVSink) {
+		s.maxRowsPerFile = maxRows
+	}
+}
+
+// WithAppend adds rows to existing files instead of overwriting them, writing the
+// header only to new or empty files. Split tables continue with the next file
+// number after the existing ones.
+func WithAppend() CSVOption {
+	return func(s *CSVSink) {
+		s.appendMode = true
+	}
+}
+
+// WithFloatFormat writes float and decimal columns with scale digits after the
+// decimal point, -1 for as many as each value needs, in scientific notation when
+// scientific is set. Columns override it with their own scale and format.
+func WithFloatFormat(scale int, scientific bool) CSVOption {
+	return func(s *CSVSink) {
+		s.floats = floatFormat{scale: scale, scientific: scientific}
+	}
+}
+
+// WithNullToken writes token for nil values instead of an empty field, such as
+// \N for Postgres COPY, so empty strings and nulls can be told apart
+func WithNullToken(token string) CSVOption {
+	return func(s *CSVSink) {
+		s.nullToken = token
+	}
+}
+
+// WithQuotedColumns always quotes the named columns, given as column or
+// table.column, so values such as the zip code 007 keep their leading zeros in
+// importers that would read them as numbers. Null values stay unquoted.
+func WithQuotedColumns(columns ...string) CSVOption {
+	return func(s *CSVSink) {
+		if s.quoteColumns == nil {
+			s.quoteColumns = make(map[string]bool)
+		}
+		for _, column := range columns {
+			s.quoteColumns[column] = true
+		}
+	}
+}
+
+// WithQuotedStrings always quotes string values, in every column
+func WithQuotedStrings() CSVOption {
+	return func(s *CSVSink) {
+		s.quoteStrings = true
+	}
+}
+
+// WithFlushEvery flushes each table's buffered rows to disk after every n rows,
+// so wide rows across many tables are not all held in memory until Close
+func WithFlushEvery(n int) CSVOption {
+	return func(s *CSVSink) {
+		s.flushEvery = n
+	}
 }
 
 // NewCSVSink creates a new CSV sink that writes to the specified directory
-func NewCSVSink(outputDir string, schema *types.Schema) (*CSVSink, error) {
+func NewCSVSink(outputDir string, schema *types.Schema, opts ...CSVOption) (*CSVSink, error) {
 	// Create output directory if it doesn't exist
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create output directory: %v", err)
@@ -36,14 +181,36 @@ func NewCSVSink(outputDir string, schema *types.Schema) (*CSVSink, error) {
 		tableMap[table.Name] = table
 	}
 
-	return &CSVSink{
-		outputDir: outputDir,
-		writers:   make(map[string]*csv.Writer),
-		files:     make(map[string]*os.File),
-		headers:   make(map[string][]string),
-		schema:    schema,
-		tableMap:  tableMap,
-	}, nil
+	sink := &CSVSink{
+		outputDir:  outputDir,
+		writers:    make(map[string]*csvWriter),
+		files:      make(map[string]*outputFile),
+		headers:    make(map[string][]string),
+		rowCounts:  make(map[string]int),
+		fileCounts: make(map[string]int),
+		floats:     defaultFloatFormat,
+		schema:     schema,
+		tableMap:   tableMap,
+	}
+	for _, opt := range opts {
+		opt(sink)
+	}
+	if err := validateCompression(sink.compression); err != nil {
+		return nil, err
+	}
+	if sink.floats.scale < -1 {
+		return nil, fmt.Errorf("float scale must be -1 or more, got %d", sink.floats.scale)
+	}
+	if sink.maxRowsPerFile < 0 {
+		return nil, fmt.Errorf("max rows per file must not be negative, got %d", sink.maxRowsPerFile)
+	}
+	if sink.flushEvery < 0 {
+		return nil, fmt.Errorf("flush interval must not be negative, got %d", sink.flushEvery)
+	}
+	if unknown := sink.unknownQuotedColumns(); len(unknown) > 0 {
+		return nil, fmt.Errorf("quoted columns %s are not columns of any table", strings.Join(unknown, ", "))
+	}
+	return sink, nil
 }
 
 // InsertRecord writes a record to the appropriate CSV file
@@ -51,65 +218,303 @@ func (s *CSVSink) InsertRecord(tableName string, record map[string]interface{})
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// Reopening a closed table's file would truncate it and write a second header
+	if s.closed {
+		return fmt.Errorf("cannot insert into %s, the CSV sink is closed", tableName)
+	}
+
 	// Get table from the map
 	table, exists := s.tableMap[tableName]
 	if !exists {
 		return fmt.Errorf("table not found: %s", tableName)
 	}
 
+	if s.writers[tableName] != nil && s.maxRowsPerFile > 0 && s.rowCounts[tableName] >= s.maxRowsPerFile {
+		if err := s.closeFile(tableName); err != nil {
+			return err
+		}
+	}
 	if s.writers[tableName] == nil {
-		file, err := os.Create(fmt.Sprintf("%s/%s.csv", s.outputDir, tableName))
-		if err != nil {
+		if err := s.openFile(table); err != nil {
 			return err
 		}
-		writer := csv.NewWriter(file)
-		s.writers[tableName] = writer
-		s.files[tableName] = file
+	}
+
+	// Write record in the same order as columns
+	var values []string
+	quote := make([]bool, len(table.Columns))
+	for i, col := range table.Columns {
+		value := record[col.Name]
+		if value == nil {
+			values = append(values, s.nullToken)
+			continue
+		}
+		values = append(values, formatColumnValue(col, value, s.declaredOrder, s.floats))
+		_, isString := value.(string)
+		quote[i] = s.quoteColumns[col.Name] || s.quoteColumns[tableName+"."+col.Name] || (s.quoteStrings && isString)
+	}
+
+	s.rowCounts[tableName]++
+	if err := s.writers[tableName].Write(values, quote); err != nil {
+		return err
+	}
+	if s.flushEvery > 0 && s.rowCounts[tableName]%s.flushEvery == 0 {
+		return s.flushFile(tableName)
+	}
+	return nil
+}
 
-		// Write header
-		var header []string
+// unknownQuotedColumns returns the sorted quoted columns that name no column of the schema
+func (s *CSVSink) unknownQuotedColumns() []string {
+	known := make(map[string]bool)
+	for _, table := range s.schema.Tables {
 		for _, col := range table.Columns {
-			header = append(header, col.Name)
+			known[col.Name] = true
+			known[table.Name+"."+col.Name] = true
 		}
-		if err := writer.Write(header); err != nil {
-			return err
+	}
+	var unknown []string
+	for column := range s.quoteColumns {
+		if !known[column] {
+			unknown = append(unknown, column)
 		}
 	}
+	sort.Strings(unknown)
+	return unknown
+}
 
-	// Write record in the same order as columns
-	var values []string
+// openFile creates the table's next output file and writes its header, callers must hold the lock
+func (s *CSVSink) openFile(table *types.Table) error {
+	s.fileCounts[table.Name]++
+	path := s.filePath(table.Name, s.fileCounts[table.Name])
+	if s.appendMode && s.maxRowsPerFile > 0 {
+		// Split tables are appended to with new files rather than by refilling existing ones
+		for fileExists(outputPath(path, s.compression)) {
+			s.fileCounts[table.Name]++
+			path = s.filePath(table.Name, s.fileCounts[table.Name])
+		}
+	}
+
+	var header []string
 	for _, col := range table.Columns {
-		value := record[col.Name]
-		values = append(values, formatValue(value))
+		header = append(header, col.Name)
 	}
 
-	return s.writers[tableName].Write(values)
+	var (
+		file     *outputFile
+		existing bool
+		err      error
+	)
+	if s.appendMode {
+		if err := checkCSVHeader(outputPath(path, s.compression), s.compression, header); err != nil {
+			return err
+		}
+		file, existing, err = appendOutputFile(path, s.compression)
+	} else {
+		file, err = createOutputFile(path, s.compression)
+	}
+	if err != nil {
+		return err
+	}
+	writer := newCSVWriter(file)
+	s.writers[table.Name] = writer
+	s.files[table.Name] = file
+	s.rowCounts[table.Name] = 0
+
+	// Existing files already start with the header
+	if existing {
+		return nil
+	}
+	return writer.Write(header, nil)
 }
 
-// Close closes all open files
-func (s *CSVSink) Close() error {
-	var errors []string
+// filePath returns the path of the table's n-th file, without the compression extension
+func (s *CSVSink) filePath(tableName string, n int) string {
+	if s.maxRowsPerFile > 0 {
+		return fmt.Sprintf("%s/%s_%03d.csv", s.outputDir, tableName, n)
+	}
+	return fmt.Sprintf("%s/%s.csv", s.outputDir, tableName)
+}
 
-	// Flush and close all writers and files
-	for tableName, writer := range s.writers {
-		writer.Flush()
-		if err := writer.Error(); err != nil {
-			errors = append(errors, fmt.Sprintf("failed to flush writer for table %s: %v", tableName, err))
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// checkCSVHeader returns an error when the CSV file at path exists with a header
+// other than header, appending to it would misalign the columns
+func checkCSVHeader(path string, compression string, header []string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var input io.Reader = file
+	if compression == CompressionGzip {
+		gz, err := gzip.NewReader(file)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		defer gz.Close()
+		input = gz
+	}
+
+	existing, err := csv.NewReader(input).Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read header of %s: %v", path, err)
+	}
+	if strings.Join(existing, ",") != strings.Join(header, ",") {
+		return fmt.Errorf("cannot append to %s, its header %s does not match columns %s",
+			path, strings.Join(existing, ","), strings.Join(header, ","))
+	}
+	return nil
+}
+
+// closeFile flushes and closes the table's current file, callers must hold the lock
+func (s *CSVSink) closeFile(tableName string) error {
+	writer := s.writers[tableName]
+	delete(s.writers, tableName)
+	file := s.files[tableName]
+	delete(s.files, tableName)
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to flush writer for table %s: %v", tableName, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close file for table %s: %v", tableName, err)
+	}
+	return nil
+}
+
+// Flush writes any buffered rows to their files
+func (s *CSVSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errors []string
+	for tableName := range s.writers {
+		if err := s.flushFile(tableName); err != nil {
+			errors = append(errors, err.Error())
 		}
+	}
 
-		if file, exists := s.files[tableName]; exists {
-			if err := file.Close(); err != nil {
-				errors = append(errors, fmt.Sprintf("failed to close file for table %s: %v", tableName, err))
+	if len(errors) > 0 {
+		return fmt.Errorf("errors while flushing CSV sink: %s", strings.Join(errors, "; "))
+	}
+	return nil
+}
+
+// flushFile writes the table's buffered rows to its current file, callers must hold the lock
+func (s *CSVSink) flushFile(tableName string) error {
+	writer := s.writers[tableName]
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush writer for table %s: %v", tableName, err)
+	}
+	if err := s.files[tableName].Flush(); err != nil {
+		return fmt.Errorf("failed to flush file for table %s: %v", tableName, err)
+	}
+	return nil
+}
+
+// Close closes all open files. Tables that received no records get a file
+// holding just the header, so an empty run still produces every table's file.
+func (s *CSVSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	var errors []string
+	for i := range s.schema.Tables {
+		table := &s.schema.Tables[i]
+		if s.appendMode && s.maxRowsPerFile > 0 && fileExists(outputPath(s.filePath(table.Name, 1), s.compression)) {
+			// Split tables already have files, an empty run adds none
+			continue
+		}
+		if s.fileCounts[table.Name] == 0 {
+			if err := s.openFile(table); err != nil {
+				errors = append(errors, fmt.Sprintf("failed to create file for table %s: %v", table.Name, err))
 			}
 		}
 	}
 
+	// Flush and close all writers and files
+	for tableName := range s.writers {
+		if err := s.closeFile(tableName); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+
 	if len(errors) > 0 {
 		return fmt.Errorf("errors while closing CSV sink: %s", strings.Join(errors, "; "))
 	}
 	return nil
 }
 
+// WriteCSV writes a header and the given rows of table to w, formatting values as the CSV sink does
+func WriteCSV(w io.Writer, table *types.Table, rows []map[string]interface{}) error {
+	writer, err := NewCSVWriter(w, table)
+	if err != nil {
+		return err
+	}
+	for _, record := range rows {
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// CSVWriter writes the rows of one table to w one at a time, formatting values as the CSV sink does
+type CSVWriter struct {
+	writer *csv.Writer
+	table  *types.Table
+}
+
+// NewCSVWriter returns a CSVWriter for table, writing its header straight away
+func NewCSVWriter(w io.Writer, table *types.Table) (*CSVWriter, error) {
+	writer := csv.NewWriter(w)
+	var header []string
+	for _, col := range table.Columns {
+		header = append(header, col.Name)
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	return &CSVWriter{writer: writer, table: table}, nil
+}
+
+// Write writes one row of the table
+func (c *CSVWriter) Write(record map[string]interface{}) error {
+	var values []string
+	for _, col := range c.table.Columns {
+		values = append(values, formatColumnValue(col, record[col.Name], false, defaultFloatFormat))
+	}
+	return c.writer.Write(values)
+}
+
+// Flush writes any buffered rows to the underlying writer
+func (c *CSVWriter) Flush() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
 // formatValue converts a value to its string representation
 func formatValue(value interface{}) string {
 	if value == nil {
@@ -121,40 +526,128 @@ func formatValue(value interface{}) string {
 		return v
 	case int:
 		return fmt.Sprintf("%d", v)
+	case int64:
+		return fmt.Sprintf("%d", v)
 	case float64:
-		return fmt.Sprintf("%.2f", v)
+		return defaultFloatFormat.format(v)
 	case bool:
 		return fmt.Sprintf("%v", v)
+	case time.Time:
+		return v.Format(csvTimeFormat)
 	case map[string]interface{}:
-		// Sort keys for consistent output
-		var keys []string
-		for k := range v {
-			keys = append(keys, k)
-		}
-		sort.Strings(keys)
-
-		var pairs []string
-		for _, k := range keys {
-			pairs = append(pairs, fmt.Sprintf("%s:%v", k, v[k]))
+		return formatMap(v, nil)
+	case []interface{}:
+		// Lists, sets and tuples keep their element order
+		elements := make([]string, 0, len(v))
+		for _, element := range v {
+			elements = append(elements, formatNestedValue(element))
 		}
-		return fmt.Sprintf("{%s}", strings.Join(pairs, ","))
+		return fmt.Sprintf("[%s]", strings.Join(elements, ","))
 	default:
 		return fmt.Sprintf("%v", v)
 	}
 }
 
-// JSONToString converts a map to a JSON-like string representation
-func JSONToString(data map[string]interface{}) (string, error) {
-	// Get all keys and sort them
+// formatColumnValue formats a value, rendering timestamps with the column's layout, floats
+// with floats unless the column sets its own and UDT and JSON sub-objects in the order
+// their fields are declared when declaredOrder is set
+func formatColumnValue(col types.Column, value interface{}, declaredOrder bool, floats floatFormat) string {
+	if t, ok := value.(time.Time); ok && col.Format != "" {
+		return t.Format(col.Format)
+	}
+	if f, ok := value.(float64); ok {
+		return floats.forColumn(col).format(f)
+	}
+	if col.Type == "objects" && value != nil {
+		// Arrays of sub-records are JSON encoded so they can be parsed back
+		if encoded, err := json.Marshal(value); err == nil {
+			return string(encoded)
+		}
+	}
+	if m, ok := value.(map[string]interface{}); ok && declaredOrder {
+		return formatMap(m, declaredFields(col))
+	}
+	return formatValue(value)
+}
+
+// formatMap renders a map as {k:v,...} with keys in the given order, any keys
+// not listed are appended in sorted order for consistent output
+func formatMap(data map[string]interface{}, order []string) string {
+	var pairs []string
+	for _, k := range orderedKeys(data, order) {
+		pairs = append(pairs, fmt.Sprintf("%s:%s", quoteNested(k), formatNestedValue(data[k])))
+	}
+	return fmt.Sprintf("{%s}", strings.Join(pairs, ","))
+}
+
+// nestedSpecialChars are the characters that would make a string inside a
+// rendered map or list ambiguous
+const nestedSpecialChars = ",:{}[]\"\\\n\r"
+
+// formatNestedValue formats a map value or list element, quoting strings that
+// contain separators so the rendered value can be parsed back unambiguously
+func formatNestedValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return quoteNested(s)
+	}
+	return formatValue(value)
+}
+
+// quoteNested returns s unchanged when it is safe to embed, otherwise as a
+// JSON string literal with quotes, backslashes and newlines escaped
+func quoteNested(s string) string {
+	if s != "" && !strings.ContainsAny(s, nestedSpecialChars) {
+		return s
+	}
+	encoded, _ := json.Marshal(s)
+	return string(encoded)
+}
+
+// orderedKeys returns the keys of data, first those present in order and then the rest sorted
+func orderedKeys(data map[string]interface{}, order []string) []string {
 	keys := make([]string, 0, len(data))
+	seen := make(map[string]bool, len(order))
+	for _, k := range order {
+		if _, ok := data[k]; ok && !seen[k] {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+
+	var rest []string
 	for k := range data {
-		keys = append(keys, k)
+		if !seen[k] {
+			rest = append(rest, k)
+		}
 	}
-	sort.Strings(keys)
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// declaredFields returns the sub-field names of a column in declaration order
+func declaredFields(col types.Column) []string {
+	var fields []string
+	switch col.Type {
+	case "udt":
+		for _, field := range col.UDTConfig.Fields {
+			fields = append(fields, field.Name)
+		}
+	case "json":
+		for _, field := range col.JSONConfig {
+			fields = append(fields, field.Name)
+		}
+	case "map":
+		fields = append(fields, col.MapConfig.Keys...)
+	}
+	return fields
+}
 
-	// Build string with sorted keys
+// JSONToString converts a map to a JSON-like string representation. Keys are sorted
+// unless an explicit order is given, in which case listed keys come first.
+func JSONToString(data map[string]interface{}, order ...string) (string, error) {
+	// Build string with ordered keys
 	parts := make([]string, 0, len(data))
-	for _, k := range keys {
+	for _, k := range orderedKeys(data, order) {
 		parts = append(parts, fmt.Sprintf("%s:%v", k, data[k]))
 	}
 	return "{" + strings.Join(parts, ",") + "}", nil
@@ -1,9 +1,17 @@
 package sink
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/sujanks/data-gen-app/pkg/types"
@@ -148,6 +156,11 @@ func TestFormatValue(t *testing.T) {
 			input:    42,
 			expected: "42",
 		},
+		{
+			name:     "Int64 value",
+			input:    int64(1741348800000),
+			expected: "1741348800000",
+		},
 		{
 			name:     "Float value",
 			input:    42.123,
@@ -171,6 +184,36 @@ func TestFormatValue(t *testing.T) {
 			},
 			expected: "{key1:value1,key2:42}",
 		},
+		{
+			name:     "List value",
+			input:    []interface{}{"urgent", "normal", "low"},
+			expected: "[urgent,normal,low]",
+		},
+		{
+			name:     "Tuple value",
+			input:    []interface{}{-33.87, 151.21, "Sydney"},
+			expected: "[-33.87,151.21,Sydney]",
+		},
+		{
+			name: "Map with list value",
+			input: map[string]interface{}{
+				"tags": []interface{}{"a", "b"},
+			},
+			expected: "{tags:[a,b]}",
+		},
+		{
+			name: "Map with separators and quotes",
+			input: map[string]interface{}{
+				"note": `said "hi", then left`,
+				"city": "Springfield",
+			},
+			expected: `{city:Springfield,note:"said \"hi\", then left"}`,
+		},
+		{
+			name:     "List with multiline element",
+			input:    []interface{}{"line one\nline two", "plain"},
+			expected: `["line one\nline two",plain]`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -180,3 +223,604 @@ func TestFormatValue(t *testing.T) {
 		})
 	}
 }
+
+func TestCSVSinkGzip(t *testing.T) {
+	tempDir := t.TempDir()
+
+	schema := &types.Schema{
+		Tables: []types.Table{
+			{
+				Name: "users",
+				Columns: []types.Column{
+					{Name: "id", Type: "string"},
+					{Name: "name", Type: "string"},
+				},
+			},
+		},
+	}
+
+	sink, err := NewCSVSink(tempDir, schema, WithCompression(CompressionGzip))
+	assert.NoError(t, err)
+
+	err = sink.InsertRecord("users", map[string]interface{}{
+		"id":   "USER001",
+		"name": "John Doe",
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, sink.Close())
+
+	// Only the compressed file is written
+	_, err = os.Stat(filepath.Join(tempDir, "users.csv"))
+	assert.True(t, os.IsNotExist(err))
+
+	file, err := os.Open(filepath.Join(tempDir, "users.csv.gz"))
+	assert.NoError(t, err)
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	assert.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "id,name\nUSER001,John Doe\n", string(content))
+}
+
+func TestCSVSinkUnsupportedCompression(t *testing.T) {
+	_, err := NewCSVSink(t.TempDir(), &types.Schema{}, WithCompression("zip"))
+	assert.Error(t, err)
+}
+
+func TestCSVSinkDeclaredFieldOrder(t *testing.T) {
+	schema := &types.Schema{
+		Tables: []types.Table{
+			{
+				Name: "users",
+				Columns: []types.Column{
+					{Name: "id", Type: "string"},
+					{
+						Name: "address",
+						Type: "udt",
+						UDTConfig: types.UDTConfig{
+							Name: "address_type",
+							Fields: []types.Column{
+								{Name: "street", Type: "string"},
+								{Name: "city", Type: "string"},
+								{Name: "zip", Pattern: "#####"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	record := map[string]interface{}{
+		"id": "USER001",
+		"address": map[string]interface{}{
+			"street": "Main St",
+			"city":   "Springfield",
+			"zip":    "12345",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		opts     []CSVOption
+		expected string
+	}{
+		{
+			name:     "Sorted by default",
+			expected: "id,address\nUSER001,\"{city:Springfield,street:Main St,zip:12345}\"\n",
+		},
+		{
+			name:     "Declared order",
+			opts:     []CSVOption{WithDeclaredFieldOrder()},
+			expected: "id,address\nUSER001,\"{street:Main St,city:Springfield,zip:12345}\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			sink, err := NewCSVSink(tempDir, schema, tt.opts...)
+			assert.NoError(t, err)
+			assert.NoError(t, sink.InsertRecord("users", record))
+			assert.NoError(t, sink.Close())
+
+			content, err := os.ReadFile(filepath.Join(tempDir, "users.csv"))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, string(content))
+		})
+	}
+}
+
+func TestCSVSinkMaxRowsPerFile(t *testing.T) {
+	tempDir := t.TempDir()
+	schema := &types.Schema{
+		Tables: []types.Table{
+			{
+				Name:    "users",
+				Columns: []types.Column{{Name: "id"}, {Name: "name"}},
+			},
+		},
+	}
+
+	sink, err := NewCSVSink(tempDir, schema, WithMaxRowsPerFile(2))
+	assert.NoError(t, err)
+	for _, id := range []string{"USER001", "USER002", "USER003", "USER004", "USER005"} {
+		assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": id, "name": "John"}))
+	}
+	assert.NoError(t, sink.Close())
+
+	files, err := filepath.Glob(filepath.Join(tempDir, "*.csv"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(tempDir, "users_001.csv"),
+		filepath.Join(tempDir, "users_002.csv"),
+		filepath.Join(tempDir, "users_003.csv"),
+	}, files)
+
+	expected := []string{
+		"id,name\nUSER001,John\nUSER002,John\n",
+		"id,name\nUSER003,John\nUSER004,John\n",
+		"id,name\nUSER005,John\n",
+	}
+	for i, file := range files {
+		content, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		assert.Equal(t, expected[i], string(content))
+	}
+}
+
+func TestCSVSinkConcurrentInserts(t *testing.T) {
+	tempDir := t.TempDir()
+	schema := &types.Schema{
+		Tables: []types.Table{
+			{Name: "users", Columns: []types.Column{{Name: "id"}, {Name: "worker"}}},
+			{Name: "orders", Columns: []types.Column{{Name: "id"}, {Name: "worker"}}},
+			{Name: "events", Columns: []types.Column{{Name: "id"}, {Name: "worker"}}},
+		},
+	}
+	sink, err := NewCSVSink(tempDir, schema)
+	assert.NoError(t, err)
+	splitDir := t.TempDir()
+	splitSink, err := NewCSVSink(splitDir, schema, WithMaxRowsPerFile(7))
+	assert.NoError(t, err)
+
+	const workers, perWorker = 32, 50
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				table := schema.Tables[(w+i)%len(schema.Tables)].Name
+				record := map[string]interface{}{"id": fmt.Sprintf("%d-%d", w, i), "worker": w}
+				assert.NoError(t, sink.InsertRecord(table, record))
+				assert.NoError(t, splitSink.InsertRecord(table, record))
+				if i%10 == 0 {
+					assert.NoError(t, sink.Flush())
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	assert.NoError(t, sink.Close())
+	assert.NoError(t, splitSink.Close())
+
+	for _, dir := range []string{tempDir, splitDir} {
+		rows, headers := 0, 0
+		ids := make(map[string]bool)
+		files, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+		assert.NoError(t, err)
+		for _, file := range files {
+			f, err := os.Open(file)
+			assert.NoError(t, err)
+			records, err := csv.NewReader(f).ReadAll()
+			f.Close()
+			assert.NoError(t, err)
+			for i, record := range records {
+				if record[0] == "id" {
+					headers++
+					assert.Zero(t, i, "%s has a header after its first line", file)
+					continue
+				}
+				assert.False(t, ids[record[0]], "%s written twice", record[0])
+				ids[record[0]] = true
+				rows++
+			}
+		}
+		assert.Equal(t, workers*perWorker, rows)
+		assert.Equal(t, len(files), headers, "every file has exactly one header")
+	}
+
+	// Inserting after Close must not reopen and truncate the files
+	err = sink.InsertRecord("users", map[string]interface{}{"id": "late"})
+	assert.EqualError(t, err, "cannot insert into users, the CSV sink is closed")
+	assert.NoError(t, sink.Close())
+	content, err := os.ReadFile(filepath.Join(tempDir, "users.csv"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(content), "id,worker\n"))
+	assert.Greater(t, strings.Count(string(content), "\n"), perWorker)
+	assert.NotContains(t, string(content), "late")
+}
+
+func TestCSVSinkNegativeMaxRowsPerFile(t *testing.T) {
+	_, err := NewCSVSink(t.TempDir(), &types.Schema{}, WithMaxRowsPerFile(-1))
+	assert.Error(t, err)
+}
+
+func TestCSVSinkAppend(t *testing.T) {
+	schema := &types.Schema{
+		Tables: []types.Table{
+			{Name: "users", Columns: []types.Column{{Name: "id"}, {Name: "name"}}},
+		},
+	}
+	writeRun := func(t *testing.T, dir string, ids []string, opts ...CSVOption) {
+		sink, err := NewCSVSink(dir, schema, opts...)
+		assert.NoError(t, err)
+		for _, id := range ids {
+			assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": id, "name": "John"}))
+		}
+		assert.NoError(t, sink.Close())
+	}
+
+	t.Run("Single file", func(t *testing.T) {
+		tempDir := t.TempDir()
+		writeRun(t, tempDir, []string{"USER001", "USER002"})
+		writeRun(t, tempDir, []string{"USER003"}, WithAppend())
+		writeRun(t, tempDir, nil, WithAppend())
+
+		content, err := os.ReadFile(filepath.Join(tempDir, "users.csv"))
+		assert.NoError(t, err)
+		assert.Equal(t, "id,name\nUSER001,John\nUSER002,John\nUSER003,John\n", string(content))
+	})
+
+	t.Run("New file gets a header", func(t *testing.T) {
+		tempDir := t.TempDir()
+		writeRun(t, tempDir, []string{"USER001"}, WithAppend())
+
+		content, err := os.ReadFile(filepath.Join(tempDir, "users.csv"))
+		assert.NoError(t, err)
+		assert.Equal(t, "id,name\nUSER001,John\n", string(content))
+	})
+
+	t.Run("Gzip", func(t *testing.T) {
+		tempDir := t.TempDir()
+		writeRun(t, tempDir, []string{"USER001"}, WithCompression(CompressionGzip))
+		writeRun(t, tempDir, []string{"USER002"}, WithCompression(CompressionGzip), WithAppend())
+
+		file, err := os.Open(filepath.Join(tempDir, "users.csv.gz"))
+		assert.NoError(t, err)
+		defer file.Close()
+		reader, err := gzip.NewReader(file)
+		assert.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.Equal(t, "id,name\nUSER001,John\nUSER002,John\n", string(content))
+	})
+
+	t.Run("Split files continue numbering", func(t *testing.T) {
+		tempDir := t.TempDir()
+		writeRun(t, tempDir, []string{"USER001", "USER002", "USER003"}, WithMaxRowsPerFile(2))
+		writeRun(t, tempDir, []string{"USER004"}, WithMaxRowsPerFile(2), WithAppend())
+		writeRun(t, tempDir, nil, WithMaxRowsPerFile(2), WithAppend())
+
+		files, err := filepath.Glob(filepath.Join(tempDir, "*.csv"))
+		assert.NoError(t, err)
+		assert.Len(t, files, 3)
+		content, err := os.ReadFile(filepath.Join(tempDir, "users_003.csv"))
+		assert.NoError(t, err)
+		assert.Equal(t, "id,name\nUSER004,John\n", string(content))
+	})
+
+	t.Run("Mismatched header", func(t *testing.T) {
+		tempDir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "users.csv"), []byte("id,email\nUSER001,a@b.c\n"), 0644))
+
+		sink, err := NewCSVSink(tempDir, schema, WithAppend())
+		assert.NoError(t, err)
+		err = sink.InsertRecord("users", map[string]interface{}{"id": "USER002"})
+		assert.ErrorContains(t, err, "header id,email does not match columns id,name")
+	})
+}
+
+func TestCSVSinkFlushEvery(t *testing.T) {
+	schema := &types.Schema{
+		Tables: []types.Table{{Name: "users", Columns: []types.Column{{Name: "id"}}}},
+	}
+	tempDir := t.TempDir()
+	sink, err := NewCSVSink(tempDir, schema, WithFlushEvery(2))
+	assert.NoError(t, err)
+
+	readUsers := func() string {
+		content, err := os.ReadFile(filepath.Join(tempDir, "users.csv"))
+		assert.NoError(t, err)
+		return string(content)
+	}
+
+	assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER001"}))
+	assert.Equal(t, "", readUsers())
+
+	// The second row hits the interval, both are on disk before Close
+	assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER002"}))
+	assert.Equal(t, "id\nUSER001\nUSER002\n", readUsers())
+
+	assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER003"}))
+	assert.Equal(t, "id\nUSER001\nUSER002\n", readUsers())
+
+	assert.NoError(t, sink.Close())
+	assert.Equal(t, "id\nUSER001\nUSER002\nUSER003\n", readUsers())
+
+	_, err = NewCSVSink(t.TempDir(), schema, WithFlushEvery(-1))
+	assert.Error(t, err)
+}
+
+func TestCSVSinkNullToken(t *testing.T) {
+	schema := &types.Schema{
+		Tables: []types.Table{
+			{Name: "users", Columns: []types.Column{{Name: "id"}, {Name: "nickname"}, {Name: "note"}, {Name: "score", Type: "float"}}},
+		},
+	}
+	records := []map[string]interface{}{
+		{"id": "USER001", "nickname": nil, "note": "", "score": 1.5},
+		{"id": "USER002", "note": "hi"}, // Missing columns are nil too
+	}
+
+	tests := []struct {
+		name     string
+		opts     []CSVOption
+		expected string
+	}{
+		{
+			name:     "Empty by default",
+			expected: "id,nickname,note,score\nUSER001,,,1.50\nUSER002,,hi,\n",
+		},
+		{
+			name:     "Postgres COPY",
+			opts:     []CSVOption{WithNullToken(`\N`)},
+			expected: "id,nickname,note,score\nUSER001,\\N,,1.50\nUSER002,\\N,hi,\\N\n",
+		},
+		{
+			name:     "Token needing quotes",
+			opts:     []CSVOption{WithNullToken("NULL, really")},
+			expected: "id,nickname,note,score\nUSER001,\"NULL, really\",,1.50\nUSER002,\"NULL, really\",hi,\"NULL, really\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			sink, err := NewCSVSink(tempDir, schema, tt.opts...)
+			assert.NoError(t, err)
+			for _, record := range records {
+				assert.NoError(t, sink.InsertRecord("users", record))
+			}
+			assert.NoError(t, sink.Close())
+
+			content, err := os.ReadFile(filepath.Join(tempDir, "users.csv"))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, string(content))
+		})
+	}
+}
+
+func TestCSVSinkQuotedColumns(t *testing.T) {
+	schema := &types.Schema{
+		Tables: []types.Table{
+			{Name: "users", Columns: []types.Column{{Name: "id"}, {Name: "zip"}, {Name: "phone"}, {Name: "age", Type: "int"}}},
+			{Name: "stores", Columns: []types.Column{{Name: "zip"}}},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		opts   []CSVOption
+		users  string
+		stores string
+	}{
+		{
+			name:   "Quoted only when needed by default",
+			users:  "id,zip,phone,age\nU1,007,0123 456,42\nU2,,,7\n",
+			stores: "zip\n007\n",
+		},
+		{
+			name:   "Named column in every table",
+			opts:   []CSVOption{WithQuotedColumns("zip")},
+			users:  "id,zip,phone,age\nU1,\"007\",0123 456,42\nU2,,,7\n",
+			stores: "zip\n\"007\"\n",
+		},
+		{
+			name:   "Column of one table",
+			opts:   []CSVOption{WithQuotedColumns("users.zip", "users.age")},
+			users:  "id,zip,phone,age\nU1,\"007\",0123 456,\"42\"\nU2,,,\"7\"\n",
+			stores: "zip\n007\n",
+		},
+		{
+			name:   "All strings",
+			opts:   []CSVOption{WithQuotedStrings()},
+			users:  "id,zip,phone,age\n\"U1\",\"007\",\"0123 456\",42\n\"U2\",,\"\",7\n",
+			stores: "zip\n\"007\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			sink, err := NewCSVSink(tempDir, schema, tt.opts...)
+			assert.NoError(t, err)
+			assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "U1", "zip": "007", "phone": "0123 456", "age": 42}))
+			assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "U2", "zip": nil, "phone": "", "age": 7}))
+			assert.NoError(t, sink.InsertRecord("stores", map[string]interface{}{"zip": "007"}))
+			assert.NoError(t, sink.Close())
+
+			users, err := os.ReadFile(filepath.Join(tempDir, "users.csv"))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.users, string(users))
+			stores, err := os.ReadFile(filepath.Join(tempDir, "stores.csv"))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.stores, string(stores))
+		})
+	}
+
+	_, err := NewCSVSink(t.TempDir(), schema, WithQuotedColumns("zip", "users.postcode"))
+	assert.EqualError(t, err, "quoted columns users.postcode are not columns of any table")
+}
+
+func TestCSVSinkFloatFormat(t *testing.T) {
+	intPtr := func(n int) *int { return &n }
+	columns := []types.Column{
+		{Name: "amount", Type: "decimal"},
+		{Name: "ratio", Type: "float", Scale: intPtr(8)},
+		{Name: "mass", Type: "float", Format: FloatScientific, Scale: intPtr(3)},
+		{Name: "exact", Type: "float", Scale: intPtr(-1)},
+		{Name: "price", Type: "float", Format: FloatFixed},
+	}
+	record := map[string]interface{}{
+		"amount": 1234.5,
+		"ratio":  0.123456789,
+		"mass":   5.9722e24,
+		"exact":  3.0,
+		"price":  19.999,
+	}
+
+	tests := []struct {
+		name     string
+		opts     []CSVOption
+		expected string
+	}{
+		{
+			name:     "Default two decimals",
+			expected: "1234.50,0.12345679,5.972e+24,3,20.00",
+		},
+		{
+			name:     "High precision default",
+			opts:     []CSVOption{WithFloatFormat(6, false)},
+			expected: "1234.500000,0.12345679,5.972e+24,3,19.999000",
+		},
+		{
+			name:     "Scientific default",
+			opts:     []CSVOption{WithFloatFormat(2, true)},
+			expected: "1.23e+03,1.23456789e-01,5.972e+24,3e+00,20.00",
+		},
+		{
+			name:     "Shortest exact default",
+			opts:     []CSVOption{WithFloatFormat(-1, false)},
+			expected: "1234.5,0.12345679,5.972e+24,3,19.999",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			schema := &types.Schema{Tables: []types.Table{{Name: "samples", Columns: columns}}}
+			sink, err := NewCSVSink(tempDir, schema, tt.opts...)
+			assert.NoError(t, err)
+			assert.NoError(t, sink.InsertRecord("samples", record))
+			assert.NoError(t, sink.Close())
+
+			content, err := os.ReadFile(filepath.Join(tempDir, "samples.csv"))
+			assert.NoError(t, err)
+			assert.Equal(t, "amount,ratio,mass,exact,price\n"+tt.expected+"\n", string(content))
+		})
+	}
+
+	_, err := NewCSVSink(t.TempDir(), &types.Schema{}, WithFloatFormat(-2, false))
+	assert.Error(t, err)
+}
+
+func TestCSVSinkNestedValueRoundTrip(t *testing.T) {
+	tempDir := t.TempDir()
+	schema := &types.Schema{
+		Tables: []types.Table{
+			{Name: "users", Columns: []types.Column{{Name: "metadata", Type: "json"}}},
+		},
+	}
+
+	sink, err := NewCSVSink(tempDir, schema)
+	assert.NoError(t, err)
+	err = sink.InsertRecord("users", map[string]interface{}{
+		"metadata": map[string]interface{}{"note": `a, "quoted" value`},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, sink.Close())
+
+	file, err := os.Open(filepath.Join(tempDir, "users.csv"))
+	assert.NoError(t, err)
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"metadata"}, {`{note:"a, \"quoted\" value"}`}}, rows)
+}
+
+func TestFormatColumnValueObjects(t *testing.T) {
+	col := types.Column{Name: "items", Type: "objects"}
+	items := []interface{}{
+		map[string]interface{}{"sku": "SKU1", "quantity": 2},
+		map[string]interface{}{"sku": "SKU2", "quantity": 1},
+	}
+
+	assert.Equal(t, `[{"quantity":2,"sku":"SKU1"},{"quantity":1,"sku":"SKU2"}]`, formatColumnValue(col, items, false, defaultFloatFormat))
+	assert.Equal(t, "", formatColumnValue(col, nil, false, defaultFloatFormat))
+}
+
+func TestCSVSinkTimestampFormat(t *testing.T) {
+	tempDir := t.TempDir()
+	schema := &types.Schema{
+		Tables: []types.Table{
+			{
+				Name: "events",
+				Columns: []types.Column{
+					{Name: "occurred_at", Type: "timestamp", Format: "02/01/2006 15:04"},
+					{Name: "recorded_at", Type: "timestamp"},
+				},
+			},
+		},
+	}
+
+	sink, err := NewCSVSink(tempDir, schema)
+	assert.NoError(t, err)
+
+	occurred := time.Date(2024, time.March, 7, 9, 30, 0, 0, time.UTC)
+	err = sink.InsertRecord("events", map[string]interface{}{
+		"occurred_at": occurred,
+		"recorded_at": occurred,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, sink.Close())
+
+	content, err := os.ReadFile(filepath.Join(tempDir, "events.csv"))
+	assert.NoError(t, err)
+	assert.Equal(t, "occurred_at,recorded_at\n07/03/2024 09:30,2024-03-07 09:30:00\n", string(content))
+}
+
+func TestWriteCSV(t *testing.T) {
+	table := &types.Table{
+		Name: "users",
+		Columns: []types.Column{
+			{Name: "id"},
+			{Name: "score", Type: "float"},
+			{Name: "tags", Type: "list"},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, table, []map[string]interface{}{
+		{"id": "USER001", "score": 9.5, "tags": []interface{}{"a", "b"}},
+		{"id": "USER002"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "id,score,tags\nUSER001,9.50,\"[a,b]\"\nUSER002,,\n", buf.String())
+}
+
+func TestJSONToString(t *testing.T) {
+	data := map[string]interface{}{
+		"street": "Main St",
+		"city":   "Springfield",
+		"zip":    "12345",
+	}
+
+	sorted, err := JSONToString(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "{city:Springfield,street:Main St,zip:12345}", sorted)
+
+	ordered, err := JSONToString(data, "street", "city", "zip")
+	assert.NoError(t, err)
+	assert.Equal(t, "{street:Main St,city:Springfield,zip:12345}", ordered)
+}
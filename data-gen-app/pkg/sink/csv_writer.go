@@ -0,0 +1,63 @@
+package sink
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// csvWriter writes comma separated rows the way csv.Writer does, quoting fields
+// only when they need it, and additionally lets callers force quotes on fields
+// such as zip codes that importers would otherwise read as numbers
+type csvWriter struct {
+	w *bufio.Writer
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: bufio.NewWriter(w)}
+}
+
+// Write writes one row, quoting field i when quote[i] is set or the field
+// needs it. quote may be nil or shorter than fields.
+func (w *csvWriter) Write(fields []string, quote []bool) error {
+	for i, field := range fields {
+		if i > 0 {
+			if err := w.w.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		if (i < len(quote) && quote[i]) || fieldNeedsQuotes(field) {
+			field = `"` + strings.ReplaceAll(field, `"`, `""`) + `"`
+		}
+		if _, err := w.w.WriteString(field); err != nil {
+			return err
+		}
+	}
+	return w.w.WriteByte('\n')
+}
+
+// Flush writes any buffered rows to the underlying writer, check Error for failures
+func (w *csvWriter) Flush() {
+	w.w.Flush()
+}
+
+// Error reports any error from a previous Write or Flush
+func (w *csvWriter) Error() error {
+	_, err := w.w.Write(nil)
+	return err
+}
+
+// fieldNeedsQuotes matches csv.Writer: fields holding separators, quotes or line
+// breaks, starting with a space, or \. which Postgres reads as end of data
+func fieldNeedsQuotes(field string) bool {
+	if field == "" {
+		return false
+	}
+	if field == `\.` || strings.ContainsAny(field, ",\"\r\n") {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(field)
+	return unicode.IsSpace(r)
+}
@@ -0,0 +1,164 @@
+package sink
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// SQL dialects CreateTableStatements can produce
+const (
+	DialectPostgres = "postgres"
+	DialectMySQL    = "mysql"
+	DialectSQLite   = "sqlite"
+)
+
+// sqlDialect quotes identifiers and maps column configurations to column types
+type sqlDialect struct {
+	quote      func(name string) string
+	columnType func(col types.Column) string
+}
+
+var sqlDialects = map[string]sqlDialect{
+	DialectPostgres: {quote: quoteIdent, columnType: postgresType},
+	DialectMySQL:    {quote: quoteMySQLIdent, columnType: mysqlType},
+	DialectSQLite:   {quote: quoteIdent, columnType: sqliteType},
+}
+
+// CreateTableStatements returns a CREATE TABLE IF NOT EXISTS statement per table
+// of the schema in the dialect, postgres, mysql or sqlite. Column types are
+// inferred from the column configuration, foreign columns without a type take
+// their parent column's, and mandatory columns are NOT NULL.
+func CreateTableStatements(schema *types.Schema, dialect string) ([]string, error) {
+	d, ok := sqlDialects[dialect]
+	if !ok {
+		supported := make([]string, 0, len(sqlDialects))
+		for name := range sqlDialects {
+			supported = append(supported, name)
+		}
+		sort.Strings(supported)
+		return nil, fmt.Errorf("unsupported dialect %q, expected %s", dialect, strings.Join(supported, ", "))
+	}
+
+	columns := make(map[string]types.Column) // Columns by table.column, to type foreign columns
+	for _, table := range schema.Tables {
+		for _, col := range table.Columns {
+			columns[table.Name+"."+col.Name] = col
+		}
+	}
+
+	statements := make([]string, 0, len(schema.Tables))
+	for i := range schema.Tables {
+		table := schema.Tables[i]
+		table.Columns = append([]types.Column(nil), table.Columns...)
+		for c, col := range table.Columns {
+			if parent, ok := columns[col.Foreign]; ok && col.Type == "" {
+				table.Columns[c].Type, table.Columns[c].Format = parent.Type, parent.Format
+			}
+		}
+		statements = append(statements, d.createTable(&table))
+	}
+	return statements, nil
+}
+
+// createTable builds a CREATE TABLE IF NOT EXISTS statement for the table
+func (d sqlDialect) createTable(table *types.Table) string {
+	var defs []string
+	for _, col := range table.Columns {
+		def := fmt.Sprintf("%s %s", d.quote(col.Name), d.columnType(col))
+		if col.Mandatory {
+			def += " NOT NULL"
+		}
+		defs = append(defs, def)
+	}
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", d.quote(table.Name), strings.Join(defs, ", "))
+}
+
+// postgresType infers the Postgres column type from the column configuration
+func postgresType(col types.Column) string {
+	switch col.Type {
+	case "int":
+		if col.Width > 0 {
+			return "TEXT" // Zero padded
+		}
+		return "INTEGER"
+	case "bigint", "long":
+		if col.Width > 0 {
+			return "TEXT"
+		}
+		return "BIGINT"
+	case "float":
+		return "DOUBLE PRECISION"
+	case "decimal":
+		return "NUMERIC"
+	case "bool":
+		return "BOOLEAN"
+	case "date":
+		return "DATE"
+	case "timestamp":
+		if col.Format == "unix" || col.Format == "unix_ms" {
+			return "BIGINT"
+		}
+		return "TIMESTAMP"
+	case "uuid":
+		return "UUID"
+	case "json", "map", "list", "set", "udt", "tuple", "objects":
+		return "JSONB"
+	default:
+		return "TEXT"
+	}
+}
+
+// mysqlType infers the MySQL column type from the column configuration
+func mysqlType(col types.Column) string {
+	switch col.Type {
+	case "int":
+		if col.Width > 0 {
+			return fmt.Sprintf("VARCHAR(%d)", max(col.Width, 11))
+		}
+		return "INT"
+	case "bigint", "long":
+		if col.Width > 0 {
+			return fmt.Sprintf("VARCHAR(%d)", max(col.Width, 20))
+		}
+		return "BIGINT"
+	case "float":
+		return "DOUBLE"
+	case "decimal":
+		// 18 integer digits and the scale floats are written with
+		scale := defaultFloatFormat.scale
+		if col.Scale != nil {
+			scale = *col.Scale
+		}
+		if scale < 0 {
+			return "DOUBLE"
+		}
+		return fmt.Sprintf("DECIMAL(%d,%d)", 18+scale, scale)
+	case "bool":
+		return "BOOLEAN"
+	case "date":
+		return "DATE"
+	case "timestamp":
+		if col.Format == "unix" || col.Format == "unix_ms" {
+			return "BIGINT"
+		}
+		return "DATETIME"
+	case "uuid":
+		return "CHAR(36)"
+	case "ulid":
+		return "CHAR(26)"
+	case "json", "map", "list", "set", "udt", "tuple", "objects":
+		return "JSON"
+	case "sentence", "paragraph":
+		return "TEXT"
+	default:
+		return "VARCHAR(255)"
+	}
+}
+
+// quoteMySQLIdent quotes an identifier with backticks for MySQL statements
+func quoteMySQLIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
@@ -0,0 +1,66 @@
+package sink
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+func TestCreateTableStatements(t *testing.T) {
+	scale := 3
+	schema := &types.Schema{Tables: []types.Table{
+		{Name: "customers", Columns: []types.Column{
+			{Name: "id", Type: "bigint", Parent: true, Mandatory: true},
+			{Name: "email", Mandatory: true},
+			{Name: "credit_limit", Type: "decimal", Scale: &scale},
+			{Name: "created_at", Type: "timestamp"},
+		}},
+		{Name: "orders", Columns: []types.Column{
+			{Name: "id", Type: "uuid", Mandatory: true},
+			{Name: "customer_id", Foreign: "customers.id"},
+			{Name: "paid", Type: "bool"},
+			{Name: "items", Type: "list", ElementType: "string"},
+		}},
+	}}
+
+	tests := []struct {
+		dialect string
+		want    []string
+	}{
+		{
+			dialect: DialectPostgres,
+			want: []string{
+				`CREATE TABLE IF NOT EXISTS "customers" ("id" BIGINT NOT NULL, "email" TEXT NOT NULL, "credit_limit" NUMERIC, "created_at" TIMESTAMP)`,
+				`CREATE TABLE IF NOT EXISTS "orders" ("id" UUID NOT NULL, "customer_id" BIGINT, "paid" BOOLEAN, "items" JSONB)`,
+			},
+		},
+		{
+			dialect: DialectMySQL,
+			want: []string{
+				"CREATE TABLE IF NOT EXISTS `customers` (`id` BIGINT NOT NULL, `email` VARCHAR(255) NOT NULL, `credit_limit` DECIMAL(21,3), `created_at` DATETIME)",
+				"CREATE TABLE IF NOT EXISTS `orders` (`id` CHAR(36) NOT NULL, `customer_id` BIGINT, `paid` BOOLEAN, `items` JSON)",
+			},
+		},
+		{
+			dialect: DialectSQLite,
+			want: []string{
+				`CREATE TABLE IF NOT EXISTS "customers" ("id" INTEGER NOT NULL, "email" TEXT NOT NULL, "credit_limit" REAL, "created_at" TEXT)`,
+				`CREATE TABLE IF NOT EXISTS "orders" ("id" TEXT NOT NULL, "customer_id" INTEGER, "paid" INTEGER, "items" TEXT)`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			statements, err := CreateTableStatements(schema, tt.dialect)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, statements)
+		})
+	}
+
+	// The schema's own columns are left untouched
+	assert.Empty(t, schema.Tables[1].Columns[1].Type)
+
+	_, err := CreateTableStatements(schema, "oracle")
+	assert.EqualError(t, err, `unsupported dialect "oracle", expected mysql, postgres, sqlite`)
+}
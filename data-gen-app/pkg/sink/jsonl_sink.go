@@ -0,0 +1,124 @@
+package sink
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// JSONLSink implements DataSink interface by writing one JSON object per line,
+// one users.jsonl file per table
+type JSONLSink struct {
+	outputDir   string
+	compression string
+	writers     map[string]*bufio.Writer
+	files       map[string]*outputFile
+	mu          sync.Mutex
+}
+
+// NewJSONLSink creates a JSON Lines sink that writes to the specified directory,
+// compressing every file when compression is set
+func NewJSONLSink(outputDir string, compression string) (*JSONLSink, error) {
+	if err := validateCompression(compression); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %v", err)
+	}
+
+	return &JSONLSink{
+		outputDir:   outputDir,
+		compression: compression,
+		writers:     make(map[string]*bufio.Writer),
+		files:       make(map[string]*outputFile),
+	}, nil
+}
+
+// InsertRecord appends the record as a JSON line to the table's file
+func (s *JSONLSink) InsertRecord(tableName string, record map[string]interface{}) error {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to serialize record for %s: %v", tableName, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writer := s.writers[tableName]
+	if writer == nil {
+		file, err := createOutputFile(fmt.Sprintf("%s/%s.jsonl", s.outputDir, tableName), s.compression)
+		if err != nil {
+			return err
+		}
+		writer = bufio.NewWriter(file)
+		s.writers[tableName] = writer
+		s.files[tableName] = file
+	}
+
+	if _, err := writer.Write(append(payload, '\n')); err != nil {
+		return fmt.Errorf("failed to write record for %s: %v", tableName, err)
+	}
+	return nil
+}
+
+// Flush writes any buffered lines to their files
+func (s *JSONLSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errors []string
+	for _, tableName := range s.openTables() {
+		if err := s.writers[tableName].Flush(); err != nil {
+			errors = append(errors, fmt.Sprintf("failed to flush writer for table %s: %v", tableName, err))
+			continue
+		}
+		if err := s.files[tableName].Flush(); err != nil {
+			errors = append(errors, fmt.Sprintf("failed to flush file for table %s: %v", tableName, err))
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("errors while flushing JSONL sink: %s", strings.Join(errors, "; "))
+	}
+	return nil
+}
+
+// Close flushes and closes all open files, a second Close is a no-op
+func (s *JSONLSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errors []string
+	for _, tableName := range s.openTables() {
+		writer := s.writers[tableName]
+		file := s.files[tableName]
+		delete(s.writers, tableName)
+		delete(s.files, tableName)
+
+		if err := writer.Flush(); err != nil {
+			errors = append(errors, fmt.Sprintf("failed to flush writer for table %s: %v", tableName, err))
+		}
+		if err := file.Close(); err != nil {
+			errors = append(errors, fmt.Sprintf("failed to close file for table %s: %v", tableName, err))
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("errors while closing JSONL sink: %s", strings.Join(errors, "; "))
+	}
+	return nil
+}
+
+// openTables returns the tables with an open file in sorted order, callers must hold the lock
+func (s *JSONLSink) openTables() []string {
+	tables := make([]string, 0, len(s.writers))
+	for tableName := range s.writers {
+		tables = append(tables, tableName)
+	}
+	sort.Strings(tables)
+	return tables
+}
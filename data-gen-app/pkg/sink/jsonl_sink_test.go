@@ -0,0 +1,42 @@
+package sink
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONLSink(t *testing.T) {
+	tempDir := t.TempDir()
+
+	sink, err := NewJSONLSink(tempDir, "")
+	assert.NoError(t, err)
+
+	assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{
+		"id":      "USER001",
+		"address": map[string]interface{}{"city": "Springfield"},
+	}))
+	assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER002"}))
+	assert.NoError(t, sink.InsertRecord("orders", map[string]interface{}{"id": "ORDER001"}))
+	assert.NoError(t, sink.Close())
+	assert.NoError(t, sink.Close())
+
+	content, err := os.ReadFile(filepath.Join(tempDir, "users.jsonl"))
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	assert.Len(t, lines, 2)
+	assert.JSONEq(t, `{"id":"USER001","address":{"city":"Springfield"}}`, lines[0])
+	assert.JSONEq(t, `{"id":"USER002"}`, lines[1])
+
+	content, err = os.ReadFile(filepath.Join(tempDir, "orders.jsonl"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":"ORDER001"}`, string(content))
+}
+
+func TestJSONLSinkUnsupportedCompression(t *testing.T) {
+	_, err := NewJSONLSink(t.TempDir(), "zip")
+	assert.Error(t, err)
+}
@@ -0,0 +1,126 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// defaultTopicTemplate publishes each table to a topic of the same name
+const defaultTopicTemplate = "{table}"
+
+// kafkaWriter is the subset of *kafka.Writer used by the sink
+type kafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// KafkaSink implements DataSink interface by producing JSON messages to Kafka
+type KafkaSink struct {
+	writer        kafkaWriter
+	topicTemplate string
+	keyColumns    map[string]string // Message key column per table
+	batchSize     int
+	pending       []kafka.Message
+	mu            sync.Mutex
+}
+
+// NewKafkaSink creates a sink producing to the given brokers. The topic template may contain
+// {table}; keyColumn overrides the table's parent column as the message key when set.
+func NewKafkaSink(brokers []string, topicTemplate string, keyColumn string, schema *types.Schema) *KafkaSink {
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: 10 * time.Millisecond,
+	}
+	return newKafkaSink(writer, topicTemplate, keyColumn, schema)
+}
+
+func newKafkaSink(writer kafkaWriter, topicTemplate string, keyColumn string, schema *types.Schema) *KafkaSink {
+	if topicTemplate == "" {
+		topicTemplate = defaultTopicTemplate
+	}
+
+	// Key each table's messages by the configured column or its parent column
+	keyColumns := make(map[string]string)
+	for _, table := range schema.Tables {
+		if keyColumn != "" {
+			keyColumns[table.Name] = keyColumn
+			continue
+		}
+		for _, col := range table.Columns {
+			if col.Parent {
+				keyColumns[table.Name] = col.Name
+				break
+			}
+		}
+	}
+
+	return &KafkaSink{
+		writer:        writer,
+		topicTemplate: topicTemplate,
+		keyColumns:    keyColumns,
+		batchSize:     batchSizeFromEnv(),
+	}
+}
+
+// InsertRecord serializes the record to JSON and queues it for the table's topic
+func (s *KafkaSink) InsertRecord(tableName string, record map[string]interface{}) error {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to serialize record for %s: %v", tableName, err)
+	}
+
+	message := kafka.Message{
+		Topic: strings.ReplaceAll(s.topicTemplate, "{table}", tableName),
+		Value: payload,
+	}
+	if keyColumn, ok := s.keyColumns[tableName]; ok && record[keyColumn] != nil {
+		message.Key = []byte(fmt.Sprint(record[keyColumn]))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending = append(s.pending, message)
+	if len(s.pending) >= s.batchSize {
+		return s.flushPending()
+	}
+	return nil
+}
+
+// Flush produces all queued messages
+func (s *KafkaSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flushPending()
+}
+
+// Close produces queued messages and closes the writer
+func (s *KafkaSink) Close() error {
+	err := s.Flush()
+	if closeErr := s.writer.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// flushPending writes the queued messages, callers must hold the lock
+func (s *KafkaSink) flushPending() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	messages := s.pending
+	s.pending = nil
+
+	if err := s.writer.WriteMessages(context.Background(), messages...); err != nil {
+		return fmt.Errorf("failed to produce %d messages: %v", len(messages), err)
+	}
+	return nil
+}
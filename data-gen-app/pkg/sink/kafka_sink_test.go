@@ -0,0 +1,85 @@
+package sink
+
+import (
+	"context"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// mockKafkaWriter records produced messages instead of talking to a broker
+type mockKafkaWriter struct {
+	messages []kafka.Message
+	closed   bool
+}
+
+func (m *mockKafkaWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	m.messages = append(m.messages, msgs...)
+	return nil
+}
+
+func (m *mockKafkaWriter) Close() error {
+	m.closed = true
+	return nil
+}
+
+func TestKafkaSink(t *testing.T) {
+	schema := &types.Schema{
+		Tables: []types.Table{
+			{
+				Name: "users",
+				Columns: []types.Column{
+					{Name: "id", Parent: true},
+					{Name: "name"},
+				},
+			},
+		},
+	}
+
+	writer := &mockKafkaWriter{}
+	sink := newKafkaSink(writer, "loadtest.{table}", "", schema)
+
+	err := sink.InsertRecord("users", map[string]interface{}{
+		"id":   "USER001",
+		"name": "John Doe",
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, sink.Close())
+
+	assert.True(t, writer.closed)
+	assert.Len(t, writer.messages, 1)
+	message := writer.messages[0]
+	assert.Equal(t, "loadtest.users", message.Topic)
+	assert.Equal(t, "USER001", string(message.Key))
+	assert.JSONEq(t, `{"id":"USER001","name":"John Doe"}`, string(message.Value))
+}
+
+func TestKafkaSinkKeyColumnOverride(t *testing.T) {
+	schema := &types.Schema{
+		Tables: []types.Table{
+			{
+				Name: "orders",
+				Columns: []types.Column{
+					{Name: "id", Parent: true},
+					{Name: "customer_id"},
+				},
+			},
+		},
+	}
+
+	writer := &mockKafkaWriter{}
+	sink := newKafkaSink(writer, "", "customer_id", schema)
+
+	err := sink.InsertRecord("orders", map[string]interface{}{
+		"id":          "ORDER001",
+		"customer_id": "CUST042",
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, sink.Flush())
+
+	assert.Len(t, writer.messages, 1)
+	assert.Equal(t, "orders", writer.messages[0].Topic)
+	assert.Equal(t, "CUST042", string(writer.messages[0].Key))
+}
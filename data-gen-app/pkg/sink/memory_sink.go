@@ -0,0 +1,53 @@
+package sink
+
+import "sync"
+
+// MemorySink implements DataSink interface by keeping records in memory, grouped by table
+type MemorySink struct {
+	tables  []string // Tables in the order their first record arrived
+	records map[string][]map[string]interface{}
+	mu      sync.Mutex
+}
+
+// NewMemorySink creates an empty in-memory sink
+func NewMemorySink() *MemorySink {
+	return &MemorySink{
+		records: make(map[string][]map[string]interface{}),
+	}
+}
+
+// InsertRecord appends the record to its table's rows
+func (s *MemorySink) InsertRecord(tableName string, record map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.records[tableName]; !exists {
+		s.tables = append(s.tables, tableName)
+	}
+	s.records[tableName] = append(s.records[tableName], record)
+	return nil
+}
+
+// Flush is a no-op since records are kept as they are inserted
+func (s *MemorySink) Flush() error {
+	return nil
+}
+
+// Close is a no-op, records stay available after generation finishes
+func (s *MemorySink) Close() error {
+	return nil
+}
+
+// Tables returns the names of tables that received records, in generation order
+func (s *MemorySink) Tables() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.tables...)
+}
+
+// Records returns the rows inserted into tableName
+func (s *MemorySink) Records(tableName string) []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records[tableName]
+}
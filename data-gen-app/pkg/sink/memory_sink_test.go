@@ -0,0 +1,21 @@
+package sink
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemorySink(t *testing.T) {
+	sink := NewMemorySink()
+
+	assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER001"}))
+	assert.NoError(t, sink.InsertRecord("orders", map[string]interface{}{"id": "ORDER001"}))
+	assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER002"}))
+	assert.NoError(t, sink.Close())
+
+	assert.Equal(t, []string{"users", "orders"}, sink.Tables())
+	assert.Equal(t, []map[string]interface{}{{"id": "USER001"}, {"id": "USER002"}}, sink.Records("users"))
+	assert.Len(t, sink.Records("orders"), 1)
+	assert.Empty(t, sink.Records("missing"))
+}
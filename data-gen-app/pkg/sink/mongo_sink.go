@@ -0,0 +1,127 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// mongoCollection is the subset of *mongo.Collection used by the sink
+type mongoCollection interface {
+	InsertMany(ctx context.Context, documents interface{}, opts ...options.Lister[options.InsertManyOptions]) (*mongo.InsertManyResult, error)
+}
+
+// MongoSink implements DataSink interface for MongoDB, one collection per table
+type MongoSink struct {
+	client     *mongo.Client
+	collection func(name string) mongoCollection
+	batchSize  int
+	batches    map[string][]interface{}
+	tables     []string // Collections in the order their first document arrived, parents first
+	mu         sync.Mutex
+}
+
+// NewMongoSink connects to the MongoDB deployment at uri and writes into database
+func NewMongoSink(uri string, database string) (*MongoSink, error) {
+	client, err := mongo.Connect(options.Client().ApplyURI(uri))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to mongo: %v", err)
+	}
+
+	db := client.Database(database)
+	return &MongoSink{
+		client: client,
+		collection: func(name string) mongoCollection {
+			return db.Collection(name)
+		},
+		batchSize: batchSizeFromEnv(),
+		batches:   make(map[string][]interface{}),
+	}, nil
+}
+
+// InsertRecord buffers the record as a document and writes a batch once it is full
+func (s *MongoSink) InsertRecord(tableName string, record map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.batches[tableName]) == 0 {
+		s.tables = appendTable(s.tables, tableName)
+	}
+	s.batches[tableName] = append(s.batches[tableName], toDocument(record))
+	if len(s.batches[tableName]) >= s.batchSize {
+		return s.flushCollection(tableName)
+	}
+	return nil
+}
+
+// Flush writes all buffered documents, parent collections before their children
+func (s *MongoSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errors []string
+	for _, tableName := range s.tables {
+		if err := s.flushCollection(tableName); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+	if len(errors) > 0 {
+		return fmt.Errorf("errors while flushing mongo sink: %s", strings.Join(errors, "; "))
+	}
+	return nil
+}
+
+// Close flushes buffered documents and disconnects the client
+func (s *MongoSink) Close() error {
+	err := s.Flush()
+	if s.client != nil {
+		if disconnectErr := s.client.Disconnect(context.Background()); disconnectErr != nil && err == nil {
+			err = disconnectErr
+		}
+	}
+	return err
+}
+
+// flushCollection inserts the buffered documents of a single table, callers must hold the lock
+func (s *MongoSink) flushCollection(tableName string) error {
+	documents := s.batches[tableName]
+	if len(documents) == 0 {
+		return nil
+	}
+	delete(s.batches, tableName)
+
+	if _, err := s.collection(tableName).InsertMany(context.Background(), documents); err != nil {
+		return fmt.Errorf("failed to insert %d documents into %s: %v", len(documents), tableName, err)
+	}
+	return nil
+}
+
+// toDocument converts a record into a BSON document, keeping nested maps as
+// subdocuments and slices as arrays
+func toDocument(record map[string]interface{}) bson.M {
+	document := make(bson.M, len(record))
+	for key, value := range record {
+		document[key] = toBSONValue(value)
+	}
+	return document
+}
+
+func toBSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return toDocument(v)
+	case []interface{}:
+		array := make(bson.A, len(v))
+		for i, element := range v {
+			array[i] = toBSONValue(element)
+		}
+		return array
+	default:
+		return v
+	}
+}
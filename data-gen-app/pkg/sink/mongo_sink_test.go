@@ -0,0 +1,85 @@
+package sink
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// mockMongoCollection records the documents passed to InsertMany
+type mockMongoCollection struct {
+	inserts [][]interface{}
+}
+
+func (m *mockMongoCollection) InsertMany(ctx context.Context, documents interface{}, opts ...options.Lister[options.InsertManyOptions]) (*mongo.InsertManyResult, error) {
+	m.inserts = append(m.inserts, documents.([]interface{}))
+	return &mongo.InsertManyResult{}, nil
+}
+
+func TestMongoSink(t *testing.T) {
+	collections := make(map[string]*mockMongoCollection)
+	sink := &MongoSink{
+		collection: func(name string) mongoCollection {
+			if collections[name] == nil {
+				collections[name] = &mockMongoCollection{}
+			}
+			return collections[name]
+		},
+		batchSize: 2,
+		batches:   make(map[string][]interface{}),
+	}
+
+	record := map[string]interface{}{
+		"id": "USER001",
+		"address": map[string]interface{}{
+			"street": "Main St",
+			"city":   "Springfield",
+		},
+		"phone_numbers": []interface{}{"+1-555-000-0001", "+1-555-000-0002"},
+	}
+
+	assert.NoError(t, sink.InsertRecord("users", record))
+	assert.Empty(t, collections)
+
+	// The second record fills the batch and is written with InsertMany
+	assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER002"}))
+	assert.Len(t, collections["users"].inserts, 1)
+	assert.Len(t, collections["users"].inserts[0], 2)
+
+	expected := bson.M{
+		"id": "USER001",
+		"address": bson.M{
+			"street": "Main St",
+			"city":   "Springfield",
+		},
+		"phone_numbers": bson.A{"+1-555-000-0001", "+1-555-000-0002"},
+	}
+	assert.Equal(t, expected, collections["users"].inserts[0][0])
+
+	// Partial batches are written on close
+	assert.NoError(t, sink.InsertRecord("orders", map[string]interface{}{"id": "ORDER001"}))
+	assert.NoError(t, sink.Close())
+	assert.Len(t, collections["orders"].inserts, 1)
+}
+
+func TestMongoSinkFlushOrder(t *testing.T) {
+	var flushed []string
+	sink := &MongoSink{
+		collection: func(name string) mongoCollection {
+			flushed = append(flushed, name)
+			return &mockMongoCollection{}
+		},
+		batchSize: 10,
+		batches:   make(map[string][]interface{}),
+	}
+
+	// Collections flush in the order their first document arrived, parents first
+	assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER001"}))
+	assert.NoError(t, sink.InsertRecord("accounts", map[string]interface{}{"user_id": "USER001"}))
+	assert.NoError(t, sink.Flush())
+	assert.Equal(t, []string{"users", "accounts"}, flushed)
+}
@@ -0,0 +1,94 @@
+package sink
+
+import (
+	"compress/gzip"
+	"fmt"
+	"os"
+)
+
+// CompressionGzip wraps file output in a gzip stream
+const CompressionGzip = "gzip"
+
+// outputFile is a file opened for writing, optionally wrapped in a compressor
+type outputFile struct {
+	file *os.File
+	gz   *gzip.Writer
+}
+
+// createOutputFile creates the file at path, appending the compression extension when needed
+func createOutputFile(path string, compression string) (*outputFile, error) {
+	file, err := os.Create(outputPath(path, compression))
+	if err != nil {
+		return nil, err
+	}
+	return newOutputFile(file, compression), nil
+}
+
+// appendOutputFile opens the file at path for appending, creating it when it does
+// not exist, and reports whether it already held data. Compressed output is added
+// as a new gzip member, which readers decompress as one stream.
+func appendOutputFile(path string, compression string) (*outputFile, bool, error) {
+	file, err := os.OpenFile(outputPath(path, compression), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, false, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, false, err
+	}
+	return newOutputFile(file, compression), info.Size() > 0, nil
+}
+
+// outputPath returns path with the compression extension when needed
+func outputPath(path string, compression string) string {
+	if compression == CompressionGzip {
+		return path + ".gz"
+	}
+	return path
+}
+
+func newOutputFile(file *os.File, compression string) *outputFile {
+	out := &outputFile{file: file}
+	if compression == CompressionGzip {
+		out.gz = gzip.NewWriter(file)
+	}
+	return out
+}
+
+// validateCompression returns an error for unsupported compression modes
+func validateCompression(compression string) error {
+	switch compression {
+	case "", CompressionGzip:
+		return nil
+	default:
+		return fmt.Errorf("unsupported compression: %s", compression)
+	}
+}
+
+// Write writes p through the compressor if there is one
+func (f *outputFile) Write(p []byte) (int, error) {
+	if f.gz != nil {
+		return f.gz.Write(p)
+	}
+	return f.file.Write(p)
+}
+
+// Flush pushes compressed data written so far to the file
+func (f *outputFile) Flush() error {
+	if f.gz != nil {
+		return f.gz.Flush()
+	}
+	return nil
+}
+
+// Close finishes the compressed stream and closes the file
+func (f *outputFile) Close() error {
+	if f.gz != nil {
+		if err := f.gz.Close(); err != nil {
+			f.file.Close()
+			return err
+		}
+	}
+	return f.file.Close()
+}
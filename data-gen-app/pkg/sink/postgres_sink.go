@@ -1,34 +1,443 @@
 package sink
 
 import (
+	"fmt"
 	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-pg/pg/v10"
 )
 
+// defaultBatchSize is the number of rows buffered per table before a bulk insert
+const defaultBatchSize = 1000
+
+// Transaction modes of the Postgres sink
+const (
+	TransactionBatch = "batch" // Each batch is inserted in its own transaction
+	TransactionFlush = "flush" // Batches share a transaction committed when the sink is flushed or closed
+)
+
+// Conflict actions of the Postgres sink, taken when an inserted row violates a unique constraint
+const (
+	ConflictNothing = "nothing" // The conflicting row is skipped
+	ConflictUpdate  = "update"  // The existing row is updated with the inserted values
+)
+
+// pgConflict is the ON CONFLICT clause added to the Postgres sink's inserts
+type pgConflict struct {
+	action string   // ConflictNothing, ConflictUpdate or empty to fail on conflicts
+	target []string // Columns of the unique constraint of tables without their own target
+	// targets holds the unique constraint columns of individual tables
+	targets map[string][]string
+}
+
+// targetFor returns the columns of tableName's unique constraint, if any
+func (c pgConflict) targetFor(tableName string) []string {
+	if target, ok := c.targets[tableName]; ok {
+		return target
+	}
+	return c.target
+}
+
+// clause returns the ON CONFLICT clause for an insert of columns into tableName,
+// updating every column outside the table's target. Without a target there is
+// nothing to update by, so conflicting rows are skipped.
+func (c pgConflict) clause(tableName string, columns []string) string {
+	if c.action == "" {
+		return ""
+	}
+	target := c.targetFor(tableName)
+	clause := " ON CONFLICT"
+	if len(target) > 0 {
+		clause += " (" + quoteIdents(target) + ")"
+	}
+	inTarget := make(map[string]bool, len(target))
+	for _, col := range target {
+		inTarget[col] = true
+	}
+	var set []string
+	for _, col := range columns {
+		if !inTarget[col] {
+			set = append(set, quoteIdent(col)+" = EXCLUDED."+quoteIdent(col))
+		}
+	}
+	if c.action == ConflictNothing || len(target) == 0 || len(set) == 0 {
+		return clause + " DO NOTHING"
+	}
+	return clause + " DO UPDATE SET " + strings.Join(set, ", ")
+}
+
+// dedupe keeps the last of the rows sharing a target key when conflicts update.
+// Postgres rejects an insert that would update the same row twice, so within a
+// batch the latest values win as they would across batches.
+func (c pgConflict) dedupe(tableName string, rows []map[string]interface{}) []map[string]interface{} {
+	target := c.targetFor(tableName)
+	if c.action != ConflictUpdate || len(target) == 0 {
+		return rows
+	}
+	positions := make(map[string]int, len(rows))
+	deduped := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		values := make([]string, len(target))
+		for i, col := range target {
+			values[i] = fmt.Sprint(row[col])
+		}
+		key := strings.Join(values, "\x00")
+		if i, ok := positions[key]; ok {
+			deduped[i] = row
+			continue
+		}
+		positions[key] = len(deduped)
+		deduped = append(deduped, row)
+	}
+	return deduped
+}
+
+// conflictFromEnv reads ON_CONFLICT and CONFLICT_TARGET, by default conflicts fail
+// the insert. CONFLICT_TARGET lists columns for every table, or table.column for
+// the tables named, which override the plain columns.
+func conflictFromEnv() (pgConflict, error) {
+	conflict := pgConflict{action: os.Getenv("ON_CONFLICT")}
+	if target := os.Getenv("CONFLICT_TARGET"); target != "" {
+		for _, col := range strings.Split(target, ",") {
+			col = strings.TrimSpace(col)
+			if tableName, column, ok := strings.Cut(col, "."); ok {
+				if conflict.targets == nil {
+					conflict.targets = make(map[string][]string)
+				}
+				conflict.targets[tableName] = append(conflict.targets[tableName], column)
+				continue
+			}
+			conflict.target = append(conflict.target, col)
+		}
+	}
+	switch conflict.action {
+	case "", ConflictNothing:
+	case ConflictUpdate:
+		if len(conflict.target) == 0 && len(conflict.targets) == 0 {
+			return conflict, fmt.Errorf("ON_CONFLICT %s requires CONFLICT_TARGET", ConflictUpdate)
+		}
+	default:
+		return conflict, fmt.Errorf("invalid ON_CONFLICT %q, expected %s or %s", conflict.action, ConflictNothing, ConflictUpdate)
+	}
+	return conflict, nil
+}
+
+// batchInsertFunc writes a batch of rows to a table
+type batchInsertFunc func(tableName string, rows []map[string]interface{}) error
+
+// pgTx is a transaction batches are inserted in
+type pgTx interface {
+	Insert(tableName string, rows []map[string]interface{}) error
+	Commit() error
+	Rollback() error
+}
+
 type pgDataSink struct {
-	db      pg.DB
-	profile string
+	db        *pg.DB
+	profile   string
+	batchSize int
+	batches   map[string][]map[string]interface{}
+	tables    []string // Tables in the order their first record arrived, parents first
+	insert    batchInsertFunc
+	retry     RetryPolicy // Applied to each batch insert
+	mu        sync.Mutex
+	// transactions is TransactionBatch, TransactionFlush or empty to insert without one
+	transactions string
+	begin        func() (pgTx, error)
+	tx           pgTx // Open transaction of TransactionFlush, nil until the next batch
+	// failed is the error that rolled back the TransactionFlush transaction, after
+	// which the remaining batches are dropped so the load stays all or nothing
+	failed error
+	// conflict is added to every insert, so re-runs can skip or update existing rows
+	conflict pgConflict
 }
 
 // InsertRecord implements DataSink.
 func (pgDataSink *pgDataSink) InsertRecord(tableName string, data map[string]interface{}) error {
-	_, err := pgDataSink.db.Model(&data).TableExpr(tableName).Insert()
+	pgDataSink.mu.Lock()
+	defer pgDataSink.mu.Unlock()
+
+	if pgDataSink.failed != nil {
+		return pgDataSink.rolledBack()
+	}
+	if len(pgDataSink.batches[tableName]) == 0 {
+		pgDataSink.tables = appendTable(pgDataSink.tables, tableName)
+	}
+	pgDataSink.batches[tableName] = append(pgDataSink.batches[tableName], data)
+	if len(pgDataSink.batches[tableName]) >= pgDataSink.batchSize {
+		return pgDataSink.flushTable(tableName)
+	}
+	return nil
+}
+
+// Flush writes all buffered rows to the database
+func (pgDataSink *pgDataSink) Flush() error {
+	pgDataSink.mu.Lock()
+	defer pgDataSink.mu.Unlock()
+
+	if pgDataSink.failed != nil {
+		pgDataSink.batches = make(map[string][]map[string]interface{})
+		return pgDataSink.rolledBack()
+	}
+
+	// Flush in dependency order so parent tables land before their children
+	var errors []string
+	for _, tableName := range pgDataSink.tables {
+		if err := pgDataSink.flushTable(tableName); err != nil {
+			errors = append(errors, err.Error())
+			if pgDataSink.transactions == TransactionFlush {
+				// The transaction holding the other tables' batches was rolled back
+				break
+			}
+		}
+	}
+	if len(errors) == 0 && pgDataSink.tx != nil {
+		tx := pgDataSink.tx
+		pgDataSink.tx = nil
+		if err := tx.Commit(); err != nil {
+			pgDataSink.failed = fmt.Errorf("failed to commit transaction: %v", err)
+			errors = append(errors, pgDataSink.failed.Error())
+		}
+	}
+	if pgDataSink.failed != nil {
+		// Rows of the tables after the failure would otherwise land in a new transaction
+		pgDataSink.batches = make(map[string][]map[string]interface{})
+	}
+	if len(errors) > 0 {
+		return fmt.Errorf("errors while flushing postgres sink: %s", strings.Join(errors, "; "))
+	}
+	return nil
+}
+
+// Close flushes buffered rows and closes the database connection
+func (pgDataSink *pgDataSink) Close() error {
+	err := pgDataSink.Flush()
+	if pgDataSink.db != nil {
+		if closeErr := pgDataSink.db.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// flushTable bulk inserts the buffered rows of a single table, callers must hold the lock
+func (pgDataSink *pgDataSink) flushTable(tableName string) error {
+	rows := pgDataSink.batches[tableName]
+	if len(rows) == 0 {
+		return nil
+	}
+	delete(pgDataSink.batches, tableName)
+
+	normalized := pgDataSink.conflict.dedupe(tableName, normalizeRows(rows))
+	var err error
+	switch pgDataSink.transactions {
+	case TransactionFlush:
+		// An aborted transaction cannot be retried, it is rolled back with every batch it held
+		if err = pgDataSink.insertInFlushTransaction(tableName, normalized); err != nil {
+			pgDataSink.failed = fmt.Errorf("failed to insert %d rows into %s: %v", len(rows), tableName, err)
+		}
+	case TransactionBatch:
+		err = pgDataSink.retry.do(func() error {
+			return pgDataSink.insertInTransaction(tableName, normalized)
+		})
+	default:
+		err = pgDataSink.retry.do(func() error {
+			return pgDataSink.insert(tableName, normalized)
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert %d rows into %s: %v", len(rows), tableName, err)
+	}
+	return nil
+}
+
+// rolledBack reports the failure that rolled back the TransactionFlush transaction
+func (pgDataSink *pgDataSink) rolledBack() error {
+	return fmt.Errorf("postgres sink transaction was rolled back, dropping remaining rows: %v", pgDataSink.failed)
+}
+
+// insertInTransaction inserts rows in a transaction of their own, rolled back when the insert fails
+func (pgDataSink *pgDataSink) insertInTransaction(tableName string, rows []map[string]interface{}) error {
+	tx, err := pgDataSink.begin()
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	if err := tx.Insert(tableName, rows); err != nil {
+		return rollback(tx, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// insertInFlushTransaction inserts rows in the transaction open until the next flush,
+// beginning it when needed, and rolls it back when the insert fails
+func (pgDataSink *pgDataSink) insertInFlushTransaction(tableName string, rows []map[string]interface{}) error {
+	if pgDataSink.tx == nil {
+		tx, err := pgDataSink.begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %v", err)
+		}
+		pgDataSink.tx = tx
+	}
+	if err := pgDataSink.tx.Insert(tableName, rows); err != nil {
+		tx := pgDataSink.tx
+		pgDataSink.tx = nil
+		return rollback(tx, err)
 	}
 	return nil
 }
 
+// rollback rolls tx back after err, reporting both errors when the rollback fails too
+func rollback(tx pgTx, err error) error {
+	if rollbackErr := tx.Rollback(); rollbackErr != nil {
+		return fmt.Errorf("%v, rollback failed: %v", err, rollbackErr)
+	}
+	return fmt.Errorf("%v, transaction rolled back", err)
+}
+
+// goPgTx inserts batches in a go-pg transaction
+type goPgTx struct {
+	tx       *pg.Tx
+	conflict pgConflict
+}
+
+func (t goPgTx) Insert(tableName string, rows []map[string]interface{}) error {
+	query, params := insertStatement(tableName, rows, t.conflict)
+	_, err := t.tx.Exec(query, params...)
+	return err
+}
+
+func (t goPgTx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t goPgTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// beginTx begins a go-pg transaction
+func (pgDataSink *pgDataSink) beginTx() (pgTx, error) {
+	tx, err := pgDataSink.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return goPgTx{tx: tx, conflict: pgDataSink.conflict}, nil
+}
+
+// insertRows performs a multi-row insert using go-pg
+func (pgDataSink *pgDataSink) insertRows(tableName string, rows []map[string]interface{}) error {
+	query, params := insertStatement(tableName, rows, pgDataSink.conflict)
+	_, err := pgDataSink.db.Exec(query, params...)
+	return err
+}
+
+// insertStatement builds a multi-row INSERT of normalized rows with go-pg ?
+// placeholders for the values, in sorted column order
+func insertStatement(tableName string, rows []map[string]interface{}, conflict pgConflict) (string, []interface{}) {
+	columns := make([]string, 0, len(rows[0]))
+	for col := range rows[0] {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	values := make([]string, len(rows))
+	params := make([]interface{}, 0, len(rows)*len(columns))
+	for i, row := range rows {
+		values[i] = placeholders
+		for _, col := range columns {
+			params = append(params, row[col])
+		}
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s%s",
+		tableName, quoteIdents(columns), strings.Join(values, ", "), conflict.clause(tableName, columns))
+	return query, params
+}
+
+// quoteIdents quotes and comma separates identifiers
+func quoteIdents(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdent(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// normalizeRows gives every row the same set of keys, since a multi-row insert
+// derives its column list from the rows and nil values are omitted by the generator
+func normalizeRows(rows []map[string]interface{}) []map[string]interface{} {
+	keys := make(map[string]bool)
+	for _, row := range rows {
+		for key := range row {
+			keys[key] = true
+		}
+	}
+
+	normalized := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		normalized[i] = make(map[string]interface{}, len(keys))
+		for key := range keys {
+			normalized[i][key] = row[key]
+		}
+	}
+	return normalized
+}
+
+// NewPgDataSink creates a sink bulk inserting batches of BATCH_SIZE rows into
+// the generator's Postgres database, retrying transient failures of a batch as
+// RETRY_ATTEMPTS and RETRY_DELAY configure and, with TRANSACTIONS, inserting in
+// transactions per batch or per flush, and resolving unique conflicts as
+// ON_CONFLICT and CONFLICT_TARGET configure
 func NewPgDataSink(p string) DataSink {
-	return &pgDataSink{
-		db:      *pgConnection(),
-		profile: p,
+	retry, err := RetryPolicyFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	retry.Retryable = transientPgError
+	transactions := os.Getenv("TRANSACTIONS")
+	switch transactions {
+	case "", TransactionBatch, TransactionFlush:
+	default:
+		log.Fatalf("invalid TRANSACTIONS %q, expected %s or %s", transactions, TransactionBatch, TransactionFlush)
+	}
+	conflict, err := conflictFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sink := &pgDataSink{
+		db:        PgConnection(),
+		profile:   p,
+		batchSize: batchSizeFromEnv(),
+		batches:   make(map[string][]map[string]interface{}),
+		retry:     retry,
+
+		transactions: transactions,
+		conflict:     conflict,
+	}
+	sink.insert = sink.insertRows
+	sink.begin = sink.beginTx
+	return sink
+}
+
+// batchSizeFromEnv reads the BATCH_SIZE env var, falling back to the default
+func batchSizeFromEnv() int {
+	if size, err := strconv.Atoi(os.Getenv("BATCH_SIZE")); err == nil && size > 0 {
+		return size
 	}
+	return defaultBatchSize
 }
 
-func pgConnection() *pg.DB {
+// PgConnection connects to the generator's Postgres database, retrying while it starts up
+func PgConnection() *pg.DB {
 	opts := &pg.Options{
 		Addr:     "db:5432",
 		User:     "user",
@@ -0,0 +1,396 @@
+package sink
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestPgDataSink returns a pgDataSink that records batches instead of talking to a database
+func newTestPgDataSink(batchSize int) (*pgDataSink, *[][]map[string]interface{}) {
+	batches := make([][]map[string]interface{}, 0)
+	sink := &pgDataSink{
+		batchSize: batchSize,
+		batches:   make(map[string][]map[string]interface{}),
+	}
+	sink.insert = func(tableName string, rows []map[string]interface{}) error {
+		batches = append(batches, rows)
+		return nil
+	}
+	return sink, &batches
+}
+
+func TestPgDataSinkBatching(t *testing.T) {
+	sink, batches := newTestPgDataSink(3)
+
+	// Rows stay buffered until the batch size is reached
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": fmt.Sprintf("USER%d", i)}))
+	}
+	assert.Empty(t, *batches)
+
+	// The third row triggers a flush of the whole batch
+	assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER2"}))
+	assert.Len(t, *batches, 1)
+	assert.Len(t, (*batches)[0], 3)
+
+	// Remaining rows are flushed on close
+	assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER3"}))
+	assert.Len(t, *batches, 1)
+	assert.NoError(t, sink.Close())
+	assert.Len(t, *batches, 2)
+	assert.Len(t, (*batches)[1], 1)
+}
+
+func TestPgDataSinkFlushError(t *testing.T) {
+	sink, _ := newTestPgDataSink(10)
+	sink.insert = func(tableName string, rows []map[string]interface{}) error {
+		return fmt.Errorf("connection refused")
+	}
+
+	assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER1"}))
+	err := sink.Flush()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "users")
+}
+
+func TestPgDataSinkFlushOrder(t *testing.T) {
+	sink, _ := newTestPgDataSink(10)
+	var tables []string
+	sink.insert = func(tableName string, rows []map[string]interface{}) error {
+		tables = append(tables, tableName)
+		return nil
+	}
+
+	// Tables flush in the order their first record arrived, parents before children
+	// even when the child sorts first
+	assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER1"}))
+	assert.NoError(t, sink.InsertRecord("accounts", map[string]interface{}{"user_id": "USER1"}))
+	assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER2"}))
+	assert.NoError(t, sink.Flush())
+	assert.Equal(t, []string{"users", "accounts"}, tables)
+
+	assert.NoError(t, sink.InsertRecord("accounts", map[string]interface{}{"user_id": "USER2"}))
+	assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER3"}))
+	assert.NoError(t, sink.Flush())
+	assert.Equal(t, []string{"users", "accounts", "users", "accounts"}, tables)
+}
+
+func TestNormalizeRows(t *testing.T) {
+	rows := normalizeRows([]map[string]interface{}{
+		{"id": "USER1", "name": "John Doe"},
+		{"id": "USER2"},
+	})
+
+	assert.Equal(t, map[string]interface{}{"id": "USER1", "name": "John Doe"}, rows[0])
+	assert.Equal(t, map[string]interface{}{"id": "USER2", "name": nil}, rows[1])
+}
+
+// recordingTx records the transaction calls of a pgDataSink into calls, failing inserts into failTable
+type recordingTx struct {
+	calls     *[]string
+	failTable string
+}
+
+func (tx recordingTx) Insert(tableName string, rows []map[string]interface{}) error {
+	*tx.calls = append(*tx.calls, fmt.Sprintf("insert %d %s", len(rows), tableName))
+	if tableName == tx.failTable {
+		return fmt.Errorf("deadlock detected")
+	}
+	return nil
+}
+
+func (tx recordingTx) Commit() error {
+	*tx.calls = append(*tx.calls, "commit")
+	return nil
+}
+
+func (tx recordingTx) Rollback() error {
+	*tx.calls = append(*tx.calls, "rollback")
+	return nil
+}
+
+// newTransactionalPgDataSink returns a pgDataSink in the given transaction mode
+// whose transactions record their calls, failing inserts into failTable
+func newTransactionalPgDataSink(batchSize int, transactions string, failTable string) (*pgDataSink, *[]string) {
+	sink, _ := newTestPgDataSink(batchSize)
+	calls := make([]string, 0)
+	sink.transactions = transactions
+	sink.insert = func(tableName string, rows []map[string]interface{}) error {
+		return fmt.Errorf("inserted outside a transaction")
+	}
+	sink.begin = func() (pgTx, error) {
+		calls = append(calls, "begin")
+		return recordingTx{calls: &calls, failTable: failTable}, nil
+	}
+	return sink, &calls
+}
+
+func TestPgDataSinkBatchTransactions(t *testing.T) {
+	sink, calls := newTransactionalPgDataSink(2, TransactionBatch, "orders")
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": fmt.Sprintf("USER%d", i)}))
+	}
+	assert.Equal(t, []string{"begin", "insert 2 users", "commit"}, *calls)
+
+	assert.NoError(t, sink.InsertRecord("orders", map[string]interface{}{"id": "ORDER1"}))
+	err := sink.Flush()
+	assert.ErrorContains(t, err, "failed to insert 1 rows into orders: deadlock detected, transaction rolled back")
+	assert.Equal(t, []string{
+		"begin", "insert 2 users", "commit",
+		"begin", "insert 1 users", "commit",
+		"begin", "insert 1 orders", "rollback",
+	}, *calls)
+}
+
+func TestPgDataSinkFlushTransaction(t *testing.T) {
+	t.Run("Commit on flush", func(t *testing.T) {
+		sink, calls := newTransactionalPgDataSink(2, TransactionFlush, "")
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": fmt.Sprintf("USER%d", i)}))
+		}
+		assert.NoError(t, sink.InsertRecord("orders", map[string]interface{}{"id": "ORDER1"}))
+		// A full batch is inserted but not committed
+		assert.Equal(t, []string{"begin", "insert 2 users"}, *calls)
+
+		assert.NoError(t, sink.Close())
+		assert.Equal(t, []string{"begin", "insert 2 users", "insert 1 users", "insert 1 orders", "commit"}, *calls)
+	})
+
+	t.Run("Rollback on error", func(t *testing.T) {
+		sink, calls := newTransactionalPgDataSink(10, TransactionFlush, "orders")
+		assert.NoError(t, sink.InsertRecord("customers", map[string]interface{}{"id": "CUST1"}))
+		assert.NoError(t, sink.InsertRecord("orders", map[string]interface{}{"id": "ORDER1"}))
+		assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER1"}))
+
+		err := sink.Flush()
+		assert.ErrorContains(t, err, "failed to insert 1 rows into orders: deadlock detected, transaction rolled back")
+		// Tables after the failure are not inserted and nothing is committed
+		assert.Equal(t, []string{"begin", "insert 1 customers", "insert 1 orders", "rollback"}, *calls)
+		assert.Error(t, sink.Close())
+		assert.Equal(t, []string{"begin", "insert 1 customers", "insert 1 orders", "rollback"}, *calls)
+	})
+
+	t.Run("Failed batch drops the rest", func(t *testing.T) {
+		sink, calls := newTransactionalPgDataSink(2, TransactionFlush, "orders")
+		assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER1"}))
+		assert.NoError(t, sink.InsertRecord("orders", map[string]interface{}{"id": "ORDER1"}))
+		err := sink.InsertRecord("orders", map[string]interface{}{"id": "ORDER2"})
+		assert.ErrorContains(t, err, "deadlock detected, transaction rolled back")
+
+		// The buffered users row must not be committed in a transaction of its own
+		assert.ErrorContains(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER2"}), "rolled back, dropping remaining rows")
+		assert.ErrorContains(t, sink.Close(), "rolled back, dropping remaining rows")
+		assert.Equal(t, []string{"begin", "insert 2 orders", "rollback"}, *calls)
+	})
+}
+
+func TestInsertStatement(t *testing.T) {
+	rows := normalizeRows([]map[string]interface{}{
+		{"id": "USER1", "name": "John Doe"},
+		{"id": "USER2"},
+	})
+
+	tests := []struct {
+		name     string
+		conflict pgConflict
+		want     string
+	}{
+		{"No conflict clause", pgConflict{}, `INSERT INTO users ("id", "name") VALUES (?, ?), (?, ?)`},
+		{"Do nothing", pgConflict{action: ConflictNothing},
+			`INSERT INTO users ("id", "name") VALUES (?, ?), (?, ?) ON CONFLICT DO NOTHING`},
+		{"Do nothing on target", pgConflict{action: ConflictNothing, target: []string{"id"}},
+			`INSERT INTO users ("id", "name") VALUES (?, ?), (?, ?) ON CONFLICT ("id") DO NOTHING`},
+		{"Update", pgConflict{action: ConflictUpdate, target: []string{"id"}},
+			`INSERT INTO users ("id", "name") VALUES (?, ?), (?, ?) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`},
+		{"Update without other columns", pgConflict{action: ConflictUpdate, target: []string{"id", "name"}},
+			`INSERT INTO users ("id", "name") VALUES (?, ?), (?, ?) ON CONFLICT ("id", "name") DO NOTHING`},
+		{"Update on the table's target", pgConflict{action: ConflictUpdate, target: []string{"name"}, targets: map[string][]string{"users": {"id"}}},
+			`INSERT INTO users ("id", "name") VALUES (?, ?), (?, ?) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`},
+		{"Update without a target for the table", pgConflict{action: ConflictUpdate, targets: map[string][]string{"orders": {"id"}}},
+			`INSERT INTO users ("id", "name") VALUES (?, ?), (?, ?) ON CONFLICT DO NOTHING`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, params := insertStatement("users", rows, tt.conflict)
+			assert.Equal(t, tt.want, query)
+			assert.Equal(t, []interface{}{"USER1", "John Doe", "USER2", nil}, params)
+		})
+	}
+}
+
+func TestConflictFromEnv(t *testing.T) {
+	conflict, err := conflictFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, pgConflict{}, conflict)
+
+	t.Setenv("ON_CONFLICT", "update")
+	t.Setenv("CONFLICT_TARGET", "tenant_id, id")
+	conflict, err = conflictFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, pgConflict{action: ConflictUpdate, target: []string{"tenant_id", "id"}}, conflict)
+
+	// Qualified columns set the targets of single tables
+	t.Setenv("CONFLICT_TARGET", "id, order_lines.order_id, order_lines.line_number")
+	conflict, err = conflictFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id"}, conflict.targetFor("users"))
+	assert.Equal(t, []string{"order_id", "line_number"}, conflict.targetFor("order_lines"))
+
+	t.Setenv("CONFLICT_TARGET", "")
+	_, err = conflictFromEnv()
+	assert.EqualError(t, err, "ON_CONFLICT update requires CONFLICT_TARGET")
+
+	t.Setenv("ON_CONFLICT", "ignore")
+	_, err = conflictFromEnv()
+	assert.Error(t, err)
+}
+
+func TestConflictDedupe(t *testing.T) {
+	rows := []map[string]interface{}{
+		{"id": "USER1", "name": "first"},
+		{"id": "USER2", "name": "second"},
+		{"id": "USER1", "name": "third"},
+	}
+
+	// Updating the same row twice in one insert fails, so the last values win
+	update := pgConflict{action: ConflictUpdate, targets: map[string][]string{"users": {"id"}}}
+	assert.Equal(t, []map[string]interface{}{
+		{"id": "USER1", "name": "third"},
+		{"id": "USER2", "name": "second"},
+	}, update.dedupe("users", rows))
+
+	// Skipping conflicts, or tables without a target, keep every row
+	assert.Len(t, pgConflict{action: ConflictNothing, target: []string{"id"}}.dedupe("users", rows), 3)
+	assert.Len(t, update.dedupe("orders", rows), 3)
+}
+
+// fakePgServer speaks enough of the Postgres wire protocol for go-pg to connect
+// and run simple queries, recording each query and failing those containing fail
+type fakePgServer struct {
+	mu      sync.Mutex
+	queries []string
+	fail    string
+}
+
+// connect returns a go-pg database whose connections are served by the fake server
+func (f *fakePgServer) connect() *pg.DB {
+	return pg.Connect(&pg.Options{
+		User: "user",
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			client, server := net.Pipe()
+			go f.serve(server)
+			return client, nil
+		},
+	})
+}
+
+func (f *fakePgServer) serve(conn net.Conn) {
+	defer conn.Close()
+	// The startup message has no type byte, authentication always succeeds
+	var length int32
+	if binary.Read(conn, binary.BigEndian, &length) != nil {
+		return
+	}
+	if _, err := io.CopyN(io.Discard, conn, int64(length-4)); err != nil {
+		return
+	}
+	writePgMessage(conn, 'R', []byte{0, 0, 0, 0})
+	writePgMessage(conn, 'Z', []byte{'I'})
+
+	for {
+		header := make([]byte, 5)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		body := make([]byte, binary.BigEndian.Uint32(header[1:])-4)
+		if _, err := io.ReadFull(conn, body); err != nil || header[0] != 'Q' {
+			return
+		}
+		query := strings.TrimSuffix(string(body), "\x00")
+		f.mu.Lock()
+		f.queries = append(f.queries, query)
+		f.mu.Unlock()
+
+		if f.fail != "" && strings.Contains(query, f.fail) {
+			writePgMessage(conn, 'E', []byte("SERROR\x00C40P01\x00Mdeadlock detected\x00\x00"))
+			writePgMessage(conn, 'Z', []byte{'E'})
+			continue
+		}
+		tag, _, _ := strings.Cut(query, " ")
+		if tag == "INSERT" {
+			tag = "INSERT 0 1"
+		}
+		writePgMessage(conn, 'C', append([]byte(tag), 0))
+		writePgMessage(conn, 'Z', []byte{'T'})
+	}
+}
+
+// statements returns the recorded queries, inserts shortened to their table
+func (f *fakePgServer) statements() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	statements := make([]string, len(f.queries))
+	for i, query := range f.queries {
+		fields := strings.Fields(query)
+		if fields[0] == "INSERT" {
+			query = "INSERT " + fields[2]
+		}
+		statements[i] = query
+	}
+	return statements
+}
+
+func writePgMessage(w io.Writer, typ byte, body []byte) {
+	message := []byte{typ, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(message[1:], uint32(len(body)+4))
+	w.Write(append(message, body...))
+}
+
+func TestPgDataSinkGoPgTransactions(t *testing.T) {
+	newSink := func(server *fakePgServer, batchSize int) *pgDataSink {
+		sink := &pgDataSink{
+			db:           server.connect(),
+			batchSize:    batchSize,
+			batches:      make(map[string][]map[string]interface{}),
+			transactions: TransactionFlush,
+		}
+		sink.insert = sink.insertRows
+		sink.begin = sink.beginTx
+		return sink
+	}
+
+	t.Run("Commit", func(t *testing.T) {
+		server := &fakePgServer{}
+		sink := newSink(server, 2)
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": fmt.Sprintf("USER%d", i)}))
+		}
+		assert.NoError(t, sink.InsertRecord("orders", map[string]interface{}{"id": "ORDER1", "user_id": "USER0"}))
+		assert.NoError(t, sink.Close())
+		assert.Equal(t, []string{"BEGIN", "INSERT users", "INSERT users", "INSERT orders", "COMMIT"}, server.statements())
+		assert.Contains(t, server.queries[1], `INSERT INTO users ("id") VALUES ('USER0'), ('USER1')`)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		server := &fakePgServer{fail: "INTO orders"}
+		sink := newSink(server, 2)
+		assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER1"}))
+		assert.NoError(t, sink.InsertRecord("orders", map[string]interface{}{"id": "ORDER1"}))
+		err := sink.InsertRecord("orders", map[string]interface{}{"id": "ORDER2"})
+		assert.ErrorContains(t, err, "deadlock detected, transaction rolled back")
+
+		// The buffered users row is dropped rather than committed on close
+		assert.Error(t, sink.Close())
+		assert.Equal(t, []string{"BEGIN", "INSERT orders", "ROLLBACK"}, server.statements())
+	})
+}
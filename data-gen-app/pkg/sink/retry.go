@@ -0,0 +1,108 @@
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// RetryPolicy retries failed writes with exponential backoff, so transient
+// errors such as deadlocks or dropped connections do not abort a long run
+type RetryPolicy struct {
+	MaxAttempts int           // Attempts in total including the first, 1 or less never retries
+	BaseDelay   time.Duration // Wait before the first retry, doubling before each further one
+	// Retryable reports whether an error is worth retrying, every error is when nil
+	Retryable func(error) bool
+}
+
+// do runs op until it succeeds, fails with an error that is not retryable or
+// has been attempted MaxAttempts times, returning the last error
+func (p RetryPolicy) do(op func() error) error {
+	delay := p.BaseDelay
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= p.MaxAttempts || (p.Retryable != nil && !p.Retryable(err)) {
+			if err != nil && attempt > 1 {
+				return fmt.Errorf("%v (after %d attempts)", err, attempt)
+			}
+			return err
+		}
+		log.Printf("attempt %d of %d failed, retrying in %s: %v", attempt, p.MaxAttempts, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// RetryPolicyFromEnv reads RETRY_ATTEMPTS and RETRY_DELAY, by default a write is attempted once
+func RetryPolicyFromEnv() (RetryPolicy, error) {
+	policy := RetryPolicy{MaxAttempts: 1, BaseDelay: 500 * time.Millisecond}
+	if attempts := os.Getenv("RETRY_ATTEMPTS"); attempts != "" {
+		n, err := strconv.Atoi(attempts)
+		if err != nil || n < 1 {
+			return policy, fmt.Errorf("invalid RETRY_ATTEMPTS %q, expected a positive number", attempts)
+		}
+		policy.MaxAttempts = n
+	}
+	if delay := os.Getenv("RETRY_DELAY"); delay != "" {
+		d, err := time.ParseDuration(delay)
+		if err != nil || d < 0 {
+			return policy, fmt.Errorf("invalid RETRY_DELAY %q, expected a duration such as 500ms", delay)
+		}
+		policy.BaseDelay = d
+	}
+	return policy, nil
+}
+
+// transientPgError reports whether err is worth retrying against Postgres:
+// deadlocks and serialization failures, connection and resource errors, and
+// any error that did not come from the server, such as a dropped connection
+func transientPgError(err error) bool {
+	var pgErr pg.Error
+	if !errors.As(err, &pgErr) {
+		return true
+	}
+	code := pgErr.Field('C')
+	for _, class := range []string{"08", "40", "53", "57P"} {
+		if strings.HasPrefix(code, class) {
+			return true
+		}
+	}
+	return false
+}
+
+// RetrySink retries the inserts and flushes of a sink that fail. It suits sinks
+// that write each record as it is inserted; a batching sink would buffer the
+// record again on every attempt, which is why the Postgres sink retries its
+// batches itself.
+type RetrySink struct {
+	sink   DataSink
+	policy RetryPolicy
+}
+
+// NewRetrySink wraps sink so failed inserts and flushes are retried by policy
+func NewRetrySink(sink DataSink, policy RetryPolicy) *RetrySink {
+	return &RetrySink{sink: sink, policy: policy}
+}
+
+// InsertRecord inserts the record, retrying failures
+func (s *RetrySink) InsertRecord(tableName string, data map[string]interface{}) error {
+	return s.policy.do(func() error {
+		return s.sink.InsertRecord(tableName, data)
+	})
+}
+
+// Flush flushes the wrapped sink, retrying failures
+func (s *RetrySink) Flush() error {
+	return s.policy.do(s.sink.Flush)
+}
+
+// Close closes the wrapped sink, which is not retried as it releases resources
+func (s *RetrySink) Close() error {
+	return s.sink.Close()
+}
@@ -0,0 +1,115 @@
+package sink
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakySink fails its first failures inserts, then stores records
+type flakySink struct {
+	*MemorySink
+	failures int
+	attempts int
+}
+
+func (s *flakySink) InsertRecord(tableName string, record map[string]interface{}) error {
+	s.attempts++
+	if s.attempts <= s.failures {
+		return fmt.Errorf("connection reset by peer")
+	}
+	return s.MemorySink.InsertRecord(tableName, record)
+}
+
+// pgTestError is a Postgres server error with an SQLSTATE code
+type pgTestError string
+
+func (e pgTestError) Error() string            { return "ERROR #" + string(e) }
+func (e pgTestError) Field(field byte) string  { return string(e) }
+func (e pgTestError) IntegrityViolation() bool { return false }
+
+func TestRetrySink(t *testing.T) {
+	record := map[string]interface{}{"id": "USER1"}
+
+	flaky := &flakySink{MemorySink: NewMemorySink(), failures: 2}
+	sink := NewRetrySink(flaky, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	assert.NoError(t, sink.InsertRecord("users", record))
+	assert.Equal(t, 3, flaky.attempts)
+	assert.Equal(t, []map[string]interface{}{record}, flaky.Records("users"))
+
+	flaky = &flakySink{MemorySink: NewMemorySink(), failures: 2}
+	sink = NewRetrySink(flaky, RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond})
+	assert.EqualError(t, sink.InsertRecord("users", record), "connection reset by peer (after 2 attempts)")
+	assert.Empty(t, flaky.Records("users"))
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	attempts := 0
+	start := time.Now()
+	err := RetryPolicy{MaxAttempts: 4, BaseDelay: 10 * time.Millisecond}.do(func() error {
+		attempts++
+		return fmt.Errorf("deadlock detected")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 4, attempts)
+	// 10ms, 20ms and 40ms between the attempts
+	assert.GreaterOrEqual(t, time.Since(start), 70*time.Millisecond)
+}
+
+func TestPgDataSinkRetry(t *testing.T) {
+	sink, batches := newTestPgDataSink(10)
+	insert := sink.insert
+	failures := 2
+	sink.insert = func(tableName string, rows []map[string]interface{}) error {
+		if failures > 0 {
+			failures--
+			return pgTestError("40P01") // deadlock_detected
+		}
+		return insert(tableName, rows)
+	}
+	sink.retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, Retryable: transientPgError}
+
+	assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER1"}))
+	assert.NoError(t, sink.Flush())
+	assert.Equal(t, [][]map[string]interface{}{{{"id": "USER1"}}}, *batches)
+
+	// Constraint violations fail the batch without retrying
+	attempts := 0
+	sink.insert = func(tableName string, rows []map[string]interface{}) error {
+		attempts++
+		return pgTestError("23505") // unique_violation
+	}
+	assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER1"}))
+	assert.ErrorContains(t, sink.Flush(), "ERROR #23505")
+	assert.Equal(t, 1, attempts)
+}
+
+func TestTransientPgError(t *testing.T) {
+	assert.True(t, transientPgError(fmt.Errorf("read: connection reset by peer")))
+	assert.True(t, transientPgError(pgTestError("40001")))
+	assert.True(t, transientPgError(pgTestError("08006")))
+	assert.True(t, transientPgError(pgTestError("57P01")))
+	assert.False(t, transientPgError(pgTestError("23505")))
+	assert.False(t, transientPgError(pgTestError("42P01")))
+}
+
+func TestRetryPolicyFromEnv(t *testing.T) {
+	// Start from unset variables whatever the environment running the tests holds
+	t.Setenv("RETRY_ATTEMPTS", "")
+	t.Setenv("RETRY_DELAY", "")
+	policy, err := RetryPolicyFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, policy.MaxAttempts)
+
+	t.Setenv("RETRY_ATTEMPTS", "5")
+	t.Setenv("RETRY_DELAY", "2s")
+	policy, err = RetryPolicyFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, RetryPolicy{MaxAttempts: 5, BaseDelay: 2 * time.Second}, policy)
+
+	t.Setenv("RETRY_ATTEMPTS", "0")
+	_, err = RetryPolicyFromEnv()
+	assert.Error(t, err)
+}
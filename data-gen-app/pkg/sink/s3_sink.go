@@ -0,0 +1,147 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3Config locates the bucket generated files are uploaded to
+type S3Config struct {
+	Bucket    string
+	Prefix    string // Key prefix, such as exports/2024-01-01
+	Endpoint  string // S3-compatible endpoint such as http://localhost:9000, AWS when empty
+	Region    string
+	PathStyle bool // Address buckets as endpoint/bucket, as MinIO expects
+}
+
+// s3Uploader is the subset of *s3.Client used by the sink
+type s3Uploader interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// S3Sink implements DataSink interface by staging files with a file sink, such
+// as CSV or JSON Lines, and uploading every file to S3-compatible storage on Close
+type S3Sink struct {
+	files      DataSink
+	stagingDir string
+	client     s3Uploader
+	bucket     string
+	prefix     string
+}
+
+// NewS3Sink creates a sink uploading to cfg's bucket, credentials come from the
+// default AWS chain (AWS_ACCESS_KEY_ID, shared config, instance roles). newFiles
+// creates the file sink that serializes records into the staging directory.
+func NewS3Sink(cfg S3Config, newFiles func(stagingDir string) (DataSink, error)) (*S3Sink, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 sink requires a bucket")
+	}
+
+	var loadOpts []func(*config.LoadOptions) error
+	if cfg.Region != "" {
+		loadOpts = append(loadOpts, config.WithRegion(cfg.Region))
+	}
+	awsConfig, err := config.LoadDefaultConfig(context.Background(), loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load aws config: %v", err)
+	}
+	client := s3.NewFromConfig(awsConfig, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.PathStyle
+	})
+	return newS3Sink(client, cfg, newFiles)
+}
+
+func newS3Sink(client s3Uploader, cfg S3Config, newFiles func(stagingDir string) (DataSink, error)) (*S3Sink, error) {
+	stagingDir, err := os.MkdirTemp("", "data-gen-s3-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %v", err)
+	}
+	files, err := newFiles(stagingDir)
+	if err != nil {
+		os.RemoveAll(stagingDir)
+		return nil, err
+	}
+
+	return &S3Sink{
+		files:      files,
+		stagingDir: stagingDir,
+		client:     client,
+		bucket:     cfg.Bucket,
+		prefix:     strings.Trim(cfg.Prefix, "/"),
+	}, nil
+}
+
+// InsertRecord writes the record to the table's staged file
+func (s *S3Sink) InsertRecord(tableName string, record map[string]interface{}) error {
+	return s.files.InsertRecord(tableName, record)
+}
+
+// Flush flushes the staged files, nothing is uploaded before Close
+func (s *S3Sink) Flush() error {
+	return s.files.Flush()
+}
+
+// Close finishes the staged files, uploads each one under the prefix and
+// removes the staging directory
+func (s *S3Sink) Close() error {
+	defer os.RemoveAll(s.stagingDir)
+
+	if err := s.files.Close(); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(s.stagingDir)
+	if err != nil {
+		return fmt.Errorf("failed to list staged files: %v", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var errors []string
+	for _, name := range names {
+		if err := s.upload(name); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+	if len(errors) > 0 {
+		return fmt.Errorf("errors while uploading to s3: %s", strings.Join(errors, "; "))
+	}
+	return nil
+}
+
+// upload puts a single staged file
+func (s *S3Sink) upload(name string) error {
+	file, err := os.Open(filepath.Join(s.stagingDir, name))
+	if err != nil {
+		return fmt.Errorf("failed to open staged file %s: %v", name, err)
+	}
+	defer file.Close()
+
+	key := path.Join(s.prefix, name)
+	_, err = s.client.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Body:   file,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload s3://%s/%s: %v", s.bucket, key, err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package sink
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 stores the objects put to it by request path
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "unexpected method", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	f.objects[r.URL.Path] = string(body)
+	f.mu.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestS3Sink(t *testing.T) {
+	fake := &fakeS3{objects: make(map[string]string)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider("key", "secret", ""),
+	})
+	cfg := S3Config{Bucket: "exports", Prefix: "/runs/2024-01-01/"}
+	sink, err := newS3Sink(client, cfg, func(stagingDir string) (DataSink, error) {
+		return NewJSONLSink(stagingDir, "")
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER001"}))
+	assert.NoError(t, sink.InsertRecord("users", map[string]interface{}{"id": "USER002"}))
+	assert.NoError(t, sink.InsertRecord("orders", map[string]interface{}{"id": "ORDER001"}))
+	assert.NoError(t, sink.Flush())
+	assert.Empty(t, fake.objects, "nothing is uploaded before close")
+	assert.NoError(t, sink.Close())
+
+	assert.Len(t, fake.objects, 2)
+	assert.Equal(t, "{\"id\":\"USER001\"}\n{\"id\":\"USER002\"}\n", fake.objects["/exports/runs/2024-01-01/users.jsonl"])
+	assert.Equal(t, "{\"id\":\"ORDER001\"}\n", fake.objects["/exports/runs/2024-01-01/orders.jsonl"])
+
+	_, err = os.Stat(sink.stagingDir)
+	assert.True(t, os.IsNotExist(err), "staging directory is removed")
+}
+
+func TestS3SinkRequiresBucket(t *testing.T) {
+	_, err := NewS3Sink(S3Config{}, nil)
+	assert.EqualError(t, err, "s3 sink requires a bucket")
+}
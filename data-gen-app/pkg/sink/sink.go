@@ -4,4 +4,20 @@ package sink
 type DataSink interface {
 	// InsertRecord inserts a single record into the sink
 	InsertRecord(tableName string, data map[string]interface{}) error
+	// Flush writes any buffered records to the underlying destination
+	Flush() error
+	// Close flushes buffered records and releases the sink's resources
+	Close() error
+}
+
+// appendTable adds tableName to tables unless it is listed already. Generation
+// emits parents before their children, so buffering sinks that keep tables in
+// the order their first record arrived flush them in dependency order.
+func appendTable(tables []string, tableName string) []string {
+	for _, name := range tables {
+		if name == tableName {
+			return tables
+		}
+	}
+	return append(tables, tableName)
 }
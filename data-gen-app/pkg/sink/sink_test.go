@@ -16,6 +16,14 @@ func (t *TestDataSink) InsertRecord(tableName string, data map[string]interface{
 	return nil
 }
 
+func (t *TestDataSink) Flush() error {
+	return nil
+}
+
+func (t *TestDataSink) Close() error {
+	return nil
+}
+
 func TestDataSinkInterface(t *testing.T) {
 	// Create a test sink
 	sink := &TestDataSink{
@@ -46,4 +54,9 @@ func TestDataSinkInterface(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 2, len(sink.Records))
 	assert.Equal(t, testData2, sink.Records[1])
+
+	// The test sink must satisfy the full interface
+	var _ DataSink = sink
+	assert.NoError(t, sink.Flush())
+	assert.NoError(t, sink.Close())
 }
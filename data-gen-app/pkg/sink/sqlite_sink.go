@@ -0,0 +1,134 @@
+package sink
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sujanks/data-gen-app/pkg/types"
+	_ "modernc.org/sqlite" // pure-Go sqlite driver, no cgo required
+)
+
+// SQLiteSink implements DataSink interface for SQLite database files
+type SQLiteSink struct {
+	db       *sql.DB
+	mu       sync.Mutex
+	created  map[string]bool
+	tableMap map[string]*types.Table // Cache for quick table lookup
+}
+
+// NewSQLiteSink creates a new SQLite sink writing to the database file at path
+func NewSQLiteSink(path string, schema *types.Schema) (*SQLiteSink, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %v", err)
+	}
+
+	// Create a map for quick table lookup
+	tableMap := make(map[string]*types.Table)
+	for i := range schema.Tables {
+		table := &schema.Tables[i]
+		tableMap[table.Name] = table
+	}
+
+	return &SQLiteSink{
+		db:       db,
+		created:  make(map[string]bool),
+		tableMap: tableMap,
+	}, nil
+}
+
+// InsertRecord inserts a record into the table, creating the table on first use
+func (s *SQLiteSink) InsertRecord(tableName string, record map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Get table from the map
+	table, exists := s.tableMap[tableName]
+	if !exists {
+		return fmt.Errorf("table not found: %s", tableName)
+	}
+
+	if !s.created[tableName] {
+		if _, err := s.db.Exec(createTableSQL(table)); err != nil {
+			return fmt.Errorf("failed to create table %s: %v", tableName, err)
+		}
+		s.created[tableName] = true
+	}
+
+	// Bind values in the same order as columns
+	names := make([]string, 0, len(table.Columns))
+	placeholders := make([]string, 0, len(table.Columns))
+	args := make([]interface{}, 0, len(table.Columns))
+	for _, col := range table.Columns {
+		value, err := sqliteValue(record[col.Name])
+		if err != nil {
+			return fmt.Errorf("failed to convert column %s: %v", col.Name, err)
+		}
+		names = append(names, quoteIdent(col.Name))
+		placeholders = append(placeholders, "?")
+		args = append(args, value)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		quoteIdent(tableName), strings.Join(names, ", "), strings.Join(placeholders, ", "))
+	_, err := s.db.Exec(query, args...)
+	return err
+}
+
+// Flush is a no-op since every record is written as it is inserted
+func (s *SQLiteSink) Flush() error {
+	return nil
+}
+
+// Close closes the underlying database
+func (s *SQLiteSink) Close() error {
+	return s.db.Close()
+}
+
+// createTableSQL builds a CREATE TABLE IF NOT EXISTS statement for the table
+func createTableSQL(table *types.Table) string {
+	return sqlDialects[DialectSQLite].createTable(table)
+}
+
+// sqliteType infers the SQLite column type from the column configuration
+func sqliteType(col types.Column) string {
+	switch col.Type {
+	case "int", "bigint", "long", "bool":
+		return "INTEGER"
+	case "float", "decimal":
+		return "REAL"
+	case "timestamp":
+		if col.Format == "unix" || col.Format == "unix_ms" {
+			return "INTEGER"
+		}
+		return "TEXT"
+	default:
+		return "TEXT"
+	}
+}
+
+// sqliteValue converts a generated value into something the driver can bind,
+// nested maps and slices are stored as JSON text
+func sqliteValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05"), nil
+	default:
+		return v, nil
+	}
+}
+
+// quoteIdent quotes an identifier for use in SQL statements
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
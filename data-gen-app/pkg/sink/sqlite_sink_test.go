@@ -0,0 +1,82 @@
+package sink
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+func TestSQLiteSink(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "fixtures.db")
+
+	// Create a test schema
+	schema := &types.Schema{
+		Tables: []types.Table{
+			{
+				Name: "users",
+				Columns: []types.Column{
+					{Name: "id", Type: "string", Mandatory: true},
+					{Name: "age", Type: "int"},
+					{Name: "metadata", Type: "json"},
+				},
+			},
+			{
+				Name: "orders",
+				Columns: []types.Column{
+					{Name: "id", Type: "string"},
+					{Name: "user_id", Type: "string"},
+					{Name: "amount", Type: "decimal"},
+				},
+			},
+		},
+	}
+
+	sink, err := NewSQLiteSink(dbPath, schema)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		err := sink.InsertRecord("users", map[string]interface{}{
+			"id":       fmt.Sprintf("USER%03d", i),
+			"age":      30 + i,
+			"metadata": map[string]interface{}{"type": "admin"},
+		})
+		assert.NoError(t, err)
+	}
+	for i := 0; i < 5; i++ {
+		err := sink.InsertRecord("orders", map[string]interface{}{
+			"id":      fmt.Sprintf("ORDER%03d", i),
+			"user_id": "USER000",
+			"amount":  12.5,
+		})
+		assert.NoError(t, err)
+	}
+	assert.Error(t, sink.InsertRecord("missing", map[string]interface{}{}))
+	assert.NoError(t, sink.Close())
+
+	// Reopen the file and verify the row counts
+	db, err := sql.Open("sqlite", dbPath)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	var users, orders int
+	assert.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&users))
+	assert.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM orders`).Scan(&orders))
+	assert.Equal(t, 3, users)
+	assert.Equal(t, 5, orders)
+
+	var metadata string
+	assert.NoError(t, db.QueryRow(`SELECT metadata FROM users WHERE id = 'USER000'`).Scan(&metadata))
+	assert.Equal(t, `{"type":"admin"}`, metadata)
+}
+
+func TestSQLiteType(t *testing.T) {
+	assert.Equal(t, "INTEGER", sqliteType(types.Column{Type: "int"}))
+	assert.Equal(t, "REAL", sqliteType(types.Column{Type: "decimal"}))
+	assert.Equal(t, "INTEGER", sqliteType(types.Column{Type: "timestamp", Format: "unix"}))
+	assert.Equal(t, "TEXT", sqliteType(types.Column{Type: "timestamp"}))
+	assert.Equal(t, "TEXT", sqliteType(types.Column{Name: "code", Pattern: "ABC###"}))
+}
@@ -0,0 +1,30 @@
+package pkg
+
+import (
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// applySoftDeletes decides for each soft delete column whether the record is
+// deleted. Deleted records keep the column's timestamp, which after has already
+// placed past the creation time, and get a true flag; the others lose the
+// timestamp and get a false flag. A deleted record without a timestamp, when its
+// creation time is missing, counts as not deleted.
+func applySoftDeletes(faker *gofakeit.Faker, columns []types.Column, tableData map[string]interface{}) {
+	for _, col := range columns {
+		if col.SoftDelete == nil {
+			continue
+		}
+		deleted := faker.Float64() < col.SoftDelete.Probability && tableData[col.Name] != nil
+		if !deleted {
+			// Like any nil value, only mandatory columns keep an explicit null
+			delete(tableData, col.Name)
+			if col.Mandatory {
+				tableData[col.Name] = nil
+			}
+		}
+		if col.SoftDelete.Flag != "" {
+			tableData[col.SoftDelete.Flag] = deleted
+		}
+	}
+}
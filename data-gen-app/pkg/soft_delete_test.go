@@ -0,0 +1,52 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSoftDelete(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: accounts
+  columns:
+  - name: created_at
+    type: timestamp
+    range:
+      min: "2024-01-01 00:00:00"
+      max: "2024-12-31 00:00:00"
+  - name: is_deleted
+    type: bool
+  - name: deleted_at
+    type: timestamp
+    mandatory: true
+    after:
+      column: created_at
+      max_offset: 720h
+    soft_delete:
+      flag: is_deleted
+      probability: 0.2
+`)
+
+	stream, err := GenerateStream(context.Background(), 1000, manifestPath)
+	assert.NoError(t, err)
+
+	deletedRows := 0
+	for record := range stream.Records() {
+		created := record.Data["created_at"].(time.Time)
+		if record.Data["is_deleted"].(bool) {
+			deletedRows++
+			deleted := record.Data["deleted_at"].(time.Time)
+			assert.True(t, deleted.After(created), "deleted_at %s not after created_at %s", deleted, created)
+			assert.True(t, deleted.Sub(created) <= 720*time.Hour)
+		} else {
+			assert.Contains(t, record.Data, "deleted_at")
+			assert.Nil(t, record.Data["deleted_at"])
+		}
+	}
+	// Roughly 200 of the 1000 rows are deleted
+	assert.InDelta(t, 200, deletedRows, 80)
+}
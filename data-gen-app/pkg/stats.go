@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"fmt"
+
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// maxTrackedDistinct caps the distinct values remembered per column, so stats
+// stay cheap on large runs
+const maxTrackedDistinct = 1000
+
+// Stats collects how the values of each column were produced, by table and column
+type Stats map[string]map[string]*ColumnStats
+
+// ColumnStats summarises the values a column received during a run
+type ColumnStats struct {
+	// Sources counts values by how they were produced: const, foreign, value,
+	// pattern, aggregate, hash, generated, or null when the record holds no value
+	Sources map[string]int
+	// Distinct counts distinct non-null values, up to maxTrackedDistinct
+	Distinct int
+	seen     map[string]bool
+}
+
+// WithStats records per-column value sources and distinct counts into stats
+func WithStats(stats Stats) Option {
+	return func(o *options) {
+		o.stats = stats
+	}
+}
+
+// trackStats wraps emit so every emitted record is counted into the run's stats
+func (o *options) trackStats(tables []types.Table, emit func(Record) error) func(Record) error {
+	if o.stats == nil {
+		return emit
+	}
+
+	columns := make(map[string][]types.Column, len(tables))
+	for _, table := range tables {
+		columns[table.Name] = table.Columns
+	}
+	return func(record Record) error {
+		if err := emit(record); err != nil {
+			return err
+		}
+		if o.stats[record.Table] == nil {
+			o.stats[record.Table] = make(map[string]*ColumnStats)
+		}
+		for _, col := range columns[record.Table] {
+			stats := o.stats[record.Table][col.Name]
+			if stats == nil {
+				stats = &ColumnStats{Sources: make(map[string]int), seen: make(map[string]bool)}
+				o.stats[record.Table][col.Name] = stats
+			}
+			stats.observe(col, record.Data[col.Name])
+		}
+		return nil
+	}
+}
+
+// observe counts one value of col
+func (s *ColumnStats) observe(col types.Column, value interface{}) {
+	if value == nil {
+		s.Sources["null"]++
+		return
+	}
+	s.Sources[valueSource(col)]++
+
+	if s.Distinct < maxTrackedDistinct {
+		key := fmt.Sprint(value)
+		if !s.seen[key] {
+			s.seen[key] = true
+			s.Distinct++
+		}
+	}
+}
+
+// valueSource names the column setting a non-null value of col comes from,
+// following the precedence of columnValue
+func valueSource(col types.Column) string {
+	switch {
+	case col.Aggregate.Function != "":
+		return "aggregate"
+	case col.Type == "hash":
+		return "hash"
+	case col.Const != "":
+		return "const"
+	case col.Foreign != "":
+		return "foreign"
+	case len(col.Value) > 0:
+		return "value"
+	case col.Pattern != "":
+		return "pattern"
+	default:
+		return "generated"
+	}
+}
@@ -0,0 +1,47 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithStats(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: customers
+  columns:
+  - name: id
+    pattern: "C####"
+    parent: true
+    validation:
+      unique: true
+  - name: tier
+    const: "gold"
+- name: orders
+  depends_on: customers
+  columns:
+  - name: customer_id
+    foreign: "customers.id"
+  - name: status
+    value: ["NEW"]
+  - name: note
+    type: string
+    when: "fields.status == 'SHIPPED'"
+`)
+
+	stats := Stats{}
+	err := GenerateData(&MockDataSink{}, 20, manifestPath, WithStats(stats))
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]int{"pattern": 20}, stats["customers"]["id"].Sources)
+	assert.Equal(t, 20, stats["customers"]["id"].Distinct)
+	assert.Equal(t, map[string]int{"const": 20}, stats["customers"]["tier"].Sources)
+	assert.Equal(t, 1, stats["customers"]["tier"].Distinct)
+
+	assert.Equal(t, map[string]int{"foreign": 20}, stats["orders"]["customer_id"].Sources)
+	assert.Equal(t, map[string]int{"value": 20}, stats["orders"]["status"].Sources)
+	assert.Equal(t, 1, stats["orders"]["status"].Distinct)
+	assert.Equal(t, map[string]int{"null": 20}, stats["orders"]["note"].Sources)
+	assert.Equal(t, 0, stats["orders"]["note"].Distinct)
+}
@@ -0,0 +1,37 @@
+package pkg
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+// templateToken matches a {{function}} or {{function:params}} token of a template column
+var templateToken = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+
+// expandTemplate replaces every token of template with the output of the gofakeit
+// function it names, such as {{firstname}} or {{number:1,10}}, keeping the text
+// around tokens as it is. Unlike ${...} rule values, tokens cannot read the record.
+func expandTemplate(faker *gofakeit.Faker, template string) string {
+	return templateToken.ReplaceAllStringFunc(template, func(token string) string {
+		call := templateToken.FindStringSubmatch(token)[1]
+		value, err := faker.Generate("{" + call + "}")
+		if err != nil {
+			return token
+		}
+		return value
+	})
+}
+
+// unknownTemplateFunctions lists the token functions of template gofakeit does not provide
+func unknownTemplateFunctions(template string) []string {
+	var unknown []string
+	for _, match := range templateToken.FindAllStringSubmatch(template, -1) {
+		name := strings.SplitN(match[1], ":", 2)[0]
+		if gofakeit.GetFuncLookup(name) == nil {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,35 @@
+package pkg
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+func TestTemplateColumn(t *testing.T) {
+	col := types.Column{Name: "greeting", Type: "template", Template: "User {{firstname}} from {{ city }} (#{{number:1,9}}?)"}
+	pattern := regexp.MustCompile(`^User (.+) from (.+) \(#([1-9])\?\)$`)
+
+	for i := 0; i < 50; i++ {
+		value := generateColumnValue(col, gofakeit.GlobalFaker).(string)
+		match := pattern.FindStringSubmatch(value)
+		if assert.NotNil(t, match, "unexpected template output %q", value) {
+			assert.NotEmpty(t, match[1])
+			assert.NotEmpty(t, match[2])
+		}
+		assert.NotContains(t, value, "{{")
+	}
+}
+
+func TestTemplateReproducible(t *testing.T) {
+	template := "{{firstname}} {{lastname}} <{{email}}>"
+	assert.Equal(t, expandTemplate(gofakeit.New(7), template), expandTemplate(gofakeit.New(7), template))
+}
+
+func TestUnknownTemplateFunctions(t *testing.T) {
+	assert.Equal(t, []string{"nickname", "colour"}, unknownTemplateFunctions("{{firstname}} {{nickname}} {{colour:red}} {{number:1,5}}"))
+	assert.Empty(t, unknownTemplateFunctions("no tokens {here}"))
+}
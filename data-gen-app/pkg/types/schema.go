@@ -1,6 +1,9 @@
 package types
 
 import (
+	"fmt"
+	"math"
+	"strconv"
 	"strings"
 	"time"
 
@@ -9,32 +12,68 @@ import (
 
 // Schema represents the data generation schema
 type Schema struct {
-	Tables []Table `yaml:"tables"`
+	Tables []Table         `yaml:"tables"`
+	UDTs   []UDTDefinition `yaml:"udts,omitempty"` // Cassandra user-defined type declarations
+	// ExternalKeys are table.column parent keys generated by a previous run,
+	// which foreign columns may reference once loaded
+	ExternalKeys []string `yaml:"external_keys,omitempty"`
+	// Enums are named value lists that columns reference with enum, resolved
+	// into the columns' values when the manifest is loaded
+	Enums map[string][]string `yaml:"enums,omitempty"`
+	// Rules are cross-table rules, each evaluated for every record of its table
+	// with the parent records referenced by the record's foreign keys
+	Rules []SchemaRule `yaml:"rules,omitempty"`
 }
 
 // Table represents a table in the schema
 type Table struct {
 	Name      string   `yaml:"name"`
-	Priority  int      `yaml:"priority"`
+	Priority  int      `yaml:"priority,omitempty"`
 	DependsOn string   `yaml:"depends_on,omitempty"`
+	Count     int      `yaml:"count,omitempty"` // Records to generate, overriding the run's count
 	Columns   []Column `yaml:"columns"`
 	Rules     []Rule   `yaml:"rules,omitempty"`
+	// Choices are rows of correlated column values, one is picked per record
+	Choices []map[string]string `yaml:"choices,omitempty"`
+	// Unique lists sets of columns whose combined values must not repeat
+	Unique [][]string `yaml:"unique,omitempty"`
 }
 
 // Column represents a column in a table
 type Column struct {
-	Name       string     `yaml:"name"`
-	Pattern    string     `yaml:"pattern,omitempty"`
-	Value      []string   `yaml:"value,omitempty"`
-	Type       string     `yaml:"type,omitempty"`
-	Format     string     `yaml:"format,omitempty"`
-	Mandatory  bool       `yaml:"mandatory"`
-	Parent     bool       `yaml:"parent"`
-	Foreign    string     `yaml:"foreign,omitempty"`
-	Validation Validation `yaml:"validation,omitempty"`
-	Range      Range      `yaml:"range,omitempty"`
-	JSONConfig JSONConfig `yaml:"json_config,omitempty"`
-	Rules      []Rule     `yaml:"rules,omitempty"` // Rules to apply on the column
+	Name             string     `yaml:"name"`
+	Pattern          string     `yaml:"pattern,omitempty"`
+	AllowLeadingZero bool       `yaml:"allow_leading_zero,omitempty"` // Let a leading # in the pattern generate 0
+	Luhn             bool       `yaml:"luhn,omitempty"`               // Make the pattern's last digit a Luhn check digit
+	Network          string     `yaml:"network,omitempty"`            // Card network for creditcard and card_cvv: visa, mastercard or amex
+	Width            int        `yaml:"width,omitempty"`              // Render int values as zero-padded strings of at least this many digits
+	Scale            *int       `yaml:"scale,omitempty"`              // Digits written after the decimal point of float values, -1 for as many as needed
+	Enum             string     `yaml:"enum,omitempty"`               // Name of a manifest-level enum supplying the column's values
+	Template         string     `yaml:"template,omitempty"`           // Text with {{function}} tokens expanded by gofakeit, for the template type
+	Value            []string   `yaml:"value,omitempty"`
+	Mode             string     `yaml:"mode,omitempty"` // How values are picked: random, the default, or sequential to cycle through them in order
+	Type             string     `yaml:"type,omitempty"`
+	Format           string     `yaml:"format,omitempty"`
+	Version          int        `yaml:"version,omitempty"` // UUID version, 4 (random, default) or 7 (time-ordered)
+	Mandatory        bool       `yaml:"mandatory,omitempty"`
+	Parent           bool       `yaml:"parent,omitempty"`
+	Foreign          string     `yaml:"foreign,omitempty"`
+	NullProbability  float64    `yaml:"null_probability,omitempty"` // Chance (0-1) of a foreign column having no parent reference
+	ForeignFilter    string     `yaml:"foreign_filter,omitempty"`   // Condition on parent.<table> a referenced parent must meet
+	Validation       Validation `yaml:"validation,omitempty"`
+	Range            Range      `yaml:"range,omitempty"`
+	JSONConfig       JSONConfig `yaml:"json_config,omitempty"`
+	Words            int        `yaml:"words,omitempty"`      // Words per sentence for sentence/paragraph types
+	Sentences        int        `yaml:"sentences,omitempty"`  // Sentences per paragraph
+	Paragraphs       int        `yaml:"paragraphs,omitempty"` // Paragraphs for the paragraph type
+	Rules            []Rule     `yaml:"rules,omitempty"`      // Rules to apply on the column
+	When             string     `yaml:"when,omitempty"`       // Condition on other fields for generating the column at all
+	Default          string     `yaml:"default,omitempty"`    // Value, typed by type, used when generation yields nil
+	Const            string     `yaml:"const,omitempty"`      // Value, typed by type, used for every record instead of generating
+	Aggregate        Aggregate  `yaml:"aggregate,omitempty"`  // Backfill from the child rows referencing this record
+	After            After      `yaml:"after,omitempty"`      // Generate a timestamp later than another column's
+	Mask             Mask       `yaml:"mask,omitempty"`       // Mask the final string value
+	Counter          *Counter   `yaml:"counter,omitempty"`    // Accumulate a running total across the table's rows
 	// Cassandra-specific fields
 	KeyType     string      `yaml:"key_type,omitempty"`
 	ValueType   string      `yaml:"value_type,omitempty"`
@@ -44,40 +83,228 @@ type Column struct {
 	UDTConfig   UDTConfig   `yaml:"udt_config,omitempty"`
 	ListConfig  ListConfig  `yaml:"list_config,omitempty"`
 	TupleConfig TupleConfig `yaml:"tuple_config,omitempty"`
+	// Repeated sub-records
+	ObjectsConfig ObjectsConfig `yaml:"objects_config,omitempty"`
+	// Digest of other columns of the same record
+	HashConfig HashConfig `yaml:"hash_config,omitempty"`
+	// Files of candidate string values, one file is picked per value by weight
+	ValuesFiles []ValuesFile `yaml:"values_file,omitempty"`
+	// Mark a fraction of rows deleted, keeping this timestamp only for those rows
+	SoftDelete *SoftDelete `yaml:"soft_delete,omitempty"`
+}
+
+// ValuesFile is a file of candidate string values, one per line, its path
+// relative to the manifest. Values are drawn from a file picked by weight.
+type ValuesFile struct {
+	Path   string   `yaml:"path"`
+	Weight float64  `yaml:"weight,omitempty"` // Relative chance of the file being picked, defaults to 1
+	Values []string `yaml:"-"`                // Lines of the file, read when the manifest is loaded
+}
+
+// RelativeWeight returns the file's weight, 1 when none is set
+func (v ValuesFile) RelativeWeight() float64 {
+	if v.Weight == 0 {
+		return 1
+	}
+	return v.Weight
+}
+
+// pickValuesFile draws a value from one of files, chosen in proportion to their weights
+func pickValuesFile(f *gofakeit.Faker, files []ValuesFile) string {
+	total := 0.0
+	for _, file := range files {
+		total += file.RelativeWeight()
+	}
+	r := f.Float64() * total
+	for _, file := range files {
+		if r -= file.RelativeWeight(); r < 0 {
+			return f.RandomString(file.Values)
+		}
+	}
+	return f.RandomString(files[len(files)-1].Values)
+}
+
+// Aggregate fills a parent column from the child rows that reference it,
+// once every child has been generated
+type Aggregate struct {
+	Function string `yaml:"function"`        // count or sum
+	Foreign  string `yaml:"foreign"`         // Child foreign column as table.column
+	Field    string `yaml:"field,omitempty"` // Child column added up by sum
+}
+
+// After generates a timestamp a random positive offset later than an earlier
+// timestamp column of the same record
+type After struct {
+	Column    string `yaml:"column"`               // Earlier timestamp column
+	MaxOffset string `yaml:"max_offset,omitempty"` // Largest offset as a duration, defaults to 24h
+}
+
+// Counter turns an int or float column into a running total, each row adds a
+// delta read from another column or, without one, generated from the column's range
+type Counter struct {
+	Start float64 `yaml:"start,omitempty"` // Total before the first row
+	Delta string  `yaml:"delta,omitempty"` // Numeric column of the same record added to the total
+}
+
+// SoftDelete makes a timestamp column, such as deleted_at, the deletion time of
+// a fraction of the table's rows. Deleted rows keep the timestamp and have their
+// flag column set true, the others get a false flag and no timestamp.
+type SoftDelete struct {
+	Flag        string  `yaml:"flag,omitempty"` // Bool column of the same record telling whether it is deleted
+	Probability float64 `yaml:"probability"`    // Chance (0-1) of a row being deleted
+}
+
+// Mask hides part or all of a generated string once rules have run
+type Mask struct {
+	Strategy string `yaml:"strategy"`        // last4, email or fixed
+	Char     string `yaml:"char,omitempty"`  // Masking character, defaults to *
+	Value    string `yaml:"value,omitempty"` // Replacement for the fixed strategy
 }
 
 // Validation defines validation rules for a column
 type Validation struct {
 	Unique bool `yaml:"unique,omitempty"`
+	// UniquePerParent names a foreign column of the same table, values then only
+	// have to be unique among the records referencing the same parent
+	UniquePerParent string `yaml:"unique_per_parent,omitempty"`
 }
 
 // Range defines min/max values for numeric and date fields
 type Range struct {
-	Min interface{} `yaml:"min,omitempty"`
-	Max interface{} `yaml:"max,omitempty"`
+	Min          interface{} `yaml:"min,omitempty"`
+	Max          interface{} `yaml:"max,omitempty"`
+	ExclusiveMin bool        `yaml:"exclusive_min,omitempty"` // Numbers never equal min
+	ExclusiveMax bool        `yaml:"exclusive_max,omitempty"` // Numbers never equal max
+	Step         float64     `yaml:"step,omitempty"`          // Numbers are multiples of step
+}
+
+// intBounds narrows inclusive integer bounds by the range's exclusive flags
+func intBounds[T int | int64](r Range, min, max T) (T, T) {
+	if r.ExclusiveMin {
+		min++
+	}
+	if r.ExclusiveMax {
+		max--
+	}
+	return min, max
+}
+
+// intInRange returns a random int between min and max, honouring the range's
+// exclusive bounds and step
+func intInRange(f *gofakeit.Faker, r Range, min, max int) int {
+	min, max = intBounds(r, min, max)
+	if step := int64(r.Step); step > 0 {
+		return int(steppedInt64(f, int64(min), int64(max), step))
+	}
+	return f.IntRange(min, max)
+}
+
+// int64InRange returns a random int64 between min and max, honouring the range's
+// exclusive bounds and step
+func int64InRange(f *gofakeit.Faker, r Range, min, max int64) int64 {
+	min, max = intBounds(r, min, max)
+	if step := int64(r.Step); step > 0 {
+		return steppedInt64(f, min, max, step)
+	}
+	return int64Range(f, min, max)
+}
+
+// steppedInt64 returns a random multiple of step between min and max inclusive
+func steppedInt64(f *gofakeit.Faker, min, max, step int64) int64 {
+	// Division truncates towards zero, move inwards to the multiples within the range
+	low, high := min/step, max/step
+	if low*step < min {
+		low++
+	}
+	if high*step > max {
+		high--
+	}
+	return int64Range(f, low, high) * step
+}
+
+// floatInRange returns a random float between min and max, excluding either
+// bound when the range says so, and a multiple of the range's step when it has one
+func floatInRange(f *gofakeit.Faker, r Range, min, max float64) float64 {
+	if r.Step > 0 {
+		low, high := StepMultiples(r, min, max)
+		return roundNano(float64(int64Range(f, int64(low), int64(high))) * r.Step)
+	}
+	if r.ExclusiveMin {
+		min = math.Nextafter(min, math.Inf(1))
+	}
+	if r.ExclusiveMax {
+		max = math.Nextafter(max, math.Inf(-1))
+	}
+	// Rounding may carry the value onto a bound
+	return math.Max(min, math.Min(max, f.Float64Range(min, max)))
+}
+
+// StepMultiples returns the factors of the first and last multiple of the range's
+// step between min and max, leaving out excluded bounds. The range holds no
+// multiple when low is above high.
+func StepMultiples(r Range, min, max float64) (low, high float64) {
+	// Quotients are rounded so that 0.3 / 0.1 counts as the multiple it is
+	low, high = math.Ceil(roundNano(min/r.Step)), math.Floor(roundNano(max/r.Step))
+	if r.ExclusiveMin && roundNano(low*r.Step) <= min {
+		low++
+	}
+	if r.ExclusiveMax && roundNano(high*r.Step) >= max {
+		high--
+	}
+	return low, high
+}
+
+// roundNano rounds v to nine decimals, removing the error of float multiplication and division
+func roundNano(v float64) float64 {
+	return math.Round(v*1e9) / 1e9
 }
 
 // Rule defines a conditional rule with an expression and actions
 type Rule struct {
-	When      string            `yaml:"when"`      // Expression to evaluate
-	Then      map[string]string `yaml:"then"`      // Field values to set when expression is true
-	Otherwise map[string]string `yaml:"otherwise"` // Field values to set when expression is false
+	When      string            `yaml:"when"`            // Expression to evaluate
+	Then      map[string]string `yaml:"then"`            // Field values to set when expression is true
+	Cases     []RuleCase        `yaml:"cases,omitempty"` // Further branches tried in order when the expressions before them are false
+	Otherwise map[string]string `yaml:"otherwise"`       // Field values to set when every expression is false
+}
+
+// RuleCase is an else-if branch of a rule
+type RuleCase struct {
+	When string            `yaml:"when"`
+	Then map[string]string `yaml:"then"`
+}
+
+// SchemaRule is a rule on the records of one table that may read the parent
+// record each foreign key selected as parent.<table>
+type SchemaRule struct {
+	Table string `yaml:"table"` // Child table the rule applies to
+	Rule  `yaml:",inline"`
 }
 
 // FieldConfig defines configuration for a specific JSON field
 type FieldConfig struct {
-	Name  string `yaml:"name"`
-	Type  string `yaml:"type"`
-	Range Range  `yaml:"range,omitempty"`
+	Name            string      `yaml:"name"`
+	Type            string      `yaml:"type"`
+	Range           Range       `yaml:"range,omitempty"`
+	Pattern         string      `yaml:"pattern,omitempty"`
+	Value           []string    `yaml:"value,omitempty"`
+	Format          string      `yaml:"format,omitempty"`
+	NullProbability float64     `yaml:"null_probability,omitempty"` // Chance (0-1) of the field being null
+	Fields          JSONConfig  `yaml:"fields,omitempty"`           // Nested fields for object fields and object array elements
+	Items           ArrayConfig `yaml:"items,omitempty"`            // Element spec for array fields
+}
+
+// ArrayConfig defines the elements of an array-typed JSON field
+type ArrayConfig struct {
+	ElementType string `yaml:"element_type"`
+	MinLength   int    `yaml:"min_length"`
+	MaxLength   int    `yaml:"max_length"`
 }
 
 // JSONConfig is an array of field configurations
 type JSONConfig []FieldConfig
 
 // Tables represents the root object in the YAML file
-type Tables struct {
-	Tables []Table `yaml:"tables"`
-}
+type Tables = Schema
 
 // Cassandra-specific configurations
 
@@ -98,6 +325,7 @@ type SetConfig struct {
 	Values      []string `yaml:"values,omitempty"`
 	ElementType string   `yaml:"element_type"`
 	Pattern     string   `yaml:"pattern,omitempty"`
+	Element     *Column  `yaml:"element,omitempty"` // Full column config for generated elements
 }
 
 // UDTConfig defines configuration for user-defined type
@@ -106,6 +334,19 @@ type UDTConfig struct {
 	Fields []Column `yaml:"fields"`
 }
 
+// UDTDefinition declares a user-defined type as it exists on the server,
+// field order must match the server-side type
+type UDTDefinition struct {
+	Name   string     `yaml:"name"`
+	Fields []UDTField `yaml:"fields"`
+}
+
+// UDTField is a single field of a declared user-defined type
+type UDTField struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type,omitempty"`
+}
+
 // ListConfig defines configuration for list type
 type ListConfig struct {
 	MinElements int      `yaml:"min_elements"`
@@ -113,6 +354,7 @@ type ListConfig struct {
 	Pattern     string   `yaml:"pattern,omitempty"`
 	ElementType string   `yaml:"element_type"`
 	Values      []string `yaml:"values,omitempty"`
+	Element     *Column  `yaml:"element,omitempty"` // Full column config for generated elements
 }
 
 // TupleConfig defines configuration for tuple type
@@ -120,6 +362,23 @@ type TupleConfig struct {
 	Elements []Column `yaml:"elements"`
 }
 
+// ObjectsConfig defines configuration for the objects type, an array of
+// sub-records that each have the configured fields
+type ObjectsConfig struct {
+	Min    int      `yaml:"min"`
+	Max    int      `yaml:"max"`
+	Fields []Column `yaml:"fields"`
+}
+
+// HashConfig derives a hash column from other columns of the same record,
+// hashing their values concatenated in the order listed
+type HashConfig struct {
+	Fields    []string `yaml:"fields"`
+	Algorithm string   `yaml:"algorithm,omitempty"` // sha256 (default), sha1 or md5
+	Encoding  string   `yaml:"encoding,omitempty"`  // hex (default) or base64
+	Separator string   `yaml:"separator,omitempty"` // Placed between values, none by default
+}
+
 // ValueGenerator defines the interface for generating values
 type ValueGenerator interface {
 	Generate() interface{}
@@ -128,6 +387,18 @@ type ValueGenerator interface {
 // BaseGenerator provides common functionality for all generators
 type BaseGenerator struct {
 	Config interface{}
+	// Faker is the generator's random source, gofakeit's global faker when nil.
+	// Giving generators their own seeded faker makes them reproducible and
+	// independent of other goroutines.
+	Faker *gofakeit.Faker
+}
+
+// Rand returns the random source values are drawn from
+func (b *BaseGenerator) Rand() *gofakeit.Faker {
+	if b.Faker != nil {
+		return b.Faker
+	}
+	return gofakeit.GlobalFaker
 }
 
 // MapGenerator generates map values
@@ -138,7 +409,7 @@ type MapGenerator struct {
 
 // Generate generates a random map
 func (g *MapGenerator) Generate() interface{} {
-	numEntries := gofakeit.IntRange(g.Config.MinEntries, g.Config.MaxEntries)
+	numEntries := collectionSize(g.Rand(), g.Config.MinEntries, g.Config.MaxEntries)
 	result := make(map[string]interface{})
 
 	// First, add all predefined keys if available
@@ -152,11 +423,12 @@ func (g *MapGenerator) Generate() interface{} {
 		}
 	}
 
-	// Then add random entries until we reach the desired number
-	for len(result) < 1 && len(result) < numEntries {
+	// Then add random entries until we reach the desired number, giving up
+	// after a few attempts per entry if the keys keep repeating
+	for i := 0; i < numEntries*2 && len(result) < numEntries; i++ {
 		key := g.generateKey()
 		value := g.generateValue()
-		result[key.(string)] = value
+		result[mapKey(key)] = value
 	}
 
 	return result
@@ -164,16 +436,50 @@ func (g *MapGenerator) Generate() interface{} {
 
 func (g *MapGenerator) generateKey() interface{} {
 	if len(g.Config.Keys) > 0 {
-		return gofakeit.RandomString(g.Config.Keys)
+		return g.Rand().RandomString(g.Config.Keys)
 	}
-	return generateRandomValue(g.Config.KeyType)
+	return generateRandomValue(g.Rand(), g.Config.KeyType)
 }
 
 func (g *MapGenerator) generateValue() interface{} {
 	if len(g.Config.Values) > 0 {
-		return gofakeit.RandomString(g.Config.Values)
+		return typedValue(g.Config.ValueType, g.Rand().RandomString(g.Config.Values))
+	}
+	return generateRandomValue(g.Rand(), g.Config.ValueType)
+}
+
+// mapKey serializes a generated key, maps are keyed by strings so they encode
+// as JSON objects; int and uuid keys keep their canonical text form, which the
+// Cassandra driver parses back when binding to map<int, ...> or map<uuid, ...>
+func mapKey(key interface{}) string {
+	if s, ok := key.(string); ok {
+		return s
+	}
+	return fmt.Sprint(key)
+}
+
+// typedValue converts a predefined value to valueType, keeping the string when
+// it does not parse
+func typedValue(valueType string, s string) interface{} {
+	switch valueType {
+	case "int":
+		if v, err := strconv.Atoi(s); err == nil {
+			return v
+		}
+	case "bigint", "long":
+		if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return v
+		}
+	case "float":
+		if v, err := strconv.ParseFloat(s, 64); err == nil {
+			return v
+		}
+	case "bool":
+		if v, err := strconv.ParseBool(s); err == nil {
+			return v
+		}
 	}
-	return generateRandomValue(g.Config.ValueType)
+	return s
 }
 
 // SetGenerator generates set values
@@ -182,17 +488,33 @@ type SetGenerator struct {
 	Config SetConfig
 }
 
-// Generate generates a random set
+// Generate generates a random set of distinct elements. Sets drawn from predefined
+// values never hold duplicates and are capped at the number of distinct values,
+// which manifests must keep at or above min_elements. Generated elements are
+// retried up to maxSetAttempts times per element before the set is returned smaller.
 func (g *SetGenerator) Generate() interface{} {
-	numElements := gofakeit.IntRange(g.Config.MinElements, g.Config.MaxElements)
-	result := make([]interface{}, 0, numElements)
-	seen := make(map[interface{}]bool)
+	numElements := collectionSize(g.Rand(), g.Config.MinElements, g.Config.MaxElements)
+
+	if len(g.Config.Values) > 0 {
+		pool := distinctStrings(g.Config.Values)
+		g.Rand().ShuffleStrings(pool)
+		if numElements > len(pool) {
+			numElements = len(pool)
+		}
+		result := make([]interface{}, numElements)
+		for i := range result {
+			result[i] = pool[i]
+		}
+		return result
+	}
 
-	for i := 0; i < numElements*2 && len(result) < numElements; i++ { // Try twice as many times to ensure we get enough unique values
+	result := make([]interface{}, 0, numElements)
+	seen := make(map[string]bool)
+	for i := 0; i < numElements*maxSetAttempts && len(result) < numElements; i++ {
 		value := g.generateElement()
-		valueStr := value.(string)
-		if !seen[valueStr] {
-			seen[valueStr] = true
+		key := fmt.Sprint(value)
+		if !seen[key] {
+			seen[key] = true
 			result = append(result, value)
 		}
 	}
@@ -200,11 +522,30 @@ func (g *SetGenerator) Generate() interface{} {
 	return result
 }
 
+// maxSetAttempts bounds how many generated elements are tried per set element
+const maxSetAttempts = 10
+
+// distinctStrings returns values without repeats, in their original order
+func distinctStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	distinct := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			distinct = append(distinct, value)
+		}
+	}
+	return distinct
+}
+
 func (g *SetGenerator) generateElement() interface{} {
-	if len(g.Config.Values) > 0 {
-		return gofakeit.RandomString(g.Config.Values)
+	if g.Config.Pattern != "" && stringPatternHandler != nil {
+		return stringPatternHandler(g.Rand(), g.Config.Pattern)
 	}
-	return generateRandomValue(g.Config.ElementType)
+	if g.Config.Element != nil && elementGenerateFunc != nil {
+		return elementGenerateFunc(*g.Config.Element, g.Rand())
+	}
+	return generateRandomValue(g.Rand(), g.Config.ElementType)
 }
 
 // ListGenerator generates list values
@@ -215,7 +556,7 @@ type ListGenerator struct {
 
 // Generate generates a random list
 func (g *ListGenerator) Generate() interface{} {
-	numElements := gofakeit.IntRange(g.Config.MinElements, g.Config.MaxElements)
+	numElements := collectionSize(g.Rand(), g.Config.MinElements, g.Config.MaxElements)
 	result := make([]interface{}, 0, numElements)
 
 	for i := 0; i < numElements; i++ {
@@ -228,17 +569,31 @@ func (g *ListGenerator) Generate() interface{} {
 
 func (g *ListGenerator) generateElement() interface{} {
 	if len(g.Config.Values) > 0 {
-		return gofakeit.RandomString(g.Config.Values)
+		return g.Rand().RandomString(g.Config.Values)
 	}
 	if g.Config.Pattern != "" {
 		// Use the registered pattern handler if available
 		if stringPatternHandler != nil {
-			return stringPatternHandler(g.Config.Pattern)
+			return stringPatternHandler(g.Rand(), g.Config.Pattern)
 		}
 		// Otherwise, just return the pattern
 		return g.Config.Pattern
 	}
-	return generateRandomValue(g.Config.ElementType)
+	if g.Config.Element != nil && elementGenerateFunc != nil {
+		return elementGenerateFunc(*g.Config.Element, g.Rand())
+	}
+	return generateRandomValue(g.Rand(), g.Config.ElementType)
+}
+
+// ElementGenerateFunc generates a single list or set element from its column config
+type ElementGenerateFunc func(col Column, f *gofakeit.Faker) interface{}
+
+// Global variable to hold the element generation function
+var elementGenerateFunc ElementGenerateFunc
+
+// RegisterGenerateElement registers a function for list and set element generation
+func RegisterGenerateElement(fn ElementGenerateFunc) {
+	elementGenerateFunc = fn
 }
 
 // UDTGenerator generates UDT values
@@ -293,6 +648,32 @@ func (g *TupleGenerator) Generate() interface{} {
 	return make([]interface{}, len(g.Config.Elements))
 }
 
+// ObjectsGenerator generates arrays of sub-records
+type ObjectsGenerator struct {
+	BaseGenerator
+	Config ObjectsConfig
+}
+
+// Function type for objects generation
+type ObjectsGenerateFunc func(g *ObjectsGenerator) interface{}
+
+// Global variable to hold the objects generation function
+var objectsGenerateFunc ObjectsGenerateFunc
+
+// RegisterGenerateObjects registers a function for objects generation
+func RegisterGenerateObjects(fn ObjectsGenerateFunc) {
+	objectsGenerateFunc = fn
+}
+
+// Generate generates a random array of sub-records
+func (g *ObjectsGenerator) Generate() interface{} {
+	if objectsGenerateFunc != nil {
+		return objectsGenerateFunc(g)
+	}
+	// Default implementation as a fallback
+	return []interface{}{}
+}
+
 // NumericGenerator generates numeric values with range constraints
 type NumericGenerator struct {
 	BaseGenerator
@@ -314,7 +695,7 @@ func (g *NumericGenerator) Generate() interface{} {
 				max = maxVal
 			}
 		}
-		return gofakeit.Float64Range(min, max)
+		return floatInRange(g.Rand(), g.Config, min, max)
 	} else {
 		min, max := 0, 1000000
 		if g.Config.Min != nil {
@@ -327,18 +708,67 @@ func (g *NumericGenerator) Generate() interface{} {
 				max = maxVal
 			}
 		}
-		return gofakeit.IntRange(min, max)
+		return intInRange(g.Rand(), g.Config, min, max)
 	}
 }
 
+// BigIntGenerator generates 64-bit integers for bigint and long columns, whose
+// values may not fit the platform's int
+type BigIntGenerator struct {
+	BaseGenerator
+	Config Range
+}
+
+// Generate generates a random int64 within the range
+func (g *BigIntGenerator) Generate() interface{} {
+	min, max := int64(0), int64(1000000)
+	if minVal, ok := int64Bound(g.Config.Min); ok {
+		min = minVal
+	}
+	if maxVal, ok := int64Bound(g.Config.Max); ok {
+		max = maxVal
+	}
+	return int64InRange(g.Rand(), g.Config, min, max)
+}
+
+// int64Bound reads a range bound as a 64-bit integer, YAML decodes integers as
+// int, or as int64 or uint64 when they do not fit int
+func int64Bound(bound interface{}) (int64, bool) {
+	switch v := bound.(type) {
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int64(v), true
+		}
+	}
+	return 0, false
+}
+
+// int64Range returns a random int64 between min and max inclusive
+func int64Range(f *gofakeit.Faker, min, max int64) int64 {
+	if max <= min {
+		return min
+	}
+	// The span is computed in uint64 so ranges wider than math.MaxInt64 do not overflow
+	span := uint64(max) - uint64(min) + 1
+	if span == 0 {
+		return int64(f.Uint64())
+	}
+	return min + int64(f.Uint64()%span)
+}
+
 // StringGenerator generates string values
 type StringGenerator struct {
 	BaseGenerator
 	Column Column
 }
 
-// StringPatternHandler defines a function type for handling patterns in strings
-type StringPatternHandler func(pattern string) string
+// StringPatternHandler defines a function type for handling patterns in strings,
+// drawing random characters from f
+type StringPatternHandler func(f *gofakeit.Faker, pattern string) string
 
 // Global variable to hold the pattern handler function
 var stringPatternHandler StringPatternHandler
@@ -351,20 +781,49 @@ func RegisterStringPatternHandler(handler StringPatternHandler) {
 // Generate generates a random string value
 func (g *StringGenerator) Generate() interface{} {
 	if len(g.Column.Value) > 0 {
-		return gofakeit.RandomString(g.Column.Value)
+		return g.Rand().RandomString(g.Column.Value)
+	}
+	if len(g.Column.ValuesFiles) > 0 {
+		return pickValuesFile(g.Rand(), g.Column.ValuesFiles)
 	}
 	if g.Column.Pattern != "" {
 		// Use the registered pattern handler if available
 		if stringPatternHandler != nil {
-			return stringPatternHandler(g.Column.Pattern)
+			return stringPatternHandler(g.Rand(), g.Column.Pattern)
 		}
 		// Otherwise, just return the pattern
 		return g.Column.Pattern
 	}
 	if strings.Contains(g.Column.Name, "name") {
-		return gofakeit.Name()
+		return g.Rand().Name()
 	}
-	return gofakeit.Word()
+	return g.Rand().Word()
+}
+
+// TextGenerator generates sentences and paragraphs of configurable size
+type TextGenerator struct {
+	BaseGenerator
+	Column Column
+}
+
+// Generate generates a random sentence, or paragraphs separated by newlines
+func (g *TextGenerator) Generate() interface{} {
+	words := g.Column.Words
+	if words <= 0 {
+		words = 5
+	}
+	if g.Column.Type != "paragraph" {
+		return g.Rand().Sentence(words)
+	}
+
+	sentences, paragraphs := g.Column.Sentences, g.Column.Paragraphs
+	if sentences <= 0 {
+		sentences = 3
+	}
+	if paragraphs <= 0 {
+		paragraphs = 1
+	}
+	return g.Rand().Paragraph(paragraphs, sentences, words, "\n")
 }
 
 // TimeGenerator generates time/date values
@@ -416,46 +875,118 @@ func (g *JSONGenerator) Generate() interface{} {
 
 	if len(g.Config) > 0 {
 		for _, field := range g.Config {
-			jsonObj[field.Name] = generateRandomValueWithRange(field.Type, field.Range)
+			jsonObj[field.Name] = generateJSONField(g.Rand(), field)
 		}
 	} else {
-		numKeys := gofakeit.IntRange(1, 5)
+		numKeys := g.Rand().IntRange(1, 5)
 		for i := 0; i < numKeys; i++ {
-			field := gofakeit.Word()
-			valueType := getRandomValueType()
-			jsonObj[field] = generateRandomValue(valueType)
+			field := g.Rand().Word()
+			valueType := getRandomValueType(g.Rand())
+			jsonObj[field] = generateRandomValue(g.Rand(), valueType)
 		}
 	}
 
 	return jsonObj
 }
 
+// generateJSONField generates a JSON field value, recursing into nested objects and arrays.
+// Patterns, value lists and formats behave as they do for regular columns.
+func generateJSONField(f *gofakeit.Faker, field FieldConfig) interface{} {
+	if field.NullProbability > 0 && f.Float64() < field.NullProbability {
+		return nil
+	}
+	if len(field.Value) > 0 {
+		return f.RandomString(field.Value)
+	}
+	if field.Pattern != "" {
+		return (&StringGenerator{BaseGenerator: BaseGenerator{Faker: f}, Column: Column{Name: field.Name, Pattern: field.Pattern}}).Generate()
+	}
+
+	switch field.Type {
+	case "date", "timestamp":
+		if field.Format == "" {
+			return generateRandomValueWithRange(f, field.Type, field.Range)
+		}
+		return (&TimeGenerator{BaseGenerator: BaseGenerator{Faker: f}, Column: Column{
+			Name:   field.Name,
+			Type:   field.Type,
+			Format: field.Format,
+			Range:  field.Range,
+		}}).Generate()
+	case "object":
+		return (&JSONGenerator{BaseGenerator: BaseGenerator{Faker: f}, Config: field.Fields}).Generate()
+	case "array":
+		minLength, maxLength := field.Items.MinLength, field.Items.MaxLength
+		if minLength == 0 && maxLength == 0 {
+			minLength, maxLength = 1, 3
+		}
+		elements := make([]interface{}, f.IntRange(minLength, maxLength))
+		for i := range elements {
+			elements[i] = generateJSONField(f, FieldConfig{
+				Type:   field.Items.ElementType,
+				Range:  field.Range,
+				Fields: field.Fields,
+			})
+		}
+		return elements
+	default:
+		return generateRandomValueWithRange(f, field.Type, field.Range)
+	}
+}
+
 // Helper functions
 
+const (
+	// defaultMinElements and defaultMaxElements size collections configured
+	// without min or max
+	defaultMinElements = 1
+	defaultMaxElements = 3
+)
+
+// collectionSize picks how many entries a map, set or list gets. Collections
+// without bounds get a small default range, and a max below min is raised to
+// min; manifests with such bounds are rejected at load time.
+func collectionSize(f *gofakeit.Faker, min, max int) int {
+	if min == 0 && max == 0 {
+		min, max = defaultMinElements, defaultMaxElements
+	}
+	if min < 0 {
+		min = 0
+	}
+	if max < min {
+		max = min
+	}
+	return f.IntRange(min, max)
+}
+
 // generateRandomValue generates a random value of the specified type
-func generateRandomValue(valueType string) interface{} {
+func generateRandomValue(f *gofakeit.Faker, valueType string) interface{} {
 	switch valueType {
 	case "string":
-		return gofakeit.Word()
+		return f.Word()
 	case "int":
-		return gofakeit.IntRange(0, 1000)
+		return f.IntRange(0, 1000)
+	case "bigint", "long":
+		return int64Range(f, 0, 1000)
 	case "float":
-		return gofakeit.Float64Range(0.0, 1000.0)
+		return f.Float64Range(0.0, 1000.0)
 	case "bool":
-		return gofakeit.Bool()
+		return f.Bool()
+	case "uuid":
+		return f.UUID()
 	case "date":
 		return time.Now().Format("2006-01-02")
 	case "email":
-		return gofakeit.Email()
+		return f.Email()
 	case "url":
-		return gofakeit.URL()
+		return f.URL()
 	default:
-		return gofakeit.Word()
+		return f.Word()
 	}
 }
 
 // generateRandomValueWithRange generates a random value of the specified type with range constraints
-func generateRandomValueWithRange(valueType string, rangeConfig Range) interface{} {
+func generateRandomValueWithRange(f *gofakeit.Faker, valueType string, rangeConfig Range) interface{} {
 	switch valueType {
 	case "int":
 		min, max := 0, 1000
@@ -469,7 +1000,16 @@ func generateRandomValueWithRange(valueType string, rangeConfig Range) interface
 				max = maxVal
 			}
 		}
-		return gofakeit.IntRange(min, max)
+		return intInRange(f, rangeConfig, min, max)
+	case "bigint", "long":
+		min, max := int64(0), int64(1000)
+		if minVal, ok := int64Bound(rangeConfig.Min); ok {
+			min = minVal
+		}
+		if maxVal, ok := int64Bound(rangeConfig.Max); ok {
+			max = maxVal
+		}
+		return int64InRange(f, rangeConfig, min, max)
 	case "float":
 		min, max := 0.0, 1000.0
 		if rangeConfig.Min != nil {
@@ -482,14 +1022,14 @@ func generateRandomValueWithRange(valueType string, rangeConfig Range) interface
 				max = maxVal
 			}
 		}
-		return gofakeit.Float64Range(min, max)
+		return floatInRange(f, rangeConfig, min, max)
 	default:
-		return generateRandomValue(valueType)
+		return generateRandomValue(f, valueType)
 	}
 }
 
 // getRandomValueType returns a random value type for JSON fields
-func getRandomValueType() string {
+func getRandomValueType(f *gofakeit.Faker) string {
 	types := []string{"string", "int", "float", "bool", "date", "email", "url"}
-	return types[gofakeit.IntRange(0, len(types)-1)]
+	return types[f.IntRange(0, len(types)-1)]
 }
@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"sync"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+// crockfordAlphabet is the Crockford base32 alphabet ULIDs are written in
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidSource generates ULIDs that increase monotonically within a run, even
+// when several are generated in the same millisecond
+type ulidSource struct {
+	mu       sync.Mutex
+	lastMs   uint64
+	entropy  [10]byte // 80 random bits following the timestamp
+	started  bool
+	clockNow func() time.Time
+}
+
+var ulids = &ulidSource{clockNow: time.Now}
+
+// next returns a 26 character ULID: a 48-bit millisecond timestamp followed by
+// 80 random bits drawn from faker. Within one millisecond, or if the clock goes
+// backwards, the previous random bits are incremented instead so the order still holds.
+func (s *ulidSource) next(faker *gofakeit.Faker) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ms := uint64(s.clockNow().UnixMilli())
+	if s.started && ms <= s.lastMs {
+		ms = s.lastMs
+		for i := len(s.entropy) - 1; i >= 0; i-- {
+			s.entropy[i]++
+			if s.entropy[i] != 0 {
+				break
+			}
+		}
+	} else {
+		for i := range s.entropy {
+			s.entropy[i] = faker.Uint8()
+		}
+	}
+	s.lastMs = ms
+	s.started = true
+
+	var id [16]byte
+	for i := 0; i < 6; i++ {
+		id[i] = byte(ms >> (40 - 8*i))
+	}
+	copy(id[6:], s.entropy[:])
+	return encodeCrockford(id)
+}
+
+// encodeCrockford writes the 128 bits of id as 26 base32 characters, the first
+// holding the top 3 bits
+func encodeCrockford(id [16]byte) string {
+	out := make([]byte, 26)
+	// Walk the bits from least significant, 5 at a time
+	var acc uint32
+	bits := 0
+	pos := len(out) - 1
+	for i := len(id) - 1; i >= 0; i-- {
+		acc |= uint32(id[i]) << bits
+		bits += 8
+		for bits >= 5 {
+			out[pos] = crockfordAlphabet[acc&31]
+			pos--
+			acc >>= 5
+			bits -= 5
+		}
+	}
+	out[0] = crockfordAlphabet[acc&31]
+	return string(out)
+}
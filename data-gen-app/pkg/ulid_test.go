@@ -0,0 +1,55 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+func TestULID(t *testing.T) {
+	col := types.Column{Name: "id", Type: "ulid"}
+
+	var previous string
+	for i := 0; i < 1000; i++ {
+		id := generateColumnValue(col, gofakeit.GlobalFaker).(string)
+		assert.Regexp(t, "^[0-7][0-9A-HJKMNP-TV-Z]{25}$", id)
+		assert.Greater(t, id, previous, "ULID %d does not sort after the one before it", i)
+		previous = id
+	}
+}
+
+func TestULIDTimestamp(t *testing.T) {
+	now := time.UnixMilli(1469918176385)
+	source := &ulidSource{clockNow: func() time.Time { return now }}
+
+	// The first 10 characters encode the millisecond timestamp
+	first := source.next(gofakeit.GlobalFaker)
+	assert.Equal(t, "01ARYZ6S41", first[:10])
+
+	// Within the same millisecond, and when the clock goes backwards, ids keep increasing
+	second := source.next(gofakeit.GlobalFaker)
+	now = now.Add(-time.Second)
+	third := source.next(gofakeit.GlobalFaker)
+	assert.Equal(t, "01ARYZ6S41", third[:10])
+	assert.Greater(t, second, first)
+	assert.Greater(t, third, second)
+
+	// The random bits come from the faker, so seeded runs repeat them
+	seeded := func() string {
+		return (&ulidSource{clockNow: func() time.Time { return now }}).next(gofakeit.New(5))
+	}
+	assert.Equal(t, seeded(), seeded())
+}
+
+func TestEncodeCrockford(t *testing.T) {
+	var max [16]byte
+	for i := range max {
+		max[i] = 0xff
+	}
+	assert.Equal(t, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ", encodeCrockford(max))
+	assert.Equal(t, "00000000000000000000000000", encodeCrockford([16]byte{}))
+	assert.Equal(t, "0000000000000000000000000Z", encodeCrockford([16]byte{15: 31}))
+}
@@ -0,0 +1,1080 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/expr-lang/expr"
+
+	"github.com/sujanks/data-gen-app/pkg/sink"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// supportedTypes lists the column types generation understands, an empty type generates strings
+var supportedTypes = map[string]bool{
+	"":            true,
+	"string":      true,
+	"int":         true,
+	"bigint":      true,
+	"long":        true,
+	"float":       true,
+	"decimal":     true,
+	"bool":        true,
+	"uuid":        true,
+	"ulid":        true,
+	"date":        true,
+	"timestamp":   true,
+	"sentence":    true,
+	"paragraph":   true,
+	"json":        true,
+	"map":         true,
+	"set":         true,
+	"list":        true,
+	"udt":         true,
+	"tuple":       true,
+	"objects":     true,
+	"hash":        true,
+	"phone":       true,
+	"ssn":         true,
+	"ein":         true,
+	"creditcard":  true,
+	"card_expiry": true,
+	"card_cvv":    true,
+	"template":    true,
+}
+
+// numericTypes lists the column types that generate numbers
+var numericTypes = map[string]bool{
+	"int":     true,
+	"bigint":  true,
+	"long":    true,
+	"float":   true,
+	"decimal": true,
+}
+
+// integerTypes are the column types generating whole numbers
+var integerTypes = map[string]bool{
+	"int":    true,
+	"bigint": true,
+	"long":   true,
+}
+
+// floatTypes are the column types generating float64 values
+var floatTypes = map[string]bool{
+	"float":   true,
+	"decimal": true,
+}
+
+// manifestChecks run every time a manifest is loaded
+var manifestChecks = []func(*types.Schema) error{
+	validateColumnTypes,
+	validateTypeConfig,
+	validateUDTs,
+	validateForeignKeys,
+	validateNullProbabilities,
+	validateChoices,
+	validateLiterals,
+	validateAggregates,
+	validateCompositeUnique,
+	validateUniquePerParent,
+	validateUniqueOverwrites,
+	validateAfter,
+	validateHashes,
+	validateMasks,
+	validateCounters,
+	validateSoftDeletes,
+	validateSchemaRules,
+}
+
+// dryRunChecks run in addition to manifestChecks when validating without generating
+var dryRunChecks = []func(*types.Schema) error{
+	validateDependencies,
+	validateRuleExpressions,
+}
+
+// Validate parses the manifest and runs every check generation relies on,
+// including dependency cycles, foreign key targets and rule expressions,
+// without producing any data
+func Validate(manifestPath string) error {
+	file, err := os.Open(manifestPath)
+	if err != nil {
+		return fmt.Errorf("error reading file %v", err)
+	}
+	defer file.Close()
+	return ValidateFrom(file, filepath.Dir(manifestPath))
+}
+
+// ValidateFrom validates the manifest read from r like Validate, resolving the
+// paths of its values files relative to dir
+func ValidateFrom(r io.Reader, dir string) error {
+	schema, err := decodeManifest(r, dir)
+	if err != nil {
+		return err
+	}
+	checks := append(append([]func(*types.Schema) error{}, manifestChecks...), dryRunChecks...)
+	return validateSchema(&schema, checks...)
+}
+
+// validateSchema runs the checks against the parsed manifest,
+// reporting every problem found rather than stopping at the first
+func validateSchema(schema *types.Schema, checks ...func(*types.Schema) error) error {
+	var problems []string
+	for _, check := range checks {
+		if err := check(schema); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid manifest: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validateColumnTypes rejects column types that would otherwise silently generate words,
+// including the fields of UDTs and the elements of tuples
+func validateColumnTypes(schema *types.Schema) error {
+	var problems []string
+	for _, table := range schema.Tables {
+		for _, col := range table.Columns {
+			problems = append(problems, unknownTypes(table.Name, col.Name, col)...)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("unknown column types: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// unknownTypes describes every unsupported type in col and its nested columns
+func unknownTypes(tableName string, path string, col types.Column) []string {
+	var problems []string
+	if _, registered := registeredType(col.Type); !supportedTypes[col.Type] && !registered {
+		problems = append(problems, fmt.Sprintf("table %s column %s has type %q", tableName, path, col.Type))
+	}
+	for _, field := range col.UDTConfig.Fields {
+		problems = append(problems, unknownTypes(tableName, path+"."+field.Name, field)...)
+	}
+	for i, element := range col.TupleConfig.Elements {
+		problems = append(problems, unknownTypes(tableName, fmt.Sprintf("%s[%d]", path, i), element)...)
+	}
+	for _, field := range col.ObjectsConfig.Fields {
+		problems = append(problems, unknownTypes(tableName, path+"[]."+field.Name, field)...)
+	}
+	for _, element := range collectionElements(col) {
+		problems = append(problems, unknownTypes(tableName, path+"[]", element)...)
+	}
+	return problems
+}
+
+// validateTypeConfig checks that collection, UDT, tuple and JSON columns carry the
+// configuration their generators need, and usable collection bounds
+func validateTypeConfig(schema *types.Schema) error {
+	var problems []string
+	for _, table := range schema.Tables {
+		for _, col := range table.Columns {
+			problems = append(problems, missingConfig(table.Name, col.Name, col)...)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("missing type config: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// missingConfig describes the required config absent from col and its nested columns
+func missingConfig(tableName string, path string, col types.Column) []string {
+	var missing []string
+	switch col.Type {
+	case "map":
+		cfg := col.MapConfig
+		if !validBounds(cfg.MinEntries, cfg.MaxEntries) {
+			missing = append(missing, "0 <= map_config.min_entries <= max_entries")
+		}
+		if len(cfg.Keys) == 0 && cfg.KeyType == "" && col.KeyType == "" {
+			missing = append(missing, "key_type or map_config.keys")
+		}
+		if len(cfg.Values) == 0 && cfg.ValueType == "" && col.ValueType == "" {
+			missing = append(missing, "value_type or map_config.values")
+		}
+	case "set":
+		cfg := col.SetConfig
+		if !validBounds(cfg.MinElements, cfg.MaxElements) {
+			missing = append(missing, "0 <= set_config.min_elements <= max_elements")
+		}
+		if len(cfg.Values) == 0 && cfg.Pattern == "" && cfg.Element == nil && cfg.ElementType == "" && col.ElementType == "" {
+			missing = append(missing, "element_type, set_config.values, set_config.pattern or set_config.element")
+		}
+		if distinct := len(distinctValues(cfg.Values)); distinct > 0 && distinct < cfg.MinElements {
+			missing = append(missing, fmt.Sprintf("at least min_elements (%d) distinct set_config.values, got %d", cfg.MinElements, distinct))
+		}
+	case "list":
+		cfg := col.ListConfig
+		if !validBounds(cfg.MinElements, cfg.MaxElements) {
+			missing = append(missing, "0 <= list_config.min_elements <= max_elements")
+		}
+		if len(cfg.Values) == 0 && cfg.Pattern == "" && cfg.Element == nil && cfg.ElementType == "" && col.ElementType == "" {
+			missing = append(missing, "element_type, list_config.values, list_config.pattern or list_config.element")
+		}
+	case "udt":
+		if len(col.UDTConfig.Fields) == 0 {
+			missing = append(missing, "udt_config.fields")
+		}
+	case "tuple":
+		if len(col.TupleConfig.Elements) == 0 {
+			missing = append(missing, "tuple_config.elements")
+		}
+	case "objects":
+		cfg := col.ObjectsConfig
+		if len(cfg.Fields) == 0 {
+			missing = append(missing, "objects_config.fields")
+		}
+		if cfg.Max <= 0 || cfg.Min < 0 || cfg.Min > cfg.Max {
+			missing = append(missing, "objects_config.min and max with 0 <= min <= max and max > 0")
+		}
+	case "json":
+		missing = append(missing, unnamedJSONFields("json_config", col.JSONConfig)...)
+	case "hash":
+		if len(col.HashConfig.Fields) == 0 {
+			missing = append(missing, "hash_config.fields")
+		}
+	case "phone":
+		if f := col.Format; f != "" && f != phoneNational && f != phoneE164 && !strings.ContainsRune(f, hashtag) {
+			missing = append(missing, "format national, e164 or a pattern containing #")
+		}
+	case "template":
+		if col.Template == "" {
+			missing = append(missing, "template")
+		}
+		for _, name := range unknownTemplateFunctions(col.Template) {
+			missing = append(missing, fmt.Sprintf("a gofakeit function for {{%s}}", name))
+		}
+	case "creditcard", "card_cvv":
+		if _, ok := cardNetworks[col.Network]; col.Network != "" && !ok {
+			missing = append(missing, "network visa, mastercard or amex")
+		}
+	case "uuid":
+		if col.Version != 0 && col.Version != 4 && col.Version != 7 {
+			missing = append(missing, "version 4 or 7")
+		}
+	}
+
+	if col.Luhn && !strings.ContainsRune(col.Pattern, hashtag) {
+		missing = append(missing, "a pattern containing # for luhn")
+	}
+	if col.Width < 0 || (col.Width > 0 && !integerTypes[col.Type]) {
+		missing = append(missing, "type int, bigint or long and a positive width for zero padding")
+	}
+	if col.Scale != nil && (!floatTypes[col.Type] || *col.Scale < -1) {
+		missing = append(missing, "type float or decimal and a scale of -1 or more")
+	}
+	if floatTypes[col.Type] && col.Format != "" && col.Format != sink.FloatFixed && col.Format != sink.FloatScientific {
+		missing = append(missing, "format fixed or scientific")
+	}
+	if r := col.Range; r.ExclusiveMin || r.ExclusiveMax {
+		if !numericTypes[col.Type] {
+			missing = append(missing, "type int, bigint, long, float or decimal for exclusive_min or exclusive_max")
+		} else if emptyExclusiveRange(col) {
+			missing = append(missing, "a range holding values between its exclusive bounds")
+		}
+	}
+	if r := col.Range; r.Step != 0 {
+		switch {
+		case r.Step < 0 || !numericTypes[col.Type]:
+			missing = append(missing, "type int, bigint, long, float or decimal and a positive step")
+		case integerTypes[col.Type] && r.Step != math.Trunc(r.Step):
+			missing = append(missing, "a whole number step for integer columns")
+		case !emptyExclusiveRange(col) && noMultipleInRange(col):
+			missing = append(missing, fmt.Sprintf("a range holding a multiple of step %v", r.Step))
+		}
+	}
+	if (col.Type == "date" || col.Type == "timestamp") && col.Range.Min != nil && col.Range.Max != nil {
+		format := defaultTimeFormat
+		if col.Format != "" && !isEpochFormat(col.Format) {
+			format = col.Format
+		}
+		// Bounds that do not parse are left to the generator, which falls back to the current time
+		minTime, minErr := parseTimeBound(format, col.Range.Min)
+		maxTime, maxErr := parseTimeBound(format, col.Range.Max)
+		if minErr == nil && maxErr == nil && minTime.After(maxTime) {
+			missing = append(missing, fmt.Sprintf("range min %v no later than max %v", col.Range.Min, col.Range.Max))
+		}
+	}
+	if col.ForeignFilter != "" && col.Foreign == "" {
+		missing = append(missing, "foreign for foreign_filter")
+	}
+	if len(col.ValuesFiles) > 0 {
+		if col.Type != "" && col.Type != "string" {
+			missing = append(missing, "type string for values_file")
+		}
+		if len(col.Value) > 0 || col.Pattern != "" {
+			missing = append(missing, "values_file without value, enum or pattern")
+		}
+		for _, file := range col.ValuesFiles {
+			if file.Weight < 0 {
+				missing = append(missing, fmt.Sprintf("a weight of 0 or more for values_file %s", file.Path))
+			}
+		}
+	}
+	switch col.Mode {
+	case "", modeRandom:
+	case modeSequential:
+		if len(col.Value) == 0 && len(col.ValuesFiles) == 0 {
+			missing = append(missing, "value, enum or values_file for mode sequential")
+		}
+	default:
+		missing = append(missing, "mode random or sequential")
+	}
+
+	var problems []string
+	for _, m := range missing {
+		problems = append(problems, fmt.Sprintf("table %s column %s (%s) requires %s", tableName, path, col.Type, m))
+	}
+	for _, field := range col.UDTConfig.Fields {
+		problems = append(problems, missingConfig(tableName, path+"."+field.Name, field)...)
+	}
+	for i, element := range col.TupleConfig.Elements {
+		problems = append(problems, missingConfig(tableName, fmt.Sprintf("%s[%d]", path, i), element)...)
+	}
+	for _, field := range col.ObjectsConfig.Fields {
+		problems = append(problems, missingConfig(tableName, path+"[]."+field.Name, field)...)
+	}
+	for _, element := range collectionElements(col) {
+		problems = append(problems, missingConfig(tableName, path+"[]", element)...)
+	}
+	return problems
+}
+
+// emptyExclusiveRange reports whether excluding the bounds of col's range leaves no number to generate
+func emptyExclusiveRange(col types.Column) bool {
+	min, okMin := toFloat(col.Range.Min)
+	max, okMax := toFloat(col.Range.Max)
+	if !okMin || !okMax {
+		return false
+	}
+	if !integerTypes[col.Type] {
+		return min >= max
+	}
+	if col.Range.ExclusiveMin {
+		min++
+	}
+	if col.Range.ExclusiveMax {
+		max--
+	}
+	return min > max
+}
+
+// noMultipleInRange reports whether no multiple of the step of col's range lies within its bounds
+func noMultipleInRange(col types.Column) bool {
+	r := col.Range
+	min, okMin := toFloat(r.Min)
+	max, okMax := toFloat(r.Max)
+	if !okMin || !okMax {
+		return false
+	}
+	low, high := types.StepMultiples(r, min, max)
+	return low > high
+}
+
+// collectionElements returns the element columns configured for a list or set
+func collectionElements(col types.Column) []types.Column {
+	var elements []types.Column
+	if col.Type == "list" && col.ListConfig.Element != nil {
+		elements = append(elements, *col.ListConfig.Element)
+	}
+	if col.Type == "set" && col.SetConfig.Element != nil {
+		elements = append(elements, *col.SetConfig.Element)
+	}
+	return elements
+}
+
+// distinctValues returns the distinct entries of values
+func distinctValues(values []string) map[string]bool {
+	distinct := make(map[string]bool, len(values))
+	for _, value := range values {
+		distinct[value] = true
+	}
+	return distinct
+}
+
+// validBounds reports whether collection bounds are usable, a max of zero means
+// unbounded when min is also zero and exactly min otherwise
+func validBounds(min, max int) bool {
+	return min >= 0 && max >= 0 && (max == 0 || min <= max)
+}
+
+// unnamedJSONFields reports explicitly configured JSON fields, at any depth, without a name
+func unnamedJSONFields(path string, config types.JSONConfig) []string {
+	var missing []string
+	for i, field := range config {
+		fieldPath := fmt.Sprintf("%s[%d]", path, i)
+		if field.Name == "" {
+			missing = append(missing, fieldPath+".name")
+		}
+		missing = append(missing, unnamedJSONFields(fieldPath+".fields", field.Fields)...)
+	}
+	return missing
+}
+
+// validateUDTs checks that every UDT column naming a declared type has exactly
+// the declared fields, in the declared order
+func validateUDTs(schema *types.Schema) error {
+	definitions := make(map[string]types.UDTDefinition)
+	for _, def := range schema.UDTs {
+		definitions[def.Name] = def
+	}
+
+	var problems []string
+	for _, table := range schema.Tables {
+		for _, col := range table.Columns {
+			if col.Type != "udt" {
+				continue
+			}
+			def, ok := definitions[col.UDTConfig.Name]
+			if !ok {
+				continue
+			}
+			if err := matchUDTFields(def, col.UDTConfig.Fields); err != nil {
+				problems = append(problems, fmt.Sprintf("table %s column %s: %v", table.Name, col.Name, err))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("udt validation failed: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// matchUDTFields compares a column's UDT fields against the declared type
+func matchUDTFields(def types.UDTDefinition, fields []types.Column) error {
+	if len(def.Fields) != len(fields) {
+		return fmt.Errorf("udt %s declares %d fields but column has %d", def.Name, len(def.Fields), len(fields))
+	}
+	for i, declared := range def.Fields {
+		field := fields[i]
+		if field.Name != declared.Name {
+			return fmt.Errorf("udt %s field %d is %q but column has %q", def.Name, i, declared.Name, field.Name)
+		}
+		if declared.Type != "" && field.Type != "" && field.Type != declared.Type {
+			return fmt.Errorf("udt %s field %s is %s but column has %s", def.Name, declared.Name, declared.Type, field.Type)
+		}
+	}
+	return nil
+}
+
+// validateDependencies rejects depends_on references to unknown tables and dependency cycles
+func validateDependencies(schema *types.Schema) error {
+	dependsOn := make(map[string]string)
+	for _, table := range schema.Tables {
+		dependsOn[table.Name] = table.DependsOn
+	}
+
+	var problems []string
+	for _, table := range schema.Tables {
+		if table.DependsOn == "" {
+			continue
+		}
+		if _, ok := dependsOn[table.DependsOn]; !ok {
+			problems = append(problems, fmt.Sprintf("table %s depends on unknown table %s", table.Name, table.DependsOn))
+		}
+	}
+
+	// Each table has at most one dependency, so a cycle is found by walking
+	// the chain until it ends or revisits the starting table
+	reported := make(map[string]bool)
+	for _, table := range schema.Tables {
+		chain := []string{table.Name}
+		for next := dependsOn[table.Name]; next != ""; next = dependsOn[next] {
+			chain = append(chain, next)
+			if next == table.Name {
+				if !reported[table.Name] {
+					for _, name := range chain {
+						reported[name] = true
+					}
+					problems = append(problems, fmt.Sprintf("dependency cycle: %s", strings.Join(chain, " -> ")))
+				}
+				break
+			}
+			if len(chain) > len(schema.Tables) {
+				// The chain leads into a cycle that does not include this table
+				break
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("dependency validation failed: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// validateForeignKeys checks that every foreign reference names an existing table and
+// a column marked parent, otherwise the referencing column would silently stay nil
+func validateForeignKeys(schema *types.Schema) error {
+	tables := make(map[string]bool)
+	columns := make(map[string]types.Column)
+	for _, table := range schema.Tables {
+		tables[table.Name] = true
+		for _, col := range table.Columns {
+			columns[table.Name+"."+col.Name] = col
+		}
+	}
+
+	external := make(map[string]bool)
+	for _, key := range schema.ExternalKeys {
+		external[key] = true
+	}
+
+	var problems []string
+	for _, table := range schema.Tables {
+		for _, col := range table.Columns {
+			if col.Foreign == "" || external[col.Foreign] {
+				continue
+			}
+			target, ok := columns[col.Foreign]
+			tableName, _, _ := strings.Cut(col.Foreign, ".")
+			switch {
+			case !tables[tableName]:
+				problems = append(problems, fmt.Sprintf("table %s column %s references unknown table %s", table.Name, col.Name, tableName))
+			case !ok:
+				problems = append(problems, fmt.Sprintf("table %s column %s references unknown column %s", table.Name, col.Name, col.Foreign))
+			case !target.Parent:
+				problems = append(problems, fmt.Sprintf("table %s column %s references %s which is not marked parent", table.Name, col.Name, col.Foreign))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("foreign key validation failed: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// validateNullProbabilities checks that null_probability is a usable chance and
+// only set on foreign columns, the only ones it leaves empty
+func validateNullProbabilities(schema *types.Schema) error {
+	var problems []string
+	for _, table := range schema.Tables {
+		for _, col := range table.Columns {
+			if col.NullProbability == 0 {
+				continue
+			}
+			scope := fmt.Sprintf("table %s column %s", table.Name, col.Name)
+			if p := col.NullProbability; p < 0 || p > 1 {
+				problems = append(problems, fmt.Sprintf("%s has null_probability %v, expected 0 to 1", scope, p))
+			}
+			if col.Foreign == "" {
+				problems = append(problems, fmt.Sprintf("%s has null_probability but no foreign", scope))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("null probability validation failed: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// validateRuleExpressions compiles every column condition, rule condition and ${...} value expression
+func validateRuleExpressions(schema *types.Schema) error {
+	var problems []string
+	for _, table := range schema.Tables {
+		for _, col := range table.Columns {
+			scope := fmt.Sprintf("table %s column %s", table.Name, col.Name)
+			if col.When != "" {
+				if err := compileExpression(col.When); err != nil {
+					problems = append(problems, fmt.Sprintf("%s: when %q: %v", scope, col.When, err))
+				}
+			}
+			if col.ForeignFilter != "" {
+				if err := compileExpression(col.ForeignFilter); err != nil {
+					problems = append(problems, fmt.Sprintf("%s: foreign_filter %q: %v", scope, col.ForeignFilter, err))
+				}
+			}
+			problems = append(problems, invalidRules(scope, col.Rules)...)
+		}
+		problems = append(problems, invalidRules("table "+table.Name, table.Rules)...)
+	}
+	schemaRules := make([]types.Rule, len(schema.Rules))
+	for i, rule := range schema.Rules {
+		schemaRules[i] = rule.Rule
+	}
+	problems = append(problems, invalidRules("schema", schemaRules)...)
+
+	if len(problems) > 0 {
+		return fmt.Errorf("rule validation failed: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// invalidRules describes the rule expressions in rules that fail to compile
+func invalidRules(scope string, rules []types.Rule) []string {
+	var problems []string
+	for i, rule := range rules {
+		if rule.When != "" || len(rule.Cases) == 0 {
+			if err := compileExpression(rule.When); err != nil {
+				problems = append(problems, fmt.Sprintf("%s rule %d: when %q: %v", scope, i, rule.When, err))
+			}
+		}
+		branches := []map[string]string{rule.Then, rule.Otherwise}
+		for j, branch := range rule.Cases {
+			if err := compileExpression(branch.When); err != nil {
+				problems = append(problems, fmt.Sprintf("%s rule %d case %d: when %q: %v", scope, i, j, branch.When, err))
+			}
+			branches = append(branches, branch.Then)
+		}
+		for _, values := range branches {
+			for _, field := range sortedKeys(values) {
+				value := values[field]
+				if !strings.Contains(value, "${") || !strings.Contains(value, "}") {
+					continue
+				}
+				expression := strings.TrimPrefix(strings.TrimSuffix(value, "}"), "${")
+				if err := compileExpression(expression); err != nil {
+					problems = append(problems, fmt.Sprintf("%s rule %d: %s %q: %v", scope, i, field, value, err))
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// compileExpression compiles an expression against the same environment rules run in
+func compileExpression(expression string) error {
+	_, err := expr.Compile(expression, expr.Env(initEnv(nil, nil)), expr.AllowUndefinedVariables())
+	if err != nil {
+		// Keep only the message, the compiler appends a multi-line source snippet
+		return fmt.Errorf("%s", strings.SplitN(err.Error(), "\n", 2)[0])
+	}
+	return nil
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validateChoices checks that correlated choice rows only set declared columns
+func validateChoices(schema *types.Schema) error {
+	var problems []string
+	for _, table := range schema.Tables {
+		columns := make(map[string]bool)
+		for _, col := range table.Columns {
+			columns[col.Name] = true
+		}
+		for i, choice := range table.Choices {
+			for _, name := range sortedKeys(choice) {
+				if !columns[name] {
+					problems = append(problems, fmt.Sprintf("table %s choices[%d] sets undeclared column %s", table.Name, i, name))
+				}
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("choice validation failed: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// validateLiterals checks that column constants and defaults convert to the column's type
+func validateLiterals(schema *types.Schema) error {
+	var problems []string
+	for _, table := range schema.Tables {
+		for _, col := range table.Columns {
+			literals := []struct{ field, value string }{
+				{"const", col.Const},
+				{"default", col.Default},
+			}
+			for _, literal := range literals {
+				if literal.value == "" {
+					continue
+				}
+				if _, err := literalValue(col, literal.value); err != nil {
+					problems = append(problems, fmt.Sprintf("table %s column %s %s %q is not a valid %s", table.Name, col.Name, literal.field, literal.value, col.Type))
+				}
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("literal validation failed: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// validateAggregates checks that aggregate columns name a child foreign column
+// referencing their own table, and for sums a child column to add up
+func validateAggregates(schema *types.Schema) error {
+	columns := make(map[string]types.Column)
+	for _, table := range schema.Tables {
+		for _, col := range table.Columns {
+			columns[table.Name+"."+col.Name] = col
+		}
+	}
+
+	var problems []string
+	for _, table := range schema.Tables {
+		for _, col := range table.Columns {
+			aggregate := col.Aggregate
+			if aggregate == (types.Aggregate{}) {
+				continue
+			}
+			scope := fmt.Sprintf("table %s column %s", table.Name, col.Name)
+			if aggregate.Function != aggregateCount && aggregate.Function != aggregateSum {
+				problems = append(problems, fmt.Sprintf("%s has aggregate function %q, use count or sum", scope, aggregate.Function))
+			}
+			child, ok := columns[aggregate.Foreign]
+			parentTable, _, _ := strings.Cut(child.Foreign, ".")
+			if !ok || parentTable != table.Name {
+				problems = append(problems, fmt.Sprintf("%s aggregates %q which is not a foreign column referencing %s", scope, aggregate.Foreign, table.Name))
+				continue
+			}
+			childTable, _, _ := strings.Cut(aggregate.Foreign, ".")
+			if _, ok := columns[childTable+"."+aggregate.Field]; aggregate.Function == aggregateSum && !ok {
+				problems = append(problems, fmt.Sprintf("%s sums unknown column %s.%s", scope, childTable, aggregate.Field))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("aggregate validation failed: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// validateCompositeUnique checks that composite unique constraints name declared columns
+func validateCompositeUnique(schema *types.Schema) error {
+	var problems []string
+	for _, table := range schema.Tables {
+		columns := make(map[string]bool)
+		for _, col := range table.Columns {
+			columns[col.Name] = true
+		}
+		for i, constraint := range table.Unique {
+			if len(constraint) == 0 {
+				problems = append(problems, fmt.Sprintf("table %s unique[%d] has no columns", table.Name, i))
+			}
+			for _, name := range constraint {
+				if !columns[name] {
+					problems = append(problems, fmt.Sprintf("table %s unique[%d] names undeclared column %s", table.Name, i, name))
+				}
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("unique constraint validation failed: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// validateUniquePerParent checks that unique_per_parent names an unconditional
+// foreign column of the same table, which is set before the columns it scopes
+func validateUniquePerParent(schema *types.Schema) error {
+	var problems []string
+	for _, table := range schema.Tables {
+		columns := make(map[string]types.Column)
+		for _, col := range table.Columns {
+			columns[col.Name] = col
+		}
+		for _, col := range table.Columns {
+			parent := col.Validation.UniquePerParent
+			if parent == "" {
+				continue
+			}
+			scope := fmt.Sprintf("table %s column %s", table.Name, col.Name)
+			switch foreign, ok := columns[parent]; {
+			case !ok:
+				problems = append(problems, fmt.Sprintf("%s is unique per undeclared column %s", scope, parent))
+			case foreign.Foreign == "":
+				problems = append(problems, fmt.Sprintf("%s is unique per %s which is not a foreign column", scope, parent))
+			case foreign.Validation.UniquePerParent != "":
+				problems = append(problems, fmt.Sprintf("%s is unique per %s which is itself unique per parent", scope, parent))
+			case foreign.When != "":
+				problems = append(problems, fmt.Sprintf("%s is unique per %s which has a when condition", scope, parent))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("unique_per_parent validation failed: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// validateUniqueOverwrites rejects unique columns, including those of composite
+// unique constraints, whose values choices, rules, masks or sequential mode replace
+// after their uniqueness was checked. Padding only changes how a number is written,
+// so padded values stay unique.
+func validateUniqueOverwrites(schema *types.Schema) error {
+	var problems []string
+	schemaRules := schemaRulesByTable(*schema)
+	for _, table := range schema.Tables {
+		unique := make(map[string]bool)
+		for _, col := range table.Columns {
+			if col.Validation.Unique || col.Validation.UniquePerParent != "" {
+				unique[col.Name] = true
+			}
+		}
+		for _, constraint := range table.Unique {
+			for _, name := range constraint {
+				unique[name] = true
+			}
+		}
+
+		overwritten := make(map[string]string) // What replaces each column's value, by column
+		for _, choice := range table.Choices {
+			for name := range choice {
+				overwritten[name] = "choices set"
+			}
+		}
+		rules := append(append([]types.Rule(nil), table.Rules...), schemaRules[table.Name]...)
+		for _, col := range table.Columns {
+			rules = append(rules, col.Rules...)
+			if col.Mask.Strategy != "" {
+				overwritten[col.Name] = "a mask replaces"
+			}
+			if col.Mode == modeSequential {
+				overwritten[col.Name] = "mode sequential replaces"
+			}
+		}
+		for _, rule := range rules {
+			branches := []map[string]string{rule.Then, rule.Otherwise}
+			for _, branch := range rule.Cases {
+				branches = append(branches, branch.Then)
+			}
+			for _, values := range branches {
+				for name := range values {
+					overwritten[name] = "a rule sets"
+				}
+			}
+		}
+
+		for _, col := range table.Columns {
+			if by, ok := overwritten[col.Name]; ok && unique[col.Name] {
+				problems = append(problems, fmt.Sprintf("table %s column %s is unique but %s it after uniqueness is checked", table.Name, col.Name, by))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("unique validation failed: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// validateAfter checks that after columns are timestamps following an earlier
+// declared timestamp column with a usable max_offset
+func validateAfter(schema *types.Schema) error {
+	var problems []string
+	for _, table := range schema.Tables {
+		declared := make(map[string]types.Column)
+		for _, col := range table.Columns {
+			if col.After.Column != "" {
+				scope := fmt.Sprintf("table %s column %s", table.Name, col.Name)
+				earlier, ok := declared[col.After.Column]
+				switch {
+				case col.Type != "timestamp":
+					problems = append(problems, fmt.Sprintf("%s has type %q, after needs a timestamp", scope, col.Type))
+				case !ok:
+					problems = append(problems, fmt.Sprintf("%s follows %q which is not declared before it", scope, col.After.Column))
+				case earlier.Type != "timestamp":
+					problems = append(problems, fmt.Sprintf("%s follows %s which is not a timestamp", scope, earlier.Name))
+				}
+				if _, err := afterMaxOffset(col.After); err != nil {
+					problems = append(problems, fmt.Sprintf("%s has invalid max_offset: %v", scope, err))
+				}
+			}
+			declared[col.Name] = col
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("after validation failed: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// validateHashes checks that hash columns digest declared columns with a supported
+// algorithm and encoding
+func validateHashes(schema *types.Schema) error {
+	var problems []string
+	for _, table := range schema.Tables {
+		columns := make(map[string]bool)
+		for _, col := range table.Columns {
+			columns[col.Name] = true
+		}
+		for _, col := range table.Columns {
+			if col.Type != "hash" {
+				continue
+			}
+			scope := fmt.Sprintf("table %s column %s", table.Name, col.Name)
+			cfg := col.HashConfig
+			for _, field := range cfg.Fields {
+				if !columns[field] || field == col.Name {
+					problems = append(problems, fmt.Sprintf("%s hashes unknown column %s", scope, field))
+				}
+			}
+			if _, ok := hashAlgorithms[cfg.Algorithm]; !ok {
+				problems = append(problems, fmt.Sprintf("%s has algorithm %q, use sha256, sha1 or md5", scope, cfg.Algorithm))
+			}
+			if cfg.Encoding != "" && cfg.Encoding != "hex" && cfg.Encoding != "base64" {
+				problems = append(problems, fmt.Sprintf("%s has encoding %q, use hex or base64", scope, cfg.Encoding))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("hash validation failed: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// validateMasks checks that masks use a known strategy on string columns
+func validateMasks(schema *types.Schema) error {
+	var problems []string
+	for _, table := range schema.Tables {
+		for _, col := range table.Columns {
+			mask := col.Mask
+			if mask == (types.Mask{}) {
+				continue
+			}
+			scope := fmt.Sprintf("table %s column %s", table.Name, col.Name)
+			if col.Type != "" && col.Type != "string" {
+				problems = append(problems, fmt.Sprintf("%s has type %q, masks apply to strings", scope, col.Type))
+			}
+			switch mask.Strategy {
+			case maskLast4, maskEmail:
+			case maskFixed:
+				if mask.Value == "" {
+					problems = append(problems, fmt.Sprintf("%s fixed mask needs a value", scope))
+				}
+			default:
+				problems = append(problems, fmt.Sprintf("%s has mask strategy %q, use last4, email or fixed", scope, mask.Strategy))
+			}
+			if len([]rune(mask.Char)) > 1 {
+				problems = append(problems, fmt.Sprintf("%s mask char %q must be a single character", scope, mask.Char))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("mask validation failed: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// validateCounters checks that counter columns are numeric and that their delta
+// columns exist in the same table and are numeric too
+func validateCounters(schema *types.Schema) error {
+	var problems []string
+	for _, table := range schema.Tables {
+		columns := make(map[string]types.Column)
+		for _, col := range table.Columns {
+			columns[col.Name] = col
+		}
+		for _, col := range table.Columns {
+			if col.Counter == nil {
+				continue
+			}
+			scope := fmt.Sprintf("table %s column %s", table.Name, col.Name)
+			if !numericTypes[col.Type] {
+				problems = append(problems, fmt.Sprintf("%s has type %q, counter needs int, bigint, long, float or decimal", scope, col.Type))
+			}
+			if integerTypes[col.Type] && col.Counter.Start != math.Trunc(col.Counter.Start) {
+				problems = append(problems, fmt.Sprintf("%s starts at %v, counter start must be a whole number for integer columns", scope, col.Counter.Start))
+			}
+			if col.Counter.Delta == "" {
+				continue
+			}
+			delta, ok := columns[col.Counter.Delta]
+			switch {
+			case !ok:
+				problems = append(problems, fmt.Sprintf("%s adds unknown column %s", scope, col.Counter.Delta))
+			case delta.Name == col.Name:
+				problems = append(problems, fmt.Sprintf("%s adds itself, leave delta unset to add its generated value", scope))
+			case !numericTypes[delta.Type]:
+				problems = append(problems, fmt.Sprintf("%s adds %s which is not numeric", scope, delta.Name))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("counter validation failed: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// validateSoftDeletes checks that soft delete columns are timestamps following
+// the creation time, with a usable probability and a bool flag column
+func validateSoftDeletes(schema *types.Schema) error {
+	var problems []string
+	for _, table := range schema.Tables {
+		columns := make(map[string]types.Column)
+		for _, col := range table.Columns {
+			columns[col.Name] = col
+		}
+		for _, col := range table.Columns {
+			if col.SoftDelete == nil {
+				continue
+			}
+			scope := fmt.Sprintf("table %s column %s", table.Name, col.Name)
+			if col.After.Column == "" {
+				problems = append(problems, fmt.Sprintf("%s needs after, naming the creation time the deletion follows", scope))
+			}
+			if p := col.SoftDelete.Probability; p < 0 || p > 1 {
+				problems = append(problems, fmt.Sprintf("%s has soft_delete probability %v, expected 0 to 1", scope, p))
+			}
+			if col.SoftDelete.Flag == "" {
+				continue
+			}
+			flag, ok := columns[col.SoftDelete.Flag]
+			switch {
+			case !ok:
+				problems = append(problems, fmt.Sprintf("%s flags unknown column %s", scope, col.SoftDelete.Flag))
+			case flag.Type != "bool":
+				problems = append(problems, fmt.Sprintf("%s flags %s which is not a bool", scope, flag.Name))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("soft_delete validation failed: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// validateSchemaRules checks that every schema rule applies to a table of the manifest
+func validateSchemaRules(schema *types.Schema) error {
+	tables := make(map[string]bool)
+	for _, table := range schema.Tables {
+		tables[table.Name] = true
+	}
+	var problems []string
+	for i, rule := range schema.Rules {
+		switch {
+		case rule.Table == "":
+			problems = append(problems, fmt.Sprintf("schema rule %d has no table", i))
+		case !tables[rule.Table]:
+			problems = append(problems, fmt.Sprintf("schema rule %d applies to unknown table %s", i, rule.Table))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("schema rule validation failed: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,911 @@
+package pkg
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+func TestValidateUDTs(t *testing.T) {
+	addressType := types.UDTDefinition{
+		Name: "address_type",
+		Fields: []types.UDTField{
+			{Name: "street", Type: "string"},
+			{Name: "city", Type: "string"},
+			{Name: "zip_code", Type: "string"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		fields  []types.Column
+		wantErr string
+	}{
+		{
+			name: "Matching definition",
+			fields: []types.Column{
+				{Name: "street", Type: "string"},
+				{Name: "city", Type: "string"},
+				{Name: "zip_code", Pattern: "#####"},
+			},
+		},
+		{
+			name: "Missing field",
+			fields: []types.Column{
+				{Name: "street", Type: "string"},
+				{Name: "city", Type: "string"},
+			},
+			wantErr: "declares 3 fields but column has 2",
+		},
+		{
+			name: "Fields out of order",
+			fields: []types.Column{
+				{Name: "city", Type: "string"},
+				{Name: "street", Type: "string"},
+				{Name: "zip_code", Type: "string"},
+			},
+			wantErr: `field 0 is "street" but column has "city"`,
+		},
+		{
+			name: "Mismatched field type",
+			fields: []types.Column{
+				{Name: "street", Type: "string"},
+				{Name: "city", Type: "string"},
+				{Name: "zip_code", Type: "int"},
+			},
+			wantErr: "field zip_code is string but column has int",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &types.Schema{
+				UDTs: []types.UDTDefinition{addressType},
+				Tables: []types.Table{
+					{
+						Name: "users",
+						Columns: []types.Column{
+							{
+								Name:      "address",
+								Type:      "udt",
+								UDTConfig: types.UDTConfig{Name: "address_type", Fields: tt.fields},
+							},
+						},
+					},
+				},
+			}
+
+			err := validateUDTs(schema)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "table users column address")
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidateColumnTypes(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: users
+  columns:
+  - name: id
+    type: uuid
+  - name: age
+    type: integr
+  - name: location
+    type: tuple
+    tuple_config:
+      elements:
+      - type: decimal
+      - type: strng
+`)
+
+	_, err := LoadSchema(manifestPath)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `table users column age has type "integr"`)
+	assert.Contains(t, err.Error(), `table users column location[1] has type "strng"`)
+	assert.NotContains(t, err.Error(), "column id")
+
+	// Generation refuses to run against the invalid manifest
+	mockSink := &MockDataSink{}
+	assert.Error(t, GenerateData(mockSink, 1, manifestPath))
+	assert.Empty(t, mockSink.Records)
+}
+
+func TestValidateShippedManifests(t *testing.T) {
+	for _, manifestPath := range []string{"../manifest/application.yaml", "../manifest/test.yaml"} {
+		_, err := LoadSchema(manifestPath)
+		assert.NoError(t, err, manifestPath)
+		assert.NoError(t, Validate(manifestPath), manifestPath)
+	}
+}
+
+func TestValidateTypeConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		column  types.Column
+		wantErr []string
+	}{
+		{
+			name: "Complete map config",
+			column: types.Column{
+				Name:      "prefs",
+				Type:      "map",
+				KeyType:   "string",
+				MapConfig: types.MapConfig{MinEntries: 1, MaxEntries: 2, ValueType: "string"},
+			},
+		},
+		{
+			name:   "Map without config",
+			column: types.Column{Name: "prefs", Type: "map"},
+			wantErr: []string{
+				"column prefs (map) requires key_type or map_config.keys",
+				"column prefs (map) requires value_type or map_config.values",
+			},
+		},
+		{
+			name:    "Set without element source",
+			column:  types.Column{Name: "tags", Type: "set", SetConfig: types.SetConfig{MaxElements: 3}},
+			wantErr: []string{"column tags (set) requires element_type, set_config.values, set_config.pattern or set_config.element"},
+		},
+		{
+			name:   "List without bounds",
+			column: types.Column{Name: "phones", Type: "list", ListConfig: types.ListConfig{Pattern: "###"}},
+		},
+		{
+			name:    "List with inverted bounds",
+			column:  types.Column{Name: "phones", Type: "list", ListConfig: types.ListConfig{MinElements: 4, MaxElements: 2, Pattern: "###"}},
+			wantErr: []string{"column phones (list) requires 0 <= list_config.min_elements <= max_elements"},
+		},
+		{
+			name: "Map with negative bounds",
+			column: types.Column{
+				Name:      "prefs",
+				Type:      "map",
+				MapConfig: types.MapConfig{MinEntries: -1, MaxEntries: 2, KeyType: "string", ValueType: "string"},
+			},
+			wantErr: []string{"column prefs (map) requires 0 <= map_config.min_entries <= max_entries"},
+		},
+		{
+			name:    "Set with too few distinct values",
+			column:  types.Column{Name: "tags", Type: "set", SetConfig: types.SetConfig{MinElements: 3, MaxElements: 4, Values: []string{"a", "b", "a"}}},
+			wantErr: []string{"column tags (set) requires at least min_elements (3) distinct set_config.values, got 2"},
+		},
+		{
+			name:    "Set with inverted bounds",
+			column:  types.Column{Name: "tags", Type: "set", SetConfig: types.SetConfig{MinElements: 3, MaxElements: 1, ElementType: "string"}},
+			wantErr: []string{"column tags (set) requires 0 <= set_config.min_elements <= max_elements"},
+		},
+		{
+			name:    "UDT without fields",
+			column:  types.Column{Name: "address", Type: "udt", UDTConfig: types.UDTConfig{Name: "address_type"}},
+			wantErr: []string{"column address (udt) requires udt_config.fields"},
+		},
+		{
+			name:    "Tuple without elements",
+			column:  types.Column{Name: "point", Type: "tuple"},
+			wantErr: []string{"column point (tuple) requires tuple_config.elements"},
+		},
+		{
+			name: "JSON field without name",
+			column: types.Column{
+				Name: "metadata",
+				Type: "json",
+				JSONConfig: types.JSONConfig{
+					{Name: "profile", Type: "object", Fields: types.JSONConfig{{Type: "email"}}},
+				},
+			},
+			wantErr: []string{"column metadata (json) requires json_config[0].fields[0].name"},
+		},
+		{
+			name:   "Objects without fields or bounds",
+			column: types.Column{Name: "items", Type: "objects"},
+			wantErr: []string{
+				"column items (objects) requires objects_config.fields",
+				"column items (objects) requires objects_config.min and max",
+			},
+		},
+		{
+			name: "Objects with inverted bounds",
+			column: types.Column{
+				Name:          "items",
+				Type:          "objects",
+				ObjectsConfig: types.ObjectsConfig{Min: 3, Max: 1, Fields: []types.Column{{Name: "sku"}}},
+			},
+			wantErr: []string{"column items (objects) requires objects_config.min and max"},
+		},
+		{
+			name: "Objects with incomplete nested field",
+			column: types.Column{
+				Name:          "items",
+				Type:          "objects",
+				ObjectsConfig: types.ObjectsConfig{Min: 1, Max: 2, Fields: []types.Column{{Name: "tags", Type: "set"}}},
+			},
+			wantErr: []string{"column items[].tags (set) requires element_type, set_config.values, set_config.pattern or set_config.element"},
+		},
+		{
+			name: "List with element column",
+			column: types.Column{
+				Name:       "scores",
+				Type:       "list",
+				ListConfig: types.ListConfig{MaxElements: 3, Element: &types.Column{Type: "int", Range: types.Range{Min: 1, Max: 10}}},
+			},
+		},
+		{
+			name: "Set with incomplete element column",
+			column: types.Column{
+				Name:      "prefs",
+				Type:      "set",
+				SetConfig: types.SetConfig{MaxElements: 3, Element: &types.Column{Type: "map"}},
+			},
+			wantErr: []string{
+				"column prefs[] (map) requires key_type or map_config.keys",
+				"column prefs[] (map) requires value_type or map_config.values",
+			},
+		},
+		{
+			name:   "Phone with pattern format",
+			column: types.Column{Name: "mobile", Type: "phone", Format: "+44 7### ######"},
+		},
+		{
+			name:    "Phone with unknown format",
+			column:  types.Column{Name: "mobile", Type: "phone", Format: "international"},
+			wantErr: []string{"column mobile (phone) requires format national, e164 or a pattern containing #"},
+		},
+		{
+			name:    "Luhn without pattern",
+			column:  types.Column{Name: "account", Type: "string", Luhn: true},
+			wantErr: []string{"column account (string) requires a pattern containing # for luhn"},
+		},
+		{
+			name:    "Credit card with unknown network",
+			column:  types.Column{Name: "card", Type: "creditcard", Network: "discover"},
+			wantErr: []string{"column card (creditcard) requires network visa, mastercard or amex"},
+		},
+		{
+			name:    "Width on a string column",
+			column:  types.Column{Name: "account", Type: "string", Width: 7},
+			wantErr: []string{"column account (string) requires type int, bigint or long and a positive width for zero padding"},
+		},
+		{
+			name: "Values file on an int column with a negative weight",
+			column: types.Column{Name: "count", Type: "int", ValuesFiles: []types.ValuesFile{
+				{Path: "counts.txt", Weight: -1, Values: []string{"1"}},
+			}},
+			wantErr: []string{
+				"column count (int) requires type string for values_file",
+				"column count (int) requires a weight of 0 or more for values_file counts.txt",
+			},
+		},
+		{
+			name:    "Values file with values",
+			column:  types.Column{Name: "status", Value: []string{"active"}, ValuesFiles: []types.ValuesFile{{Path: "statuses.txt", Values: []string{"closed"}}}},
+			wantErr: []string{"column status () requires values_file without value, enum or pattern"},
+		},
+		{
+			name:    "Foreign filter without foreign",
+			column:  types.Column{Name: "customer_id", ForeignFilter: "parent.customers.status == 'active'"},
+			wantErr: []string{"column customer_id () requires foreign for foreign_filter"},
+		},
+		{
+			name:    "Exclusive bound on a string column",
+			column:  types.Column{Name: "code", Type: "string", Range: types.Range{ExclusiveMax: true}},
+			wantErr: []string{"column code (string) requires type int, bigint, long, float or decimal for exclusive_min or exclusive_max"},
+		},
+		{
+			name:    "Exclusive bounds leaving no integer",
+			column:  types.Column{Name: "count", Type: "int", Range: types.Range{Min: 1, Max: 2, ExclusiveMin: true, ExclusiveMax: true}},
+			wantErr: []string{"column count (int) requires a range holding values between its exclusive bounds"},
+		},
+		{
+			name:    "Exclusive bounds leaving no float",
+			column:  types.Column{Name: "ratio", Type: "float", Range: types.Range{Min: 1.5, Max: 1.5, ExclusiveMax: true}},
+			wantErr: []string{"column ratio (float) requires a range holding values between its exclusive bounds"},
+		},
+		{
+			name:   "Exclusive bounds around one integer",
+			column: types.Column{Name: "count", Type: "int", Range: types.Range{Min: 1, Max: 3, ExclusiveMin: true, ExclusiveMax: true}},
+		},
+		{
+			name:    "Inverted timestamp range",
+			column:  types.Column{Name: "ts", Type: "timestamp", Format: "2006-01-02", Range: types.Range{Min: "2025-03-08", Max: "2025-03-01"}},
+			wantErr: []string{"column ts (timestamp) requires range min 2025-03-08 no later than max 2025-03-01"},
+		},
+		{
+			name:   "Equal date range",
+			column: types.Column{Name: "day", Type: "date", Format: "2006-01-02", Range: types.Range{Min: "2025-03-01", Max: "2025-03-01"}},
+		},
+		{
+			name:    "Step on a string column",
+			column:  types.Column{Name: "code", Type: "string", Range: types.Range{Step: 5}},
+			wantErr: []string{"column code (string) requires type int, bigint, long, float or decimal and a positive step"},
+		},
+		{
+			name:    "Fractional step on an int column",
+			column:  types.Column{Name: "count", Type: "int", Range: types.Range{Step: 2.5}},
+			wantErr: []string{"column count (int) requires a whole number step for integer columns"},
+		},
+		{
+			name:    "Step without a multiple in range",
+			column:  types.Column{Name: "price", Type: "int", Range: types.Range{Min: 11, Max: 14, Step: 5}},
+			wantErr: []string{"column price (int) requires a range holding a multiple of step 5"},
+		},
+		{
+			name:    "Exclusive bound removing the only multiple",
+			column:  types.Column{Name: "weight", Type: "float", Range: types.Range{Min: 0.3, Max: 0.35, Step: 0.1, ExclusiveMin: true}},
+			wantErr: []string{"column weight (float) requires a range holding a multiple of step 0.1"},
+		},
+		{
+			name:   "Float step",
+			column: types.Column{Name: "weight", Type: "float", Range: types.Range{Min: 0.3, Max: 0.35, Step: 0.1}},
+		},
+		{
+			name:    "Scale on an int column",
+			column:  types.Column{Name: "count", Type: "int", Scale: new(int)},
+			wantErr: []string{"column count (int) requires type float or decimal and a scale of -1 or more"},
+		},
+		{
+			name:    "Float with a date format",
+			column:  types.Column{Name: "ratio", Type: "float", Format: "2006-01-02"},
+			wantErr: []string{"column ratio (float) requires format fixed or scientific"},
+		},
+		{
+			name:   "Scientific float",
+			column: types.Column{Name: "mass", Type: "float", Format: "scientific", Scale: new(int)},
+		},
+		{
+			name:    "Sequential mode without values",
+			column:  types.Column{Name: "slot", Type: "string", Mode: "sequential"},
+			wantErr: []string{"column slot (string) requires value, enum or values_file for mode sequential"},
+		},
+		{
+			name:    "Unknown mode",
+			column:  types.Column{Name: "slot", Type: "string", Value: []string{"a"}, Mode: "shuffled"},
+			wantErr: []string{"column slot (string) requires mode random or sequential"},
+		},
+		{
+			name:    "Template with unknown function",
+			column:  types.Column{Name: "greeting", Type: "template", Template: "Hi {{firstname}} {{nickname}}"},
+			wantErr: []string{"column greeting (template) requires a gofakeit function for {{nickname}}"},
+		},
+		{
+			name:    "UUID with unsupported version",
+			column:  types.Column{Name: "id", Type: "uuid", Version: 5},
+			wantErr: []string{"column id (uuid) requires version 4 or 7"},
+		},
+		{
+			name:   "JSON without explicit config",
+			column: types.Column{Name: "metadata", Type: "json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &types.Schema{
+				Tables: []types.Table{{Name: "users", Columns: []types.Column{tt.column}}},
+			}
+
+			err := validateTypeConfig(schema)
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			for _, want := range tt.wantErr {
+				assert.Contains(t, err.Error(), "table users "+want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("Clean manifest", func(t *testing.T) {
+		assert.NoError(t, Validate("../manifest/test.yaml"))
+	})
+
+	t.Run("Manifest with multiple issues", func(t *testing.T) {
+		manifestPath := writeTempManifest(t, `
+tables:
+- name: customers
+  depends_on: orders
+  columns:
+  - name: id
+    parent: true
+  - name: tags
+    type: set
+- name: orders
+  depends_on: customers
+  columns:
+  - name: id
+    parent: true
+  - name: customer_id
+    foreign: customer.id
+  - name: status
+    value: ["NEW", "SHIPPED"]
+    type: status_code
+    rules:
+    - when: "fields.status ==="
+      then:
+        shipped_at: "${upper(}"
+  - name: cancelled_at
+    when: "fields.status =="
+  - name: account_id
+    foreign: customers.id
+    foreign_filter: "parent.customers.status =="
+  rules:
+  - cases:
+    - when: "fields.total >"
+      then:
+        tier: gold
+`)
+
+		err := Validate(manifestPath)
+		assert.Error(t, err)
+		for _, want := range []string{
+			`table orders column status has type "status_code"`,
+			"table customers column tags (set) requires element_type, set_config.values, set_config.pattern or set_config.element",
+			"dependency cycle: customers -> orders -> customers",
+			"table orders column customer_id references unknown table customer",
+			"table orders column status rule 0: when \"fields.status ===\"",
+			"table orders column status rule 0: shipped_at",
+			"table orders column cancelled_at: when \"fields.status ==\"",
+			"table orders column account_id: foreign_filter \"parent.customers.status ==\"",
+			"table orders rule 0 case 0: when \"fields.total >\"",
+		} {
+			assert.Contains(t, err.Error(), want)
+		}
+	})
+
+	t.Run("Inverted time range", func(t *testing.T) {
+		manifestPath := writeTempManifest(t, `
+tables:
+- name: events
+  columns:
+  - name: ts
+    type: timestamp
+    format: "2006-01-02"
+    range:
+      min: "2025-03-08"
+      max: "2025-03-01"
+`)
+
+		want := "table events column ts (timestamp) requires range min 2025-03-08 no later than max 2025-03-01"
+		err := Validate(manifestPath)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), want)
+		}
+		_, err = Generate(manifestPath, 1)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), want)
+		}
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		assert.Error(t, Validate("missing.yaml"))
+	})
+}
+
+func TestValidateDependencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		tables  []types.Table
+		wantErr string
+	}{
+		{
+			name: "Chain without cycle",
+			tables: []types.Table{
+				{Name: "a"},
+				{Name: "b", DependsOn: "a"},
+				{Name: "c", DependsOn: "b"},
+			},
+		},
+		{
+			name:    "Unknown table",
+			tables:  []types.Table{{Name: "a", DependsOn: "missing"}},
+			wantErr: "table a depends on unknown table missing",
+		},
+		{
+			name:    "Self dependency",
+			tables:  []types.Table{{Name: "a", DependsOn: "a"}},
+			wantErr: "dependency cycle: a -> a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDependencies(&types.Schema{Tables: tt.tables})
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateForeignKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		foreign string
+		wantErr string
+	}{
+		{
+			name:    "Valid reference",
+			foreign: "customers.id",
+		},
+		{
+			name:    "Missing table",
+			foreign: "customer.id",
+			wantErr: "table orders column customer_id references unknown table customer",
+		},
+		{
+			name:    "Missing column",
+			foreign: "customers.customer_id",
+			wantErr: "table orders column customer_id references unknown column customers.customer_id",
+		},
+		{
+			name:    "Non-parent column",
+			foreign: "customers.name",
+			wantErr: "table orders column customer_id references customers.name which is not marked parent",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifestPath := writeTempManifest(t, fmt.Sprintf(`
+tables:
+- name: customers
+  priority: 1
+  columns:
+  - name: id
+    parent: true
+  - name: name
+- name: orders
+  columns:
+  - name: id
+  - name: customer_id
+    foreign: %s
+`, tt.foreign))
+
+			_, err := NewGenerator(manifestPath, &MockDataSink{})
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateNullProbabilities(t *testing.T) {
+	table := types.Table{
+		Name: "orders",
+		Columns: []types.Column{
+			{Name: "customer_id", Foreign: "customers.id", NullProbability: 0.2},
+			{Name: "coupon_id", Foreign: "coupons.id", NullProbability: 1.5},
+			{Name: "referrer_id", Foreign: "customers.id", NullProbability: -0.1},
+			{Name: "note", Type: "sentence", NullProbability: 0.5},
+		},
+	}
+
+	err := validateNullProbabilities(&types.Schema{Tables: []types.Table{table}})
+	assert.EqualError(t, err, "null probability validation failed: "+
+		"table orders column coupon_id has null_probability 1.5, expected 0 to 1, "+
+		"table orders column referrer_id has null_probability -0.1, expected 0 to 1, "+
+		"table orders column note has null_probability but no foreign")
+
+	table.Columns = table.Columns[:1]
+	assert.NoError(t, validateNullProbabilities(&types.Schema{Tables: []types.Table{table}}))
+}
+
+func TestValidateChoices(t *testing.T) {
+	table := types.Table{
+		Name:    "addresses",
+		Columns: []types.Column{{Name: "city"}, {Name: "state"}},
+		Choices: []map[string]string{
+			{"city": "San Francisco", "state": "California"},
+			{"city": "Austin", "state": "Texas", "country": "USA"},
+		},
+	}
+
+	err := validateChoices(&types.Schema{Tables: []types.Table{table}})
+	assert.EqualError(t, err, "choice validation failed: table addresses choices[1] sets undeclared column country")
+
+	table.Choices = table.Choices[:1]
+	assert.NoError(t, validateChoices(&types.Schema{Tables: []types.Table{table}}))
+}
+
+func TestValidateLiterals(t *testing.T) {
+	tests := []struct {
+		name    string
+		column  types.Column
+		wantErr string
+	}{
+		{name: "String default", column: types.Column{Name: "status", Default: "NEW"}},
+		{name: "Int default", column: types.Column{Name: "quantity", Type: "int", Default: "1"}},
+		{name: "Bool default", column: types.Column{Name: "active", Type: "bool", Default: "true"}},
+		{
+			name:    "Invalid int default",
+			column:  types.Column{Name: "quantity", Type: "int", Default: "one"},
+			wantErr: `table orders column quantity default "one" is not a valid int`,
+		},
+		{
+			name:    "Invalid float const",
+			column:  types.Column{Name: "price", Type: "float", Const: "free"},
+			wantErr: `table orders column price const "free" is not a valid float`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &types.Schema{Tables: []types.Table{{Name: "orders", Columns: []types.Column{tt.column}}}}
+			err := validateLiterals(schema)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateAggregates(t *testing.T) {
+	tests := []struct {
+		name      string
+		aggregate types.Aggregate
+		wantErr   string
+	}{
+		{
+			name:      "Count",
+			aggregate: types.Aggregate{Function: "count", Foreign: "orders.customer_id"},
+		},
+		{
+			name:      "Sum",
+			aggregate: types.Aggregate{Function: "sum", Foreign: "orders.customer_id", Field: "amount"},
+		},
+		{
+			name:      "Unknown function",
+			aggregate: types.Aggregate{Function: "avg", Foreign: "orders.customer_id"},
+			wantErr:   `table customers column total has aggregate function "avg"`,
+		},
+		{
+			name:      "Not a foreign column",
+			aggregate: types.Aggregate{Function: "count", Foreign: "orders.amount"},
+			wantErr:   `table customers column total aggregates "orders.amount" which is not a foreign column referencing customers`,
+		},
+		{
+			name:      "Sum of unknown field",
+			aggregate: types.Aggregate{Function: "sum", Foreign: "orders.customer_id", Field: "price"},
+			wantErr:   "table customers column total sums unknown column orders.price",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &types.Schema{
+				Tables: []types.Table{
+					{
+						Name: "customers",
+						Columns: []types.Column{
+							{Name: "id", Parent: true},
+							{Name: "total", Aggregate: tt.aggregate},
+						},
+					},
+					{
+						Name: "orders",
+						Columns: []types.Column{
+							{Name: "customer_id", Foreign: "customers.id"},
+							{Name: "amount", Type: "int"},
+						},
+					},
+				},
+			}
+
+			err := validateAggregates(schema)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateCompositeUnique(t *testing.T) {
+	table := types.Table{
+		Name:    "visits",
+		Columns: []types.Column{{Name: "user_id"}, {Name: "date"}},
+		Unique:  [][]string{{"user_id", "date"}, {"user_id", "day"}, {}},
+	}
+
+	err := validateCompositeUnique(&types.Schema{Tables: []types.Table{table}})
+	assert.EqualError(t, err, "unique constraint validation failed: table visits unique[1] names undeclared column day, table visits unique[2] has no columns")
+
+	table.Unique = table.Unique[:1]
+	assert.NoError(t, validateCompositeUnique(&types.Schema{Tables: []types.Table{table}}))
+}
+
+func TestValidateUniquePerParent(t *testing.T) {
+	table := types.Table{
+		Name: "order_lines",
+		Columns: []types.Column{
+			{Name: "order_id", Foreign: "orders.id"},
+			{Name: "sku"},
+			{Name: "line_number", Type: "int", Validation: types.Validation{UniquePerParent: "order_id"}},
+			{Name: "position", Type: "int", Validation: types.Validation{UniquePerParent: "sku"}},
+			{Name: "slot", Type: "int", Validation: types.Validation{UniquePerParent: "order"}},
+			{Name: "gift_id", Foreign: "gifts.id", When: "fields.sku != nil"},
+			{Name: "gift_line", Type: "int", Validation: types.Validation{UniquePerParent: "gift_id"}},
+		},
+	}
+
+	err := validateUniquePerParent(&types.Schema{Tables: []types.Table{table}})
+	assert.EqualError(t, err, "unique_per_parent validation failed: "+
+		"table order_lines column position is unique per sku which is not a foreign column, "+
+		"table order_lines column slot is unique per undeclared column order, "+
+		"table order_lines column gift_line is unique per gift_id which has a when condition")
+
+	table.Columns = table.Columns[:3]
+	assert.NoError(t, validateUniquePerParent(&types.Schema{Tables: []types.Table{table}}))
+}
+
+func TestValidateUniqueOverwrites(t *testing.T) {
+	table := types.Table{
+		Name: "accounts",
+		Columns: []types.Column{
+			{Name: "id", Type: "int", Width: 8, Validation: types.Validation{Unique: true}},
+			{Name: "tier", Validation: types.Validation{Unique: true}},
+			{Name: "card", Mask: types.Mask{Strategy: "last4"}, Validation: types.Validation{Unique: true}},
+			{Name: "email", Validation: types.Validation{Unique: true}},
+			{Name: "region"},
+			{Name: "code", Rules: []types.Rule{{When: "true", Cases: []types.RuleCase{{When: "true", Then: map[string]string{"code": "X"}}}}}},
+			{Name: "status", Mask: types.Mask{Strategy: "fixed", Value: "-"}},
+			{Name: "slot", Value: []string{"a", "b"}, Mode: "sequential", Validation: types.Validation{UniquePerParent: "region"}},
+			{Name: "shift", Value: []string{"a", "b"}, Mode: "sequential"},
+		},
+		Choices: []map[string]string{{"tier": "gold", "region": "eu"}},
+		Rules:   []types.Rule{{When: "true", Then: map[string]string{"email": "a@example.com"}}},
+		Unique:  [][]string{{"region", "code"}, {"email", "shift"}},
+	}
+
+	err := validateUniqueOverwrites(&types.Schema{Tables: []types.Table{table}})
+	assert.EqualError(t, err, "unique validation failed: "+
+		"table accounts column tier is unique but choices set it after uniqueness is checked, "+
+		"table accounts column card is unique but a mask replaces it after uniqueness is checked, "+
+		"table accounts column email is unique but a rule sets it after uniqueness is checked, "+
+		"table accounts column region is unique but choices set it after uniqueness is checked, "+
+		"table accounts column code is unique but a rule sets it after uniqueness is checked, "+
+		"table accounts column slot is unique but mode sequential replaces it after uniqueness is checked, "+
+		"table accounts column shift is unique but mode sequential replaces it after uniqueness is checked")
+
+	// Padded numbers and masked columns that need not be unique are fine
+	table = types.Table{Name: "accounts", Columns: []types.Column{table.Columns[0], table.Columns[6]}}
+	assert.NoError(t, validateUniqueOverwrites(&types.Schema{Tables: []types.Table{table}}))
+}
+
+func TestValidateSoftDeletes(t *testing.T) {
+	table := types.Table{
+		Name: "accounts",
+		Columns: []types.Column{
+			{Name: "created_at", Type: "timestamp"},
+			{Name: "is_deleted", Type: "bool"},
+			{Name: "status"},
+			{Name: "deleted_at", Type: "timestamp", After: types.After{Column: "created_at"}, SoftDelete: &types.SoftDelete{Flag: "is_deleted", Probability: 0.1}},
+			{Name: "closed_at", Type: "timestamp", SoftDelete: &types.SoftDelete{Flag: "status", Probability: 1.5}},
+			{Name: "purged_at", Type: "timestamp", After: types.After{Column: "created_at"}, SoftDelete: &types.SoftDelete{Flag: "purged"}},
+		},
+	}
+
+	err := validateSoftDeletes(&types.Schema{Tables: []types.Table{table}})
+	assert.EqualError(t, err, "soft_delete validation failed: "+
+		"table accounts column closed_at needs after, naming the creation time the deletion follows, "+
+		"table accounts column closed_at has soft_delete probability 1.5, expected 0 to 1, "+
+		"table accounts column closed_at flags status which is not a bool, "+
+		"table accounts column purged_at flags unknown column purged")
+
+	table.Columns = table.Columns[:4]
+	assert.NoError(t, validateSoftDeletes(&types.Schema{Tables: []types.Table{table}}))
+}
+
+func TestValidateAfter(t *testing.T) {
+	table := types.Table{
+		Name: "events",
+		Columns: []types.Column{
+			{Name: "updated_on", Type: "timestamp", After: types.After{Column: "created_on"}},
+			{Name: "created_on", Type: "timestamp"},
+			{Name: "label", Type: "string"},
+			{Name: "deleted_on", Type: "timestamp", After: types.After{Column: "label", MaxOffset: "soon"}},
+			{Name: "archived", Type: "date", After: types.After{Column: "created_on"}},
+		},
+	}
+
+	err := validateAfter(&types.Schema{Tables: []types.Table{table}})
+	assert.EqualError(t, err, "after validation failed: "+
+		`table events column updated_on follows "created_on" which is not declared before it, `+
+		"table events column deleted_on follows label which is not a timestamp, "+
+		`table events column deleted_on has invalid max_offset: time: invalid duration "soon", `+
+		`table events column archived has type "date", after needs a timestamp`)
+
+	table.Columns = []types.Column{
+		{Name: "created_on", Type: "timestamp"},
+		{Name: "updated_on", Type: "timestamp", After: types.After{Column: "created_on", MaxOffset: "1h"}},
+	}
+	assert.NoError(t, validateAfter(&types.Schema{Tables: []types.Table{table}}))
+}
+
+func TestValidateCounters(t *testing.T) {
+	table := types.Table{
+		Name: "ledger",
+		Columns: []types.Column{
+			{Name: "memo", Type: "string"},
+			{Name: "balance", Type: "int", Counter: &types.Counter{Start: 10.5, Delta: "amount"}},
+			{Name: "label", Type: "string", Counter: &types.Counter{}},
+			{Name: "total", Type: "float", Counter: &types.Counter{Delta: "memo"}},
+		},
+	}
+
+	err := validateCounters(&types.Schema{Tables: []types.Table{table}})
+	assert.EqualError(t, err, "counter validation failed: "+
+		"table ledger column balance starts at 10.5, counter start must be a whole number for integer columns, "+
+		"table ledger column balance adds unknown column amount, "+
+		`table ledger column label has type "string", counter needs int, bigint, long, float or decimal, `+
+		"table ledger column total adds memo which is not numeric")
+
+	table.Columns = []types.Column{
+		{Name: "amount", Type: "decimal"},
+		{Name: "balance", Type: "float", Counter: &types.Counter{Start: 100, Delta: "amount"}},
+	}
+	assert.NoError(t, validateCounters(&types.Schema{Tables: []types.Table{table}}))
+}
+
+func TestValidateHashes(t *testing.T) {
+	table := types.Table{
+		Name: "users",
+		Columns: []types.Column{
+			{Name: "email"},
+			{Name: "key", Type: "hash", HashConfig: types.HashConfig{Fields: []string{"email", "phone"}, Algorithm: "crc32"}},
+			{Name: "digest", Type: "hash", HashConfig: types.HashConfig{Fields: []string{"digest"}, Encoding: "base32"}},
+		},
+	}
+
+	err := validateHashes(&types.Schema{Tables: []types.Table{table}})
+	assert.EqualError(t, err, "hash validation failed: "+
+		"table users column key hashes unknown column phone, "+
+		`table users column key has algorithm "crc32", use sha256, sha1 or md5, `+
+		"table users column digest hashes unknown column digest, "+
+		`table users column digest has encoding "base32", use hex or base64`)
+
+	table.Columns = table.Columns[:2]
+	table.Columns[1].HashConfig = types.HashConfig{Fields: []string{"email"}, Algorithm: "sha1", Encoding: "base64"}
+	assert.NoError(t, validateHashes(&types.Schema{Tables: []types.Table{table}}))
+}
+
+func TestValidateMasks(t *testing.T) {
+	table := types.Table{
+		Name: "users",
+		Columns: []types.Column{
+			{Name: "ssn", Mask: types.Mask{Strategy: "fixed"}},
+			{Name: "age", Type: "int", Mask: types.Mask{Strategy: "last4"}},
+			{Name: "card", Mask: types.Mask{Strategy: "first6", Char: "**"}},
+		},
+	}
+
+	err := validateMasks(&types.Schema{Tables: []types.Table{table}})
+	assert.EqualError(t, err, "mask validation failed: "+
+		"table users column ssn fixed mask needs a value, "+
+		`table users column age has type "int", masks apply to strings, `+
+		`table users column card has mask strategy "first6", use last4, email or fixed, `+
+		`table users column card mask char "**" must be a single character`)
+
+	table.Columns = []types.Column{
+		{Name: "ssn", Type: "string", Mask: types.Mask{Strategy: "fixed", Value: "XXX-XX-XXXX"}},
+		{Name: "email", Mask: types.Mask{Strategy: "email", Char: "#"}},
+	}
+	assert.NoError(t, validateMasks(&types.Schema{Tables: []types.Table{table}}))
+}
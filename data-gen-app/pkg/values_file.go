@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sujanks/data-gen-app/pkg/types"
+)
+
+// loadValuesFiles reads the values files of every column and its nested fields,
+// relative paths from dir, the manifest's directory. Each non-blank line is one
+// value. Without a dir, as for manifests not read from disk, values files are refused.
+func loadValuesFiles(schema *types.Schema, dir string) error {
+	var problems []string
+	for t := range schema.Tables {
+		table := &schema.Tables[t]
+		for c := range table.Columns {
+			problems = append(problems, loadColumnValuesFiles(dir, table.Name, table.Columns[c].Name, &table.Columns[c])...)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("values file loading failed: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// loadColumnValuesFiles reads the values files of col and its nested columns in place
+func loadColumnValuesFiles(dir string, tableName string, path string, col *types.Column) []string {
+	var problems []string
+	for i := range col.ValuesFiles {
+		file := &col.ValuesFiles[i]
+		values, err := readValuesFile(file.Path, dir)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("table %s column %s: %v", tableName, path, err))
+			continue
+		}
+		file.Values = values
+	}
+
+	for i := range col.UDTConfig.Fields {
+		field := &col.UDTConfig.Fields[i]
+		problems = append(problems, loadColumnValuesFiles(dir, tableName, path+"."+field.Name, field)...)
+	}
+	for i := range col.TupleConfig.Elements {
+		problems = append(problems, loadColumnValuesFiles(dir, tableName, fmt.Sprintf("%s[%d]", path, i), &col.TupleConfig.Elements[i])...)
+	}
+	for i := range col.ObjectsConfig.Fields {
+		field := &col.ObjectsConfig.Fields[i]
+		problems = append(problems, loadColumnValuesFiles(dir, tableName, path+"[]."+field.Name, field)...)
+	}
+	if col.ListConfig.Element != nil {
+		problems = append(problems, loadColumnValuesFiles(dir, tableName, path+"[]", col.ListConfig.Element)...)
+	}
+	if col.SetConfig.Element != nil {
+		problems = append(problems, loadColumnValuesFiles(dir, tableName, path+"[]", col.SetConfig.Element)...)
+	}
+	return problems
+}
+
+// readValuesFile returns the trimmed, non-blank lines of the values file at path,
+// which must stay inside dir. Files that are missing, unreadable or reached through
+// a symlink leaving dir all report the same error, so a manifest can't probe the
+// file system.
+func readValuesFile(path string, dir string) ([]string, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("values file %s is not allowed in a manifest without a directory", path)
+	}
+	if !filepath.IsLocal(path) {
+		return nil, fmt.Errorf("values file %s must be a relative path inside the manifest directory", path)
+	}
+	file, err := openInDir(dir, path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read values file %s", path)
+	}
+	defer file.Close()
+
+	var values []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			values = append(values, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read values file %s", path)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("values file %s has no values", path)
+	}
+	return values, nil
+}
+
+// openInDir opens the local path under dir, refusing files whose symlinks resolve outside dir
+func openInDir(dir string, path string) (*os.File, error) {
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, err
+	}
+	target, err := filepath.EvalSymlinks(filepath.Join(root, path))
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(root, target)
+	if err != nil || !filepath.IsLocal(rel) {
+		return nil, fmt.Errorf("%s is outside %s", path, dir)
+	}
+	return os.Open(target)
+}
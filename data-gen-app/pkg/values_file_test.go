@@ -0,0 +1,133 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValuesFiles(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: people
+  columns:
+  - name: first_name
+    values_file:
+    - path: names_1980s.txt
+      weight: 3
+    - path: names/1990s.txt
+`)
+	dir := filepath.Dir(manifestPath)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "names_1980s.txt"), []byte("Jennifer\nMichael\n\n  Jessica  \n"), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "names"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "names", "1990s.txt"), []byte("Ashley\nTyler\n"), 0644))
+
+	records, err := Generate(manifestPath, 4000, WithLocale("de"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, record := range records["people"] {
+		counts[record["first_name"].(string)]++
+	}
+	assert.Len(t, counts, 5)
+	for _, name := range []string{"Jennifer", "Michael", "Jessica", "Ashley", "Tyler"} {
+		assert.Positive(t, counts[name], name)
+	}
+
+	// The 1980s file has three times the weight of the 1990s file
+	eighties := float64(counts["Jennifer"]+counts["Michael"]+counts["Jessica"]) / 4000
+	assert.InDelta(t, 0.75, eighties, 0.05)
+}
+
+func TestValuesFilesProblems(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: people
+  columns:
+  - name: first_name
+    values_file:
+    - path: missing.txt
+  - name: nickname
+    values_file:
+    - path: empty.txt
+  - name: last_name
+    values_file:
+    - path: ../names.txt
+  - name: title
+    values_file:
+    - path: /etc/hostname
+  - name: city
+    values_file:
+    - path: outside.txt
+`)
+	dir := filepath.Dir(manifestPath)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "empty.txt"), []byte("\n  \n"), 0644))
+	// A symlink leaving the manifest directory is refused like a missing file
+	outside := filepath.Join(t.TempDir(), "cities.txt")
+	assert.NoError(t, os.WriteFile(outside, []byte("Paris\n"), 0644))
+	assert.NoError(t, os.Symlink(outside, filepath.Join(dir, "outside.txt")))
+
+	_, err := LoadSchema(manifestPath)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "table people column first_name: cannot read values file missing.txt")
+		assert.Contains(t, err.Error(), "empty.txt has no values")
+		assert.Contains(t, err.Error(), "values file ../names.txt must be a relative path inside the manifest directory")
+		assert.Contains(t, err.Error(), "values file /etc/hostname must be a relative path inside the manifest directory")
+		assert.Contains(t, err.Error(), "table people column city: cannot read values file outside.txt")
+		assert.NotContains(t, err.Error(), "no such file")
+	}
+
+	// Manifests without a directory, such as those posted to the server, can't use values files
+	_, err = LoadSchemaFrom(strings.NewReader(`
+tables:
+- name: people
+  columns:
+  - name: first_name
+    values_file:
+    - path: names.txt
+`), "")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "values file names.txt is not allowed in a manifest without a directory")
+	}
+}
+
+func TestValuesFilesSequentialAndNested(t *testing.T) {
+	manifestPath := writeTempManifest(t, `
+tables:
+- name: people
+  columns:
+  - name: first_name
+    mode: sequential
+    values_file:
+    - path: a.txt
+    - path: b.txt
+  - name: address
+    type: udt
+    udt_config:
+      fields:
+      - name: city
+        values_file:
+        - path: b.txt
+`)
+	dir := filepath.Dir(manifestPath)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("Ann\nBob\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("Cy\n"), 0644))
+
+	records, err := Generate(manifestPath, 5)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var names []string
+	for _, record := range records["people"] {
+		names = append(names, record["first_name"].(string))
+	}
+	// Sequential columns cycle through the files one after the other
+	assert.Equal(t, []string{"Ann", "Bob", "Cy", "Ann", "Bob"}, names)
+	// Nested fields read their values files too
+	assert.Equal(t, "Cy", records["people"][0]["address"].(map[string]interface{})["city"])
+}